@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/store"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
 )
 
@@ -19,7 +20,7 @@ func TestNewRootCommand_SubcommandRegistration(t *testing.T) {
 	cmds := root.Commands()
 	want := map[string]bool{
 		"init": false, "attest": false, "sign": false, "publish": false,
-		"verify": false, "gate": false, "report": false, "demo": false, "webhook": false,
+		"verify": false, "gate": false, "policy": false, "store": false, "export": false, "import": false, "mirror": false, "report": false, "demo": false, "webhook": false,
 	}
 	for _, c := range cmds {
 		want[c.Name()] = true
@@ -168,7 +169,7 @@ func TestSignCommand_AutoOutputPath(t *testing.T) {
 
 func TestVerifyCommand_UnsupportedSource(t *testing.T) {
 	cmd := newVerifyCommand()
-	cmd.SetArgs([]string{"--source", "s3", "--attestations", "ref"})
+	cmd.SetArgs([]string{"--source", "ftp", "--attestations", "ref"})
 	err := cmd.Execute()
 	if err == nil {
 		t.Fatal("expected error for unsupported source")
@@ -268,7 +269,7 @@ func TestVerifyCommand_OCIPullError(t *testing.T) {
 
 	originalPull := ociPullFunc
 	t.Cleanup(func() { ociPullFunc = originalPull })
-	ociPullFunc = func(_ string, _ string) error {
+	ociPullFunc = func(_ string, _ string, _ store.RegistryOptions) error {
 		return errors.New("mock OCI pull failed")
 	}
 
@@ -307,7 +308,7 @@ func TestGateCommand_UnsupportedSource(t *testing.T) {
 	os.WriteFile(policyPath, []byte("version: 1\ngates: []\n"), 0o644)
 
 	cmd := newGateCommand()
-	cmd.SetArgs([]string{"--source", "s3", "--policy", policyPath})
+	cmd.SetArgs([]string{"--source", "ftp", "--policy", policyPath})
 	err := cmd.Execute()
 	if err == nil {
 		t.Fatal("expected error for unsupported source")
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	policyyaml "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/report"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
 )
@@ -82,6 +86,115 @@ func TestAttestCreateCommand_PromptAttestation(t *testing.T) {
 	}
 }
 
+func TestAttestCreateCommand_DependsOnFromPolicyChain(t *testing.T) {
+	root := repoRoot(t)
+	outDir := t.TempDir()
+	policy := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policy, []byte(`version: 1
+gates: []
+chain:
+  rules:
+    - type: eval_attestation
+      requires: [prompt_attestation, corpus_attestation]
+  services:
+    chatbot:
+      - type: eval_attestation
+        requires: [prompt_attestation]
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newAttestCommand()
+	cmd.SetArgs([]string{
+		"create",
+		"--type", "eval_attestation",
+		"--config", filepath.Join(root, "examples", "tiny-rag", "configs", "eval.yaml"),
+		"--policy", policy,
+		"--service", "chatbot",
+		"--out", outDir,
+		"--skip-upstream-pins",
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("attest create: %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(outDir, "statement_*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one statement, got %v", matches)
+	}
+	var st struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	raw, _ := os.ReadFile(matches[0])
+	if err := json.Unmarshal(raw, &st); err != nil {
+		t.Fatal(err)
+	}
+	if got := st.Annotations["depends_on"]; got != "prompt_attestation" {
+		t.Fatalf("depends_on = %q, want the chatbot rule's prompt_attestation only", got)
+	}
+}
+
+func TestAttestCreateCommand_RejectsTamperedUpstreamBundle(t *testing.T) {
+	root := repoRoot(t)
+	store := t.TempDir()
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	evalCmd := func() error {
+		cmd := newAttestCommand()
+		cmd.SetArgs([]string{
+			"create",
+			"--type", "eval_attestation",
+			"--config", filepath.Join(root, "examples", "tiny-rag", "configs", "eval.yaml"),
+			"--out", t.TempDir(),
+			"--store", store,
+		})
+		return cmd.Execute()
+	}
+	if err := evalCmd(); err == nil || !strings.Contains(err.Error(), "no bundle for corpus_attestation, prompt_attestation") {
+		t.Fatalf("expected missing upstream error, got %v", err)
+	}
+
+	for _, attType := range []string{"prompt", "corpus"} {
+		outDir := t.TempDir()
+		createCmd := newAttestCommand()
+		createCmd.SetArgs([]string{
+			"create",
+			"--type", attType + "_attestation",
+			"--config", filepath.Join(root, "examples", "tiny-rag", "configs", attType+".yaml"),
+			"--out", outDir,
+		})
+		if err := createCmd.Execute(); err != nil {
+			t.Fatalf("attest create %s: %v", attType, err)
+		}
+		statements, _ := filepath.Glob(filepath.Join(outDir, "statement_*.json"))
+		signCmd := newSignCommand()
+		signCmd.SetArgs([]string{"--in", statements[0], "--provider", "pem", "--key", keyPath, "--out", store})
+		if err := signCmd.Execute(); err != nil {
+			t.Fatalf("sign %s: %v", attType, err)
+		}
+	}
+	if err := evalCmd(); err != nil {
+		t.Fatalf("attest create eval: %v", err)
+	}
+
+	bundles, _ := filepath.Glob(filepath.Join(store, "*.bundle.json"))
+	for _, path := range bundles {
+		bundle, err := sign.ReadBundle(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundle.Envelope.Signatures[0].Sig = base64.StdEncoding.EncodeToString(make([]byte, 64))
+		if err := sign.WriteBundle(path, bundle); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := evalCmd(); err == nil || !strings.Contains(err.Error(), "verify upstream") {
+		t.Fatalf("expected upstream verification error, got %v", err)
+	}
+}
+
 func TestAttestCreateCommand_MissingFlags(t *testing.T) {
 	cmd := newAttestCommand()
 	cmd.SetArgs([]string{"create"})
@@ -247,6 +360,15 @@ func TestWebhookServeCommand_Help(t *testing.T) {
 	}
 }
 
+func TestWebhookServeCommand_RejectsBadPolicyAtStartup(t *testing.T) {
+	policy := filepath.Join(repoRoot(t), "policy", "examples", "mvp-gates.yaml")
+	cmd := newWebhookCommand()
+	cmd.SetArgs([]string{"serve", "--policy", policy, "--engine", "rego"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "rego policy path") {
+		t.Fatalf("expected startup error for rego without --rego-policy, got %v", err)
+	}
+}
+
 // --- Demo Command ---
 
 func TestDemoRunCommand(t *testing.T) {
@@ -261,6 +383,100 @@ func TestDemoRunCommand(t *testing.T) {
 	}
 }
 
+// --- Policy Test Command ---
+
+func TestPolicyTestCommand(t *testing.T) {
+	root := repoRoot(t)
+	policyArgs := []string{
+		"test",
+		"--policy", filepath.Join(root, "policy", "examples", "mvp-gates.yaml"),
+		"--rego-policy", filepath.Join(root, "policy", "examples", "rego-gates.rego"),
+	}
+
+	cmd := newPolicyCommand()
+	cmd.SetArgs(append(policyArgs, "--cases", filepath.Join(root, "policy", "tests", "mvp-gates"), "--engine", "both", "--parity"))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("policy test on shipped fixtures: %v", err)
+	}
+
+	casesDir := t.TempDir()
+	failing := "changed_files: [release/notes.md]\nstatements: []\nexpect:\n  allow: true\n"
+	if err := os.WriteFile(filepath.Join(casesDir, "release.yaml"), []byte(failing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd = newPolicyCommand()
+	cmd.SetArgs(append(policyArgs, "--cases", casesDir))
+	err := cmd.Execute()
+	var ce cliError
+	if !errors.As(err, &ce) || ce.code != verify.ExitPolicyFail {
+		t.Fatalf("expected policy failure exit code, got %v", err)
+	}
+}
+
+// --- Signed Policies ---
+
+func TestPolicySignAndRequireSignedGate(t *testing.T) {
+	tmp := t.TempDir()
+	keyPath := filepath.Join(tmp, "policy-key.pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sign.NewPEMSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	material, err := signer.Sign([]byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPath := filepath.Join(tmp, "policy-key.pub")
+	if err := os.WriteFile(pubPath, []byte(material.PublicKeyPEM), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policyPath := filepath.Join(tmp, "gates.yaml")
+	if err := os.WriteFile(policyPath, []byte("version: 1\ngates: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	attDir := t.TempDir()
+	gate := func() error {
+		cmd := newGateCommand()
+		cmd.SetArgs([]string{
+			"--policy", policyPath,
+			"--attestations", attDir,
+			"--require-signed-policy",
+			"--policy-trust-key", pubPath,
+		})
+		return cmd.Execute()
+	}
+
+	var ce cliError
+	if err := gate(); !errors.As(err, &ce) || ce.code != verify.ExitSignatureFail {
+		t.Fatalf("expected unsigned policy to fail with signature exit code, got %v", err)
+	}
+
+	signCmd := newPolicyCommand()
+	signCmd.SetArgs([]string{"sign", "--policy", policyPath, "--key", keyPath})
+	if err := signCmd.Execute(); err != nil {
+		t.Fatalf("policy sign: %v", err)
+	}
+	if err := gate(); err != nil {
+		t.Fatalf("gate with signed policy: %v", err)
+	}
+
+	verifyCmd := newPolicyCommand()
+	verifyCmd.SetArgs([]string{"verify", "--policy", policyPath, "--policy-trust-key", pubPath})
+	if err := verifyCmd.Execute(); err != nil {
+		t.Fatalf("policy verify: %v", err)
+	}
+
+	if err := os.WriteFile(policyPath, []byte("version: 1\ngates: []\nplaintext_allowlist: [x]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gate(); !errors.As(err, &ce) || ce.code != verify.ExitSignatureFail {
+		t.Fatalf("expected edited policy to fail, got %v", err)
+	}
+}
+
 // --- Gate Command ---
 
 func TestGateCommand_NoViolations(t *testing.T) {
@@ -282,6 +498,7 @@ func TestGateCommand_NoViolations(t *testing.T) {
 			"--type", attType + "_attestation",
 			"--config", filepath.Join(root, "examples", "tiny-rag", "configs", attType+".yaml"),
 			"--out", outDir,
+			"--store", tmp,
 		})
 		if err := createCmd.Execute(); err != nil {
 			t.Fatalf("attest create %s: %v", attType, err)
@@ -316,6 +533,132 @@ func TestGateCommand_NoViolations(t *testing.T) {
 	}
 }
 
+func TestGateCommand_StructuredOutput(t *testing.T) {
+	tmp := t.TempDir()
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("version: 1\ngates: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bundlePath := writeSignedPromptBundle(t, tmp, "plaintext_explicit")
+	outPath := filepath.Join(tmp, "gate.json")
+
+	cmd := newGateCommand()
+	cmd.SetArgs([]string{
+		"--policy", policyPath,
+		"--attestations", filepath.Dir(bundlePath),
+		"--format", "json",
+		"--out", outPath,
+	})
+	err := cmd.Execute()
+	var ce cliError
+	if !errors.As(err, &ce) || ce.code != verify.ExitPolicyFail {
+		t.Fatalf("expected policy failure exit code, got %v", err)
+	}
+	raw, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gr report.GateReport
+	if err := json.Unmarshal(raw, &gr); err != nil {
+		t.Fatal(err)
+	}
+	if gr.Passed || gr.ExitCode != verify.ExitPolicyFail || gr.Engine != "yaml" || len(gr.Violations) != 1 {
+		t.Fatalf("unexpected gate report: %+v", gr)
+	}
+
+	cmd = newGateCommand()
+	cmd.SetArgs([]string{"--policy", policyPath, "--attestations", filepath.Dir(bundlePath), "--format", "xml"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Fatalf("expected unsupported format error, got %v", err)
+	}
+}
+
+func TestGateCommand_ChangeSources(t *testing.T) {
+	tmp := t.TempDir()
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	policy := "version: 1\ngates:\n  - id: G001\n    trigger_paths: [\"prompts/**\"]\n    required_attestations: [eval_attestation]\n    message: eval missing\n"
+	if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	attDir := filepath.Join(tmp, "attestations")
+	if err := os.MkdirAll(attDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	listPath := filepath.Join(tmp, "changed.txt")
+	if err := os.WriteFile(listPath, []byte("prompts/system.txt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	patchPath := filepath.Join(tmp, "change.patch")
+	if err := os.WriteFile(patchPath, []byte("--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-a\n+b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gate := func(args ...string) error {
+		cmd := newGateCommand()
+		cmd.SetArgs(append([]string{"--policy", policyPath, "--attestations", attDir}, args...))
+		return cmd.Execute()
+	}
+
+	var ce cliError
+	if err := gate("--changed-files-from", listPath); !errors.As(err, &ce) || ce.code != verify.ExitPolicyFail {
+		t.Fatalf("changed file list should trigger G001, got %v", err)
+	}
+	if err := gate("--diff-file", patchPath); err != nil {
+		t.Fatalf("patch without prompt changes should pass, got %v", err)
+	}
+	if err := gate("--git-ref", "no-such-ref-zzzz"); err == nil || !strings.Contains(err.Error(), "changed files") {
+		t.Fatalf("expected git failure to be an error, got %v", err)
+	}
+	if err := gate("--git-ref", "no-such-ref-zzzz", "--allow-no-changes"); err != nil {
+		t.Fatalf("--allow-no-changes should degrade to no changed files, got %v", err)
+	}
+	if err := gate("--changed-files-from", listPath, "--diff-file", patchPath); err == nil {
+		t.Fatal("expected mutually exclusive change sources to be rejected")
+	}
+}
+
+func TestGateCommand_RefAndEnvTriggers(t *testing.T) {
+	tmp := t.TempDir()
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	policy := "version: 1\ngates:\n" +
+		"  - id: G005\n    trigger_refs: [\"refs/tags/v*\"]\n    required_attestations: [slo_attestation]\n    message: release blocked\n" +
+		"  - id: G020\n    trigger_environments: [prod]\n    required_attestations: [route_attestation]\n    message: prod needs route\n"
+	if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	attDir := filepath.Join(tmp, "attestations")
+	if err := os.MkdirAll(attDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gate := func(args ...string) (report.GateReport, error) {
+		outPath := filepath.Join(tmp, "gate.json")
+		cmd := newGateCommand()
+		cmd.SetArgs(append([]string{"--policy", policyPath, "--attestations", attDir, "--allow-no-changes", "--format", "json", "--out", outPath}, args...))
+		err := cmd.Execute()
+		var gr report.GateReport
+		raw, readErr := os.ReadFile(outPath)
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		if jsonErr := json.Unmarshal(raw, &gr); jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		return gr, err
+	}
+
+	gr, err := gate("--ref", "refs/heads/main")
+	if err != nil {
+		t.Fatalf("branch build should not fire release or prod gates: %v", err)
+	}
+	gr, err = gate("--ref", "refs/tags/v1.2.0")
+	if err == nil || gr.Ref != "refs/tags/v1.2.0" || gr.Gates[0].Status != policyyaml.GateFailed || gr.Gates[0].Triggers[0] != "ref:refs/tags/v1.2.0" {
+		t.Fatalf("release tag should fire G005, got %v %+v", err, gr)
+	}
+	gr, err = gate("--ref", "refs/heads/main", "--env", "prod")
+	if err == nil || gr.Environment != "prod" || gr.Gates[1].Status != policyyaml.GateFailed {
+		t.Fatalf("--env prod should fire G020, got %v %+v", err, gr)
+	}
+}
+
 // --- Verify Command Multiple Bundles ---
 
 func TestVerifyCommandLocal_MultipleBundles(t *testing.T) {
@@ -334,6 +677,7 @@ func TestVerifyCommandLocal_MultipleBundles(t *testing.T) {
 			"--type", attType + "_attestation",
 			"--config", filepath.Join(root, "examples", "tiny-rag", "configs", attType+".yaml"),
 			"--out", outDir,
+			"--store", tmp,
 		})
 		if err := createCmd.Execute(); err != nil {
 			t.Fatalf("attest create %s: %v", attType, err)
@@ -391,6 +735,7 @@ func TestAttestCreateCommand_AllFiveTypes(t *testing.T) {
 				"--type", attType + "_attestation",
 				"--config", filepath.Join(root, "examples", "tiny-rag", "configs", attType+".yaml"),
 				"--out", outDir,
+				"--skip-upstream-pins",
 			})
 			if err := cmd.Execute(); err != nil {
 				t.Fatalf("attest create %s: %v", attType, err)
@@ -8,13 +8,21 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/attest"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/changes"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/policytest"
 	policyrego "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/rego"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/signed"
 	policyyaml "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/report"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/semantic"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/store"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
@@ -42,8 +50,12 @@ func main() {
 	}
 }
 
-var ociPullFunc = store.PullOCI
-var ociPublishFunc = store.PublishOCI
+var ociPullFunc = store.PullOCIWithOptions
+var ociPublishFunc = store.PublishOCIWithOptions
+var ociReferrersPullFunc = store.PullReferrers
+var s3BackendFunc = func(bucket, endpoint string) (store.Backend, error) {
+	return store.NewS3Backend(bucket, store.S3Options{Endpoint: endpoint})
+}
 
 func newRootCommand() *cobra.Command {
 	root := &cobra.Command{
@@ -56,6 +68,11 @@ func newRootCommand() *cobra.Command {
 	root.AddCommand(newPublishCommand())
 	root.AddCommand(newVerifyCommand())
 	root.AddCommand(newGateCommand())
+	root.AddCommand(newPolicyCommand())
+	root.AddCommand(newStoreCommand())
+	root.AddCommand(newExportCommand())
+	root.AddCommand(newImportCommand())
+	root.AddCommand(newMirrorCommand())
 	root.AddCommand(newReportCommand())
 	root.AddCommand(newDemoCommand())
 	root.AddCommand(newWebhookCommand())
@@ -100,16 +117,46 @@ func newInitCommand() *cobra.Command {
 func newAttestCommand() *cobra.Command {
 	attestCmd := &cobra.Command{Use: "attest", Short: "Create attestations"}
 
-	var attType, cfgPath, outDir, gitRef string
-	var changedOnly bool
+	var attType, cfgPath, outDir, storeDir string
+	var policyPath, projectConfig, service string
+	var images []string
+	var changedOnly, skipUpstreamPins bool
+	var changeFlags changeSourceFlags
 	var determinismCheck int
 
 	createCmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create statement attestation(s)",
 		RunE: func(_ *cobra.Command, _ []string) error {
+			if !changedOnly && (attType == "" || cfgPath == "") {
+				return fmt.Errorf("--type and --config are required when --changed-only is false")
+			}
+			// Upstream bundles are pinned only once their signatures verify
+			// against the policy's signer identity.
+			signerPolicy := verify.SignerPolicy{}
+			var policyChain *chain.Config
+			if policyPath != "" {
+				pol, err := policyyaml.LoadPolicy(policyPath)
+				if err != nil {
+					return err
+				}
+				policyChain = pol.Chain
+				signerPolicy = verify.SignerPolicy{OIDCIssuer: pol.OIDCIssuer, IdentityRegex: pol.IdentityRegex}
+			}
+			opts := attest.CreateOptions{
+				OutDir:           outDir,
+				DeterminismCheck: determinismCheck,
+				StoreDir:         storeDir,
+				Service:          service,
+				Images:           images,
+				SkipUpstreamPins: skipUpstreamPins,
+				VerifyUpstream: func(_ string, bundle sign.Bundle) error {
+					return verify.VerifySignature(bundle, signerPolicy)
+				},
+			}
 			if changedOnly {
-				files, err := attest.CreateChangedOnly(gitRef, outDir, determinismCheck)
+				opts.Chain = policyChain
+				files, err := attest.CreateChangedFrom(changeFlags.source(), opts)
 				if err != nil {
 					return err
 				}
@@ -118,15 +165,14 @@ func newAttestCommand() *cobra.Command {
 				}
 				return nil
 			}
-			if attType == "" || cfgPath == "" {
-				return fmt.Errorf("--type and --config are required when --changed-only is false")
+			chainConfig, err := loadChainConfig(policyChain, projectConfig)
+			if err != nil {
+				return err
 			}
-			files, err := attest.CreateByType(attest.CreateOptions{
-				Type:             attType,
-				ConfigPath:       cfgPath,
-				OutDir:           outDir,
-				DeterminismCheck: determinismCheck,
-			})
+			opts.Type = attType
+			opts.ConfigPath = cfgPath
+			opts.Chain = chainConfig
+			files, err := attest.CreateByType(opts)
 			if err != nil {
 				return err
 			}
@@ -140,8 +186,14 @@ func newAttestCommand() *cobra.Command {
 	createCmd.Flags().StringVar(&cfgPath, "config", "", "collector config file")
 	createCmd.Flags().StringVar(&outDir, "out", ".llmsa/attestations", "output directory")
 	createCmd.Flags().BoolVar(&changedOnly, "changed-only", false, "create attestations from changed files")
-	createCmd.Flags().StringVar(&gitRef, "git-ref", "HEAD~1", "git reference for changed-only")
+	addChangeSourceFlags(createCmd, &changeFlags)
 	createCmd.Flags().IntVar(&determinismCheck, "determinism-check", 1, "run attest generation multiple times and compare hashes")
+	createCmd.Flags().StringVar(&policyPath, "policy", "", "policy whose chain section depends_on is derived from and whose signer identity upstream bundles must match")
+	createCmd.Flags().StringVar(&projectConfig, "project-config", "llmsa.yaml", "project config whose chain section applies when the policy has none")
+	createCmd.Flags().StringVar(&service, "service", "", "service name selecting per-service chain rule overrides")
+	createCmd.Flags().StringSliceVar(&images, "image", nil, "digest-pinned image (<repo>@sha256:<hex>) to list as a subject, required to attach the bundle to it with publish --subject (repeatable)")
+	createCmd.Flags().StringVar(&storeDir, "store", "", "directory of signed upstream bundles to verify and pin in depends_on_digests (default: --out)")
+	createCmd.Flags().BoolVar(&skipUpstreamPins, "skip-upstream-pins", false, "write depends_on without pinning upstream bundles in depends_on_digests, instead of failing when one is missing; verify then needs --allow-legacy-statements")
 
 	attestCmd.AddCommand(createCmd)
 	return attestCmd
@@ -170,32 +222,13 @@ func newSignCommand() *cobra.Command {
 				return err
 			}
 
-			var material sign.SignMaterial
-			switch provider {
-			case "pem":
-				if keyPath == "" {
-					return fmt.Errorf("--key is required for pem provider")
-				}
-				signer, err := sign.NewPEMSigner(keyPath)
-				if err != nil {
-					return err
-				}
-				material, err = signer.Sign(canonical)
-				if err != nil {
-					return err
-				}
-			case "sigstore":
-				signer := &sign.SigstoreSigner{PEMKeyPath: keyPath, Issuer: oidcIssuer, Identity: oidcIdentity}
-				material, err = signer.Sign(canonical)
-				if err != nil {
-					return err
-				}
-			case "kms":
-				signer := &sign.KMSSigner{}
-				_, err := signer.Sign(canonical)
+			signer, err := newSigner(provider, keyPath, oidcIssuer, oidcIdentity)
+			if err != nil {
+				return err
+			}
+			material, err := signer.Sign(canonical)
+			if err != nil {
 				return err
-			default:
-				return fmt.Errorf("unsupported provider %s", provider)
 			}
 
 			bundle, err := sign.CreateBundle(statement, material)
@@ -223,16 +256,319 @@ func newSignCommand() *cobra.Command {
 	return cmd
 }
 
+// newSigner returns the signing provider selected by --provider.
+func newSigner(provider, keyPath, oidcIssuer, oidcIdentity string) (signed.Signer, error) {
+	switch provider {
+	case "pem":
+		if keyPath == "" {
+			return nil, fmt.Errorf("--key is required for pem provider")
+		}
+		return sign.NewPEMSigner(keyPath)
+	case "sigstore":
+		return &sign.SigstoreSigner{PEMKeyPath: keyPath, Issuer: oidcIssuer, Identity: oidcIdentity}, nil
+	case "kms":
+		return &sign.KMSSigner{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider %s", provider)
+	}
+}
+
+// policyTrustFlags are the signed-policy flags shared by gate, verify and
+// webhook serve.
+type policyTrustFlags struct {
+	require       bool
+	keys          []string
+	issuer        string
+	identityRegex string
+}
+
+func addPolicyTrustFlags(cmd *cobra.Command, f *policyTrustFlags) {
+	cmd.Flags().BoolVar(&f.require, "require-signed-policy", false, "refuse policies without a valid <policy>.bundle.json from a trusted policy signer")
+	cmd.Flags().StringSliceVar(&f.keys, "policy-trust-key", nil, "PEM public key trusted to sign policies (repeatable)")
+	cmd.Flags().StringVar(&f.issuer, "policy-signer-issuer", "", "OIDC issuer trusted to sign policies with sigstore")
+	cmd.Flags().StringVar(&f.identityRegex, "policy-signer-identity-regex", "", "OIDC identity regex trusted to sign policies with sigstore")
+}
+
+func policySignatureOptions(f policyTrustFlags) (signed.Options, error) {
+	if !f.require {
+		return signed.Options{}, nil
+	}
+	trust, err := signed.LoadTrustRoot(f.keys, f.issuer, f.identityRegex)
+	if err != nil {
+		return signed.Options{}, err
+	}
+	return signed.Options{Require: true, Trust: trust}, nil
+}
+
+// readSignedPolicy reads a policy file once and, when signed policies are
+// required, verifies those bytes, exiting with the signature failure code.
+// Callers use the returned bytes rather than reading the file again.
+func readSignedPolicy(f policyTrustFlags, path string) ([]byte, error) {
+	opts, err := policySignatureOptions(f)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.Load(path, opts)
+	if err != nil && opts.Require {
+		return nil, cliError{code: verify.ExitSignatureFail, err: err}
+	}
+	return raw, err
+}
+
+// loadSignedPolicy parses the YAML policy checked by readSignedPolicy.
+func loadSignedPolicy(f policyTrustFlags, path string) (policyyaml.Policy, error) {
+	raw, err := readSignedPolicy(f, path)
+	if err != nil {
+		return policyyaml.Policy{}, err
+	}
+	return policyyaml.LoadPolicyBytes(raw)
+}
+
+// changeSourceFlags select the changed files used by gate triggers and
+// attest create --changed-only.
+type changeSourceFlags struct {
+	gitRef         string
+	filesFrom      string
+	diffFile       string
+	allowNoChanges bool
+}
+
+func addChangeSourceFlags(cmd *cobra.Command, f *changeSourceFlags) {
+	cmd.Flags().StringVar(&f.gitRef, "git-ref", changes.DefaultGitRef, "git ref or range for changed files (ref, base...head from the merge base, or base..head)")
+	cmd.Flags().StringVar(&f.filesFrom, "changed-files-from", "", "read changed files from this list, one path per line (- for stdin)")
+	cmd.Flags().StringVar(&f.diffFile, "diff-file", "", "read changed files from a unified diff or patch file (- for stdin)")
+	cmd.Flags().BoolVar(&f.allowNoChanges, "allow-no-changes", false, "treat git failures as no changed files instead of an error")
+	cmd.MarkFlagsMutuallyExclusive("changed-files-from", "diff-file")
+}
+
+func (f changeSourceFlags) source() changes.Source {
+	return changes.Source{
+		FilesFrom:      f.filesFrom,
+		PatchFile:      f.diffFile,
+		GitRange:       f.gitRef,
+		AllowNoChanges: f.allowNoChanges,
+	}
+}
+
+// registryFlags are the OCI registry flags shared by publish, verify, gate
+// and webhook serve.
+type registryFlags struct {
+	defaultRegistry string
+	registryHost    string
+	username        string
+	tokenEnv        string
+	dockerConfig    string
+	pullSecrets     []string
+	caFile          string
+	insecure        []string
+	retries         int
+	retryDelay      time.Duration
+}
+
+func addRegistryFlags(cmd *cobra.Command, f *registryFlags) {
+	cmd.Flags().StringVar(&f.defaultRegistry, "default-registry", store.DefaultRegistry, "registry for OCI references without a host")
+	cmd.Flags().StringVar(&f.registryHost, "registry-host", "", "registry the explicit username and token are sent to (default the --default-registry)")
+	cmd.Flags().StringVar(&f.username, "registry-username", "", "registry username (default $"+store.RegistryUsernameEnv+")")
+	cmd.Flags().StringVar(&f.tokenEnv, "registry-token-env", store.RegistryTokenEnv, "environment variable holding the registry password or token")
+	cmd.Flags().StringVar(&f.dockerConfig, "docker-config", "", "docker config.json, or the directory holding it, to read registry credentials from")
+	cmd.Flags().StringSliceVar(&f.pullSecrets, "registry-pull-secret", nil, "mounted Kubernetes image pull secret (.dockerconfigjson file or its directory, repeatable)")
+	cmd.Flags().StringVar(&f.caFile, "registry-ca", "", "PEM CA bundle trusted for registry TLS in addition to the system roots")
+	cmd.Flags().StringSliceVar(&f.insecure, "insecure-registry", nil, "registry host reached over plain HTTP or unverified TLS (repeatable)")
+	cmd.Flags().IntVar(&f.retries, "registry-retries", 3, "attempts for transient registry errors")
+	cmd.Flags().DurationVar(&f.retryDelay, "registry-retry-delay", time.Second, "wait before the first registry retry, tripled after each attempt")
+}
+
+func (f registryFlags) options() store.RegistryOptions {
+	username := f.username
+	if username == "" {
+		username = os.Getenv(store.RegistryUsernameEnv)
+	}
+	token := ""
+	if f.tokenEnv != "" {
+		token = os.Getenv(f.tokenEnv)
+	}
+	return store.RegistryOptions{
+		DefaultRegistry:    f.defaultRegistry,
+		RegistryHost:       f.registryHost,
+		Username:           username,
+		Token:              token,
+		DockerConfig:       f.dockerConfig,
+		PullSecrets:        f.pullSecrets,
+		CAFile:             f.caFile,
+		InsecureRegistries: f.insecure,
+		Retries:            f.retries,
+		RetryDelay:         f.retryDelay,
+	}
+}
+
+// bundleSource is where verify and gate read bundles from: kind is --source
+// and path is --attestations (a local path, OCI refs, images or s3://
+// prefixes, depending on kind).
+type bundleSource struct {
+	kind       string
+	path       string
+	storeDir   string
+	query      string
+	s3Endpoint string
+	registry   store.RegistryOptions
+}
+
+// resolveBundleSource returns a directory holding the bundles of src: the
+// local path itself, or a temporary directory, named after command, that the
+// bundles are pulled into. cleanup removes what was pulled.
+func resolveBundleSource(command string, src bundleSource) (dir string, cleanup func(), err error) {
+	var pull func(dir string) error
+	name := src.kind
+	switch {
+	case src.kind == "local":
+		return src.path, func() {}, nil
+	case src.kind == "oci":
+		pull = func(dir string) error { return pullOCI(src.path, dir, src.registry) }
+	case src.kind == "referrers":
+		pull = func(dir string) error { return pullReferrers(src.path, dir, src.registry) }
+	case src.kind == "store":
+		pull = func(dir string) error { return pullStore(src.storeDir, src.query, dir) }
+	case src.kind == "s3":
+		pull = func(dir string) error { return pullS3(src.path, src.s3Endpoint, dir) }
+	case strings.HasPrefix(src.kind, "oci-layout"):
+		name = "layout"
+		pull = func(dir string) error { return pullLayout(src.kind, dir) }
+	default:
+		return "", nil, fmt.Errorf("unsupported source %s", src.kind)
+	}
+	dir, err = os.MkdirTemp("", fmt.Sprintf("llmsa-%s-%s-", name, command))
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	if err := pull(dir); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dir, cleanup, nil
+}
+
+// pullOCI downloads the bundle artifact at each OCI ref in refsCSV into dir.
+func pullOCI(refsCSV, dir string, reg store.RegistryOptions) error {
+	refs := splitCSV(refsCSV)
+	if len(refs) == 0 {
+		return fmt.Errorf("--attestations must include at least one OCI ref for --source oci")
+	}
+	for i, ref := range refs {
+		out := filepath.Join(dir, fmt.Sprintf("oci_%d.bundle.json", i+1))
+		if err := ociPullFunc(ref, out, reg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullReferrers downloads the bundles attached to each image in the CSV list
+// into dir. Bundle manifests embed their subject, so names never collide.
+func pullReferrers(imagesCSV, dir string, reg store.RegistryOptions) error {
+	images := splitCSV(imagesCSV)
+	if len(images) == 0 {
+		return fmt.Errorf("--attestations must include at least one image ref for --source referrers")
+	}
+	for _, image := range images {
+		if _, err := ociReferrersPullFunc(image, dir, reg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullLayout reads the bundles of an oci-layout:<path>[:<tag>] source into
+// dir. Without a tag the layout must hold a single attestation set.
+func pullLayout(source, dir string) error {
+	spec := strings.TrimPrefix(source, "oci-layout:")
+	if spec == source || spec == "" {
+		return fmt.Errorf("--source oci-layout:<path> requires a layout path")
+	}
+	path, tag := spec, ""
+	if i := strings.LastIndex(spec, ":"); i > 0 && !strings.ContainsAny(spec[i+1:], `/\`) {
+		path, tag = spec[:i], spec[i+1:]
+	}
+	_, err := store.PullOCILayout(path, tag, dir)
+	return err
+}
+
+// pullS3 downloads the bundles under each s3://bucket/prefix in urisCSV into
+// dir. With several prefixes each file name is prefixed s3_<n>_ so bundles
+// with the same name do not overwrite each other.
+func pullS3(urisCSV, endpoint, dir string) error {
+	uris := splitCSV(urisCSV)
+	if len(uris) == 0 {
+		return fmt.Errorf("--attestations must include at least one s3:// prefix for --source s3")
+	}
+	for i, uri := range uris {
+		bucket, prefix, err := store.ParseS3URI(uri)
+		if err != nil {
+			return err
+		}
+		b, err := s3BackendFunc(bucket, endpoint)
+		if err != nil {
+			return err
+		}
+		if len(uris) == 1 {
+			_, err = store.PullBundles(b, prefix, dir)
+			return err
+		}
+		sub := filepath.Join(dir, fmt.Sprintf("s3_%d", i+1))
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			return err
+		}
+		paths, err := store.PullBundles(b, prefix, sub)
+		if err != nil {
+			return err
+		}
+		for _, p := range paths {
+			if err := os.Rename(p, filepath.Join(dir, fmt.Sprintf("s3_%d_%s", i+1, filepath.Base(p)))); err != nil {
+				return err
+			}
+		}
+		if err := os.Remove(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func newPublishCommand() *cobra.Command {
-	var inPath, ociRef string
+	var inPath, ociRef, subject, s3URI, s3Endpoint string
+	var registry registryFlags
 	cmd := &cobra.Command{
 		Use:   "publish",
-		Short: "Publish DSSE bundle to OCI",
+		Short: "Publish a DSSE bundle or bundle set to OCI or S3-compatible storage",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			if inPath == "" || ociRef == "" {
+			if s3URI != "" {
+				if inPath == "" {
+					return fmt.Errorf("--in is required")
+				}
+				bucket, prefix, err := store.ParseS3URI(s3URI)
+				if err != nil {
+					return err
+				}
+				b, err := s3BackendFunc(bucket, s3Endpoint)
+				if err != nil {
+					return err
+				}
+				uris, err := store.PublishBundles(b, inPath, prefix)
+				if err != nil {
+					return err
+				}
+				for _, uri := range uris {
+					fmt.Println(uri)
+				}
+				return nil
+			}
+			if subject == "" && (inPath == "" || ociRef == "") {
 				return fmt.Errorf("--in and --oci are required")
 			}
-			pinned, err := ociPublishFunc(inPath, ociRef)
+			if inPath == "" {
+				return fmt.Errorf("--in is required")
+			}
+			pinned, err := ociPublishFunc(inPath, ociRef, store.PublishOptions{Subject: subject, Registry: registry.options()})
 			if err != nil {
 				return err
 			}
@@ -240,13 +576,25 @@ func newPublishCommand() *cobra.Command {
 			return nil
 		},
 	}
-	cmd.Flags().StringVar(&inPath, "in", "", "bundle path")
-	cmd.Flags().StringVar(&ociRef, "oci", "", "OCI destination")
+	cmd.Flags().StringVar(&inPath, "in", "", "bundle path, or a directory whose *.bundle.json files are pushed as one artifact")
+	cmd.Flags().StringVar(&ociRef, "oci", "", "OCI destination (optional with --subject: defaults to the image repository, pushed by digest)")
+	cmd.Flags().StringVar(&subject, "subject", "", "image to attach the bundle to via the OCI referrers API")
+	cmd.Flags().StringVar(&s3URI, "s3", "", "S3 destination s3://bucket/prefix/; each bundle is uploaded as prefix/<file name>, or to the key itself when it ends in .bundle.json")
+	cmd.Flags().StringVar(&s3Endpoint, "s3-endpoint", "", "S3-compatible endpoint (default: $LLMSA_S3_ENDPOINT, then AWS)")
+	cmd.MarkFlagsMutuallyExclusive("s3", "oci")
+	cmd.MarkFlagsMutuallyExclusive("s3", "subject")
+	addRegistryFlags(cmd, &registry)
 	return cmd
 }
 
 func newVerifyCommand() *cobra.Command {
 	var sourceType, sourcePath, policyPath, format, outPath, schemaDir string
+	var subjectRoot, subjectMode, s3Endpoint string
+	var configPath, service string
+	var storeDir, query string
+	var skipSubjects, allowLegacy bool
+	var trustFlags policyTrustFlags
+	var registry registryFlags
 	cmd := &cobra.Command{
 		Use:   "verify",
 		Short: "Verify bundle signatures, schemas, and digests",
@@ -257,39 +605,59 @@ func newVerifyCommand() *cobra.Command {
 			if schemaDir == "" {
 				schemaDir = "schemas/v1"
 			}
+			if skipSubjects {
+				subjectMode = verify.SubjectsSkip
+			}
+			mode, err := verify.ParseSubjectMode(subjectMode)
+			if err != nil {
+				return err
+			}
 			signerPolicy := verify.SignerPolicy{}
+			var policyChain *chain.Config
+			var semanticPolicy semantic.Policy
 			if policyPath != "" {
-				pol, err := policyyaml.LoadPolicy(policyPath)
+				pol, err := loadSignedPolicy(trustFlags, policyPath)
 				if err != nil {
 					return err
 				}
 				signerPolicy.OIDCIssuer = pol.OIDCIssuer
 				signerPolicy.IdentityRegex = pol.IdentityRegex
+				policyChain = pol.Chain
+				semanticPolicy = pol.Semantic
+			}
+			chainConfig, err := loadChainConfig(policyChain, configPath)
+			if err != nil {
+				return err
 			}
 
-			resolvedSource := sourcePath
-			if sourceType == "oci" {
-				tmpDir, err := os.MkdirTemp("", "llmsa-oci-verify-")
-				if err != nil {
-					return err
-				}
-				defer os.RemoveAll(tmpDir)
-				refs := splitCSV(sourcePath)
-				if len(refs) == 0 {
-					return fmt.Errorf("--attestations must include at least one OCI ref for --source oci")
-				}
-				for i, ref := range refs {
-					out := filepath.Join(tmpDir, fmt.Sprintf("oci_%d.bundle.json", i+1))
-					if err := ociPullFunc(ref, out); err != nil {
-						return err
-					}
-				}
-				resolvedSource = tmpDir
-			} else if sourceType != "local" {
-				return fmt.Errorf("unsupported source %s", sourceType)
+			resolvedSource, cleanup, err := resolveBundleSource("verify", bundleSource{
+				kind:       sourceType,
+				path:       sourcePath,
+				storeDir:   storeDir,
+				query:      query,
+				s3Endpoint: s3Endpoint,
+				registry:   registry.options(),
+			})
+			if err != nil {
+				return err
 			}
+			defer cleanup()
 
-			r := verify.Run(verify.Options{SourcePath: resolvedSource, SchemaDir: schemaDir, SignerPolicy: signerPolicy})
+			r := verify.Run(verify.Options{
+				SourcePath:   resolvedSource,
+				SchemaDir:    schemaDir,
+				SignerPolicy: signerPolicy,
+				Subjects: verify.SubjectOptions{
+					Root:       subjectRoot,
+					Mode:       mode,
+					S3Endpoint: s3Endpoint,
+					Registry:   registry.options(),
+				},
+				Chain:                 chainConfig,
+				Service:               service,
+				Semantic:              semanticPolicy,
+				AllowLegacyStatements: allowLegacy,
+			})
 
 			switch format {
 			case "json":
@@ -318,17 +686,56 @@ func newVerifyCommand() *cobra.Command {
 			return nil
 		},
 	}
-	cmd.Flags().StringVar(&sourceType, "source", "local", "source type (local|oci)")
+	cmd.Flags().StringVar(&sourceType, "source", "local", "source type (local|oci|referrers|store|s3|oci-layout:<path>[:<tag>])")
 	cmd.Flags().StringVar(&sourcePath, "attestations", ".llmsa/attestations", "bundle path or directory")
+	cmd.Flags().StringVar(&storeDir, "store-dir", store.DefaultStoreDir, "local store directory for --source store")
+	cmd.Flags().StringVar(&query, "query", "", "store query for --source store, e.g. git_sha=4f2a9c1,latest (empty selects every bundle)")
 	cmd.Flags().StringVar(&policyPath, "policy", "", "policy yaml path")
 	cmd.Flags().StringVar(&format, "format", "json", "output format (json|md)")
 	cmd.Flags().StringVar(&outPath, "out", "", "output report path")
 	cmd.Flags().StringVar(&schemaDir, "schema-dir", "schemas/v1", "schema directory")
+	cmd.Flags().StringVar(&subjectRoot, "subject-root", "", "base directory for relative subject URIs (default: working directory)")
+	cmd.Flags().StringVar(&subjectMode, "subjects", verify.SubjectsRequired, "subject verification mode (required|optional|skip)")
+	cmd.Flags().BoolVar(&skipSubjects, "skip-subjects", false, "skip subject digest verification (same as --subjects=skip)")
+	cmd.Flags().BoolVar(&allowLegacy, "allow-legacy-statements", false, "accept statements signed before predicate binding or upstream pinning, reporting their checks as skipped and edges as unpinned_legacy")
+	cmd.Flags().StringVar(&s3Endpoint, "s3-endpoint", "", "S3-compatible endpoint for s3:// subjects and --source s3 (default: $LLMSA_S3_ENDPOINT)")
+	cmd.Flags().StringVar(&configPath, "config", "llmsa.yaml", "project config whose chain section applies when the policy has none")
+	cmd.Flags().StringVar(&service, "service", "", "service name selecting per-service chain rule overrides")
+	addPolicyTrustFlags(cmd, &trustFlags)
+	addRegistryFlags(cmd, &registry)
 	return cmd
 }
 
+// loadChainConfig picks the provenance chain rules for verify: the policy's
+// chain section wins, then llmsa.yaml's, then the built-in rules (nil).
+func loadChainConfig(policyChain *chain.Config, configPath string) (*chain.Config, error) {
+	if policyChain != nil {
+		return policyChain, nil
+	}
+	if configPath == "" || !fileExists(configPath) {
+		return nil, nil
+	}
+	var cfg attest.ProjectConfig
+	if err := attest.LoadConfig(configPath, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Chain == nil {
+		return nil, nil
+	}
+	if err := cfg.Chain.Validate(); err != nil {
+		return nil, fmt.Errorf("%s chain: %w", configPath, err)
+	}
+	return cfg.Chain, nil
+}
+
 func newGateCommand() *cobra.Command {
-	var policyPath, attestationsPath, gitRef, sourceType, engine, regoPolicyPath string
+	var policyPath, attestationsPath, sourceType, engine, regoPolicyPath, schemaDir string
+	var format, outPath, gitRefName, environment string
+	var storeDir, query, s3Endpoint, verificationPath string
+	var runVerify bool
+	var trustFlags policyTrustFlags
+	var registry registryFlags
+	var changeFlags changeSourceFlags
 	cmd := &cobra.Command{
 		Use:   "gate",
 		Short: "Run policy gates and return non-zero on violations",
@@ -336,82 +743,676 @@ func newGateCommand() *cobra.Command {
 			if policyPath == "" {
 				return fmt.Errorf("--policy is required")
 			}
+			if runVerify && verificationPath != "" {
+				return fmt.Errorf("--verify and --verification are mutually exclusive")
+			}
 			if attestationsPath == "" {
 				attestationsPath = ".llmsa/attestations"
 			}
-			resolvedSource := attestationsPath
-			if sourceType == "oci" {
-				tmpDir, err := os.MkdirTemp("", "llmsa-oci-gate-")
-				if err != nil {
+			resolvedSource, cleanup, err := resolveBundleSource("gate", bundleSource{
+				kind:       sourceType,
+				path:       attestationsPath,
+				storeDir:   storeDir,
+				query:      query,
+				s3Endpoint: s3Endpoint,
+				registry:   registry.options(),
+			})
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			policy, err := loadSignedPolicy(trustFlags, policyPath)
+			if err != nil {
+				return err
+			}
+			var regoModule []byte
+			if engine == "rego" {
+				if regoModule, err = readSignedPolicy(trustFlags, regoPolicyPath); err != nil {
 					return err
 				}
-				defer os.RemoveAll(tmpDir)
-				refs := splitCSV(attestationsPath)
-				if len(refs) == 0 {
-					return fmt.Errorf("--attestations must include at least one OCI ref for --source oci")
-				}
-				for i, ref := range refs {
-					out := filepath.Join(tmpDir, fmt.Sprintf("oci_%d.bundle.json", i+1))
-					if err := ociPullFunc(ref, out); err != nil {
-						return err
-					}
-				}
-				resolvedSource = tmpDir
-			} else if sourceType != "local" {
-				return fmt.Errorf("unsupported source %s", sourceType)
 			}
-			policy, err := policyyaml.LoadPolicy(policyPath)
+			statements, err := policyyaml.LoadStatements(resolvedSource)
 			if err != nil {
 				return err
 			}
-			statements, err := policyyaml.LoadStatements(resolvedSource)
+			switch format {
+			case "text", report.GateFormatJSON, report.GateFormatSARIF, report.GateFormatJUnit, report.GateFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported format %s", format)
+			}
+			changed, err := changes.Resolve(changeFlags.source())
 			if err != nil {
 				return err
 			}
-			violations := []string{}
+			if gitRefName == "" {
+				gitRefName = changes.DetectRef()
+			}
+			trigger := policyyaml.TriggerContext{Ref: gitRefName, Environment: environment}
+			gr := report.GateReport{
+				Engine:        engine,
+				Policy:        policyPath,
+				Ref:           trigger.Ref,
+				Environment:   trigger.Environment,
+				ChangedFiles:  changed,
+				Gates:         []policyyaml.GateResult{},
+				Violations:    []string{},
+				ActiveWaivers: []policyyaml.Waiver{},
+				Waived:        []policyyaml.WaivedViolation{},
+			}
 			switch engine {
 			case "yaml":
-				violations, err = policyyaml.Evaluate(policy, statements, gitRef)
+				ev, err := policyyaml.EvaluateContext(policy, statements, changed, trigger)
 				if err != nil {
 					return err
 				}
+				gr.Gates = ev.Gates
+				gr.Violations = ev.Violations
+				gr.ActiveWaivers = ev.ActiveWaivers
+				gr.Waived = ev.Waived
 			case "rego":
-				changed, err := policyyaml.ChangedFiles(gitRef)
+				bundles, err := policyrego.LoadBundles(resolvedSource)
 				if err != nil {
 					return err
 				}
-				result, err := policyrego.Evaluate(regoPolicyPath, policyrego.BuildInput(policy, statements, changed))
+				// Verification results are only passed to rego on request:
+				// running verify may fetch remote subjects.
+				var verification *verify.Report
+				if verificationPath != "" {
+					verification, err = readVerification(verificationPath, len(bundles))
+					if err != nil {
+						return err
+					}
+				} else if runVerify {
+					r := verify.Run(verify.Options{
+						SourcePath:   resolvedSource,
+						SchemaDir:    schemaDir,
+						SignerPolicy: verify.SignerPolicy{OIDCIssuer: policy.OIDCIssuer, IdentityRegex: policy.IdentityRegex},
+						Subjects:     verify.SubjectOptions{Mode: verify.SubjectsOptional, Registry: registry.options()},
+						Chain:        policy.Chain,
+						Semantic:     policy.Semantic,
+					})
+					verification = &r
+				}
+				input := policyrego.BuildInputWithOptions(policy, statements, changed, policyrego.InputOptions{
+					Bundles:      bundles,
+					Verification: verification,
+					Trigger:      trigger,
+				})
+				result, err := policyrego.EvaluateModule(filepath.Base(regoPolicyPath), regoModule, input)
 				if err != nil {
 					return err
 				}
+				gr.Policy = regoPolicyPath
+				gr.ActiveWaivers = input.Waivers
 				if !result.Allow {
-					violations = append(violations, result.Violations...)
-					if len(violations) == 0 {
-						violations = append(violations, "rego policy denied request")
+					gr.Violations = append(gr.Violations, result.Violations...)
+					if len(gr.Violations) == 0 {
+						gr.Violations = append(gr.Violations, "rego policy denied request")
 					}
 				}
 			default:
 				return fmt.Errorf("unsupported policy engine %s", engine)
 			}
-			if len(violations) > 0 {
-				for _, v := range violations {
+			gr.Passed = len(gr.Violations) == 0
+			if !gr.Passed {
+				gr.ExitCode = verify.ExitPolicyFail
+			}
+
+			// A structured format without --out goes to stdout on its own.
+			if format != "text" && outPath == "" {
+				raw, err := report.BuildGate(format, gr)
+				if err != nil {
+					return err
+				}
+				fmt.Println(strings.TrimRight(string(raw), "\n"))
+			} else {
+				if format != "text" {
+					if err := report.WriteGate(outPath, format, gr); err != nil {
+						return err
+					}
+				}
+				printWaivers(gr.ActiveWaivers, gr.Waived)
+				for _, v := range gr.Violations {
 					fmt.Println(v)
 				}
+				if gr.Passed {
+					fmt.Println("policy gate passed")
+				}
+			}
+			if !gr.Passed {
 				return cliError{code: verify.ExitPolicyFail, err: fmt.Errorf("policy gate failed")}
 			}
-			fmt.Println("policy gate passed")
 			return nil
 		},
 	}
 	cmd.Flags().StringVar(&policyPath, "policy", "", "policy YAML path")
 	cmd.Flags().StringVar(&attestationsPath, "attestations", ".llmsa/attestations", "attestation directory or file")
-	cmd.Flags().StringVar(&sourceType, "source", "local", "attestation source type (local|oci)")
+	cmd.Flags().StringVar(&s3Endpoint, "s3-endpoint", "", "S3-compatible endpoint for --source s3 (default: $LLMSA_S3_ENDPOINT)")
+	cmd.Flags().StringVar(&storeDir, "store-dir", store.DefaultStoreDir, "local store directory for --source store")
+	cmd.Flags().StringVar(&query, "query", "", "store query for --source store, e.g. git_sha=4f2a9c1,latest (empty selects every bundle)")
+	cmd.Flags().StringVar(&sourceType, "source", "local", "attestation source type (local|oci|referrers|store|s3|oci-layout:<path>[:<tag>])")
 	cmd.Flags().StringVar(&engine, "engine", "yaml", "policy engine (yaml|rego)")
 	cmd.Flags().StringVar(&regoPolicyPath, "rego-policy", "policy/examples/rego-gates.rego", "rego policy path (used with --engine rego)")
-	cmd.Flags().StringVar(&gitRef, "git-ref", "HEAD~1", "git reference for changed-file triggers")
+	cmd.Flags().BoolVar(&runVerify, "verify", false, "run verify on the bundles and pass the results to rego as input.verification (may fetch oci:// and s3:// subjects)")
+	cmd.Flags().StringVar(&verificationPath, "verification", "", "pass an existing llmsa verify --format json report for the same bundles to rego as input.verification")
+	cmd.Flags().StringVar(&schemaDir, "schema-dir", "schemas/v1", "schema directory for --verify")
+	addChangeSourceFlags(cmd, &changeFlags)
+	cmd.Flags().StringVar(&gitRefName, "ref", "", "git ref being built for trigger_refs and trigger_branches, e.g. refs/tags/v1.2.0 (default: detected from CI or the checkout)")
+	cmd.Flags().StringVar(&environment, "env", "", "target environment for trigger_environments, e.g. prod")
+	cmd.Flags().StringVar(&format, "format", "text", "result format (text|json|sarif|junit|md)")
+	cmd.Flags().StringVar(&outPath, "out", "", "write the structured result here instead of stdout")
+	addPolicyTrustFlags(cmd, &trustFlags)
+	addRegistryFlags(cmd, &registry)
 	return cmd
 }
 
+// readVerification loads a verify JSON report for the rego input. It must
+// cover as many bundles as the gate evaluates.
+func readVerification(path string, bundles int) (*verify.Report, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read verification: %w", err)
+	}
+	var r verify.Report
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("parse verification %s: %w", path, err)
+	}
+	if r.BundleCount != bundles {
+		return nil, fmt.Errorf("verification %s covers %d bundles, the gate has %d", path, r.BundleCount, bundles)
+	}
+	return &r, nil
+}
+
+// printWaivers lists every active waiver and the violations it suppressed so
+// each bypass shows up in the gate log.
+func printWaivers(active []policyyaml.Waiver, waived []policyyaml.WaivedViolation) {
+	for _, w := range active {
+		fmt.Printf("waiver active: %s (%s) approved by %s until %s: %s\n", w.Gate, w.Scope(), w.Approver, w.Expires, w.Justification)
+	}
+	for _, v := range waived {
+		fmt.Printf("waived: %s [%s]\n", v.Violation, v.Waiver.Gate)
+	}
+}
+
+func newPolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Policy authoring utilities",
+	}
+	var policyPath, regoPolicyPath, casesDir, engine string
+	var parity bool
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run fixture cases against the YAML and/or Rego policy engines",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			policy, err := policyyaml.LoadPolicy(policyPath)
+			if err != nil {
+				return err
+			}
+			cases, err := policytest.LoadCases(casesDir)
+			if err != nil {
+				return err
+			}
+			results, err := policytest.Run(cases, policytest.Options{
+				Policy:     policy,
+				RegoPolicy: regoPolicyPath,
+				Engine:     engine,
+				Parity:     parity,
+			})
+			if err != nil {
+				return err
+			}
+			failed := 0
+			for _, r := range results {
+				status := "PASS"
+				if !r.Passed {
+					status = "FAIL"
+					failed++
+				}
+				fmt.Printf("%s %-6s %s (%s)\n", status, r.Engine, r.Case, r.File)
+				for _, d := range r.Diff {
+					fmt.Printf("    %s\n", d)
+				}
+			}
+			fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+			if failed > 0 {
+				return cliError{code: verify.ExitPolicyFail, err: fmt.Errorf("policy tests failed")}
+			}
+			return nil
+		},
+	}
+	testCmd.Flags().StringVar(&policyPath, "policy", "policy/examples/mvp-gates.yaml", "policy YAML path")
+	testCmd.Flags().StringVar(&regoPolicyPath, "rego-policy", "policy/examples/rego-gates.rego", "rego policy path (used with --engine rego|both or --parity)")
+	testCmd.Flags().StringVar(&casesDir, "cases", "policy/tests/mvp-gates", "directory of fixture case files (.yaml, .yml, .json)")
+	testCmd.Flags().StringVar(&engine, "engine", "yaml", "policy engine (yaml|rego|both)")
+	testCmd.Flags().BoolVar(&parity, "parity", false, "also require the YAML and Rego engines to agree on every case")
+	cmd.AddCommand(testCmd)
+	cmd.AddCommand(newPolicySignCommand())
+	cmd.AddCommand(newPolicyVerifyCommand())
+	return cmd
+}
+
+func newPolicySignCommand() *cobra.Command {
+	var policyPath, provider, keyPath, outPath, oidcIssuer, oidcIdentity string
+	cmd := &cobra.Command{
+		Use:   "sign",
+		Short: "Sign a YAML or Rego policy file into a bundle",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if policyPath == "" {
+				return fmt.Errorf("--policy is required")
+			}
+			signer, err := newSigner(provider, keyPath, oidcIssuer, oidcIdentity)
+			if err != nil {
+				return err
+			}
+			bundle, err := signed.Sign(policyPath, signer)
+			if err != nil {
+				return err
+			}
+			if outPath == "" {
+				outPath = signed.BundlePath(policyPath)
+			}
+			if err := sign.WriteBundle(outPath, bundle); err != nil {
+				return err
+			}
+			fmt.Println(outPath)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&policyPath, "policy", "", "policy file to sign")
+	cmd.Flags().StringVar(&provider, "provider", "pem", "signing provider (sigstore|pem|kms)")
+	cmd.Flags().StringVar(&keyPath, "key", "", "PEM key path")
+	cmd.Flags().StringVar(&outPath, "out", "", "bundle output path (default <policy>.bundle.json)")
+	cmd.Flags().StringVar(&oidcIssuer, "oidc-issuer", "", "sigstore OIDC issuer")
+	cmd.Flags().StringVar(&oidcIdentity, "oidc-identity", "", "sigstore OIDC identity")
+	return cmd
+}
+
+func newPolicyVerifyCommand() *cobra.Command {
+	var policyPath, bundlePath string
+	var trustFlags policyTrustFlags
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify a policy file against its signature bundle and the policy trust root",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if policyPath == "" {
+				return fmt.Errorf("--policy is required")
+			}
+			if bundlePath == "" {
+				bundlePath = signed.BundlePath(policyPath)
+			}
+			trust, err := signed.LoadTrustRoot(trustFlags.keys, trustFlags.issuer, trustFlags.identityRegex)
+			if err != nil {
+				return err
+			}
+			if err := signed.Verify(policyPath, bundlePath, trust); err != nil {
+				return cliError{code: verify.ExitSignatureFail, err: err}
+			}
+			fmt.Println("policy signature verified")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&policyPath, "policy", "", "policy file to verify")
+	cmd.Flags().StringVar(&bundlePath, "bundle", "", "signature bundle (default <policy>.bundle.json)")
+	cmd.Flags().StringSliceVar(&trustFlags.keys, "policy-trust-key", nil, "PEM public key trusted to sign policies (repeatable)")
+	cmd.Flags().StringVar(&trustFlags.issuer, "policy-signer-issuer", "", "OIDC issuer trusted to sign policies with sigstore")
+	cmd.Flags().StringVar(&trustFlags.identityRegex, "policy-signer-identity-regex", "", "OIDC identity regex trusted to sign policies with sigstore")
+	return cmd
+}
+
+// layoutTag picks the attestation set tag from --tag or --image.
+func layoutTag(tag, image string) (string, error) {
+	if image == "" {
+		return tag, nil
+	}
+	return webhook.AttestationTag(image)
+}
+
+func newExportCommand() *cobra.Command {
+	var layoutPath, attestationsPath, tag, image string
+	var policies, trustRoots []string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export bundles, policies and trust roots as an OCI image layout for air-gapped transfer",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if layoutPath == "" {
+				return fmt.Errorf("--oci-layout is required")
+			}
+			bundles, err := bundleArgs(attestationsPath)
+			if err != nil {
+				return err
+			}
+			tag, err := layoutTag(tag, image)
+			if err != nil {
+				return err
+			}
+			if tag == "" {
+				tag = store.DefaultLayoutTag
+			}
+			digest, err := store.ExportOCILayout(layoutPath, tag, store.LayoutContents{
+				Bundles:    bundles,
+				Policies:   policies,
+				TrustRoots: trustRoots,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s:%s@%s\n", layoutPath, tag, digest)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&layoutPath, "oci-layout", "", "OCI image layout to write: a .tar tarball or a directory; an existing layout gains or replaces the tagged set")
+	cmd.Flags().StringVar(&attestationsPath, "attestations", ".llmsa/attestations", "bundle path or directory")
+	cmd.Flags().StringSliceVar(&policies, "policy", nil, "policy file to include, with its <policy>.bundle.json signature when present (repeatable)")
+	cmd.Flags().StringSliceVar(&trustRoots, "trust-root", nil, "trust root to include, e.g. a PEM public key (repeatable)")
+	cmd.Flags().StringVar(&tag, "tag", store.DefaultLayoutTag, "tag of the attestation set in the layout")
+	cmd.Flags().StringVar(&image, "image", "", "tag the set for this image, as the webhook looks it up")
+	cmd.MarkFlagsMutuallyExclusive("tag", "image")
+	return cmd
+}
+
+func newImportCommand() *cobra.Command {
+	var layoutPath, outDir, tag, image string
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import an attestation set from an OCI image layout into <out>/attestations, policies and trust",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if layoutPath == "" {
+				return fmt.Errorf("--oci-layout is required")
+			}
+			tag, err := layoutTag(tag, image)
+			if err != nil {
+				return err
+			}
+			files, err := store.ImportOCILayout(layoutPath, tag, outDir)
+			if err != nil {
+				return err
+			}
+			for _, group := range [][]string{files.Bundles, files.Policies, files.TrustRoots} {
+				for _, p := range group {
+					fmt.Println(p)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&layoutPath, "oci-layout", "", "OCI image layout tarball or directory to read")
+	cmd.Flags().StringVar(&outDir, "out", ".llmsa", "directory receiving attestations/, policies/ and trust/")
+	cmd.Flags().StringVar(&tag, "tag", "", "tag of the attestation set (default: the only set in the layout)")
+	cmd.Flags().StringVar(&image, "image", "", "import the set tagged for this image")
+	cmd.MarkFlagsMutuallyExclusive("tag", "image")
+	return cmd
+}
+
+// mirrorFunc is store.Mirror, replaced in tests.
+var mirrorFunc = store.Mirror
+
+func newMirrorCommand() *cobra.Command {
+	var from, to, policyPath, schemaDir, subjectMode, format string
+	var allowLegacy bool
+	var trustFlags policyTrustFlags
+	var registry registryFlags
+	cmd := &cobra.Command{
+		Use:   "mirror",
+		Short: "Copy attestations between registries by digest, verifying each before it is copied",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if from == "" || to == "" {
+				return fmt.Errorf("--from and --to are required")
+			}
+			if format != "text" && format != "json" {
+				return fmt.Errorf("unsupported format %s", format)
+			}
+			mode, err := verify.ParseSubjectMode(subjectMode)
+			if err != nil {
+				return err
+			}
+			signerPolicy := verify.SignerPolicy{}
+			if policyPath != "" {
+				pol, err := loadSignedPolicy(trustFlags, policyPath)
+				if err != nil {
+					return err
+				}
+				signerPolicy.OIDCIssuer = pol.OIDCIssuer
+				signerPolicy.IdentityRegex = pol.IdentityRegex
+			}
+			results, err := mirrorFunc(from, to, store.MirrorOptions{
+				Registry: registry.options(),
+				Verify: func(dir string) error {
+					return verifyMirrored(verify.Options{
+						SourcePath:            dir,
+						SchemaDir:             schemaDir,
+						SignerPolicy:          signerPolicy,
+						Subjects:              verify.SubjectOptions{Mode: mode, Registry: registry.options()},
+						AllowLegacyStatements: allowLegacy,
+					})
+				},
+			})
+			if err != nil {
+				return err
+			}
+			if format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(results)
+			}
+			for _, r := range results {
+				fmt.Printf("%s -> %s\n", r.Source, r.Destination)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "source: an attestation artifact ref, a repository (every tagged attestation), or an image whose attached attestations are copied")
+	cmd.Flags().StringVar(&to, "to", "", "destination repository, or an artifact ref when --from is one")
+	cmd.Flags().StringVar(&policyPath, "policy", "", "policy yaml whose signer identity each bundle must match")
+	cmd.Flags().StringVar(&schemaDir, "schema-dir", "schemas/v1", "schema directory")
+	cmd.Flags().StringVar(&subjectMode, "subjects", verify.SubjectsSkip, "subject verification mode (required|optional|skip); subjects are rarely local when mirroring")
+	cmd.Flags().StringVar(&format, "format", "text", "output format (text|json)")
+	cmd.Flags().BoolVar(&allowLegacy, "allow-legacy-statements", false, "accept statements signed before predicate binding or upstream pinning, reporting their checks as skipped and edges as unpinned_legacy")
+	addPolicyTrustFlags(cmd, &trustFlags)
+	addRegistryFlags(cmd, &registry)
+	return cmd
+}
+
+// verifyMirrored runs verify over one mirrored group. The provenance chain
+// is left to deploy-time verification: a single artifact rarely carries its
+// predecessors.
+func verifyMirrored(opts verify.Options) error {
+	r := verify.Run(opts)
+	var failures []string
+	for _, c := range r.Checks {
+		if !c.Passed && c.Check != "chain_graph" {
+			failures = append(failures, fmt.Sprintf("%s: %s: %s", filepath.Base(c.Bundle), c.Check, c.Message))
+		}
+	}
+	if r.BundleCount == 0 {
+		failures = append(failures, r.Violations...)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func newStoreCommand() *cobra.Command {
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "store",
+		Short: "Manage the local content-addressed attestation store",
+	}
+	cmd.PersistentFlags().StringVar(&dir, "dir", store.DefaultStoreDir, "store directory")
+
+	addCmd := &cobra.Command{
+		Use:   "add <bundle|dir>...",
+		Short: "Add bundles, or every *.bundle.json in a directory, to the store",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			s := store.OpenLocalStore(dir)
+			for _, arg := range args {
+				paths, err := bundleArgs(arg)
+				if err != nil {
+					return err
+				}
+				for _, p := range paths {
+					e, added, err := s.Add(p)
+					if err != nil {
+						return err
+					}
+					status := "added"
+					if !added {
+						status = "exists"
+					}
+					fmt.Printf("%s %s %s %s\n", status, e.StatementHash, e.AttestationType, e.StatementID)
+				}
+			}
+			return nil
+		},
+	}
+
+	var format string
+	lsCmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List stored bundles, newest first",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			entries, err := store.OpenLocalStore(dir).Entries()
+			if err != nil {
+				return err
+			}
+			return printStoreEntries(entries, format)
+		},
+	}
+	lsCmd.Flags().StringVar(&format, "format", "table", "output format (table|json)")
+
+	queryCmd := &cobra.Command{
+		Use:   "query <expr>",
+		Short: "List stored bundles matching a query, e.g. type=eval_attestation,git_sha=4f2a9c1,latest",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			q, err := store.ParseQuery(args[0])
+			if err != nil {
+				return err
+			}
+			entries, err := store.OpenLocalStore(dir).Query(q)
+			if err != nil {
+				return err
+			}
+			return printStoreEntries(entries, format)
+		},
+	}
+	queryCmd.Flags().StringVar(&format, "format", "table", "output format (table|json)")
+
+	var outPath string
+	getCmd := &cobra.Command{
+		Use:   "get <statement-hash>",
+		Short: "Print a stored bundle, or write it with --out; hash prefixes are accepted",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			s := store.OpenLocalStore(dir)
+			e, err := s.Resolve(args[0])
+			if err != nil {
+				return err
+			}
+			raw, err := os.ReadFile(s.Path(e))
+			if err != nil {
+				return err
+			}
+			if outPath == "" {
+				_, err := os.Stdout.Write(raw)
+				return err
+			}
+			if err := os.WriteFile(outPath, raw, 0o644); err != nil {
+				return err
+			}
+			fmt.Println(outPath)
+			return nil
+		},
+	}
+	getCmd.Flags().StringVar(&outPath, "out", "", "write the bundle to this path")
+
+	var keepLatest int
+	gcCmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove unindexed bundles and dangling entries, optionally keeping only the newest N per type",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			removed, err := store.OpenLocalStore(dir).GC(keepLatest)
+			if err != nil {
+				return err
+			}
+			for _, h := range removed {
+				fmt.Printf("removed %s\n", h)
+			}
+			fmt.Printf("%d bundle(s) removed\n", len(removed))
+			return nil
+		},
+	}
+	gcCmd.Flags().IntVar(&keepLatest, "keep-latest", 0, "keep only the newest N bundles of each attestation type (0 keeps all)")
+
+	cmd.AddCommand(addCmd, lsCmd, queryCmd, getCmd, gcCmd)
+	return cmd
+}
+
+// bundleArgs expands a directory argument to its *.bundle.json files.
+func bundleArgs(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(path, "*.bundle.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func printStoreEntries(entries []store.Entry, format string) error {
+	switch format {
+	case "json":
+		raw, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(raw))
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "HASH\tTYPE\tSTATEMENT ID\tGIT SHA\tGENERATED AT\tCLAIMED SIGNER")
+		for _, e := range entries {
+			short := strings.TrimPrefix(e.StatementHash, "sha256:")
+			if len(short) > 12 {
+				short = short[:12]
+			}
+			gitSHA := e.GitSHA
+			if len(gitSHA) > 12 {
+				gitSHA = gitSHA[:12]
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", short, e.AttestationType, e.StatementID, gitSHA, e.GeneratedAt, e.ClaimedSigner)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unsupported format %s (table|json)", format)
+	}
+	return nil
+}
+
+// pullStore copies the bundles matching query from the local store into dir.
+func pullStore(storeDir, query, dir string) error {
+	q, err := store.ParseQuery(query)
+	if err != nil {
+		return err
+	}
+	entries, err := store.OpenLocalStore(storeDir).Query(q)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("store query %q matched no bundles in %s", query, storeDir)
+	}
+	_, err = store.OpenLocalStore(storeDir).CopyTo(entries, dir)
+	return err
+}
+
 func newReportCommand() *cobra.Command {
 	var inPath, outPath string
 	cmd := &cobra.Command{
@@ -458,7 +1459,7 @@ func newDemoCommand() *cobra.Command {
 }
 
 func canonicalPayload(statement map[string]any) ([]byte, error) {
-	return hash.CanonicalJSON(statement)
+	return hash.Canonicalize(hash.DefaultCanonicalization, statement)
 }
 
 func fileExists(path string) bool {
@@ -538,7 +1539,8 @@ gates:
     required_attestations: ["eval_attestation"]
     message: "Eval config changed without signed eval attestation."
   - id: G005
-    trigger_paths: ["refs/tags/v*"]
+    trigger_paths: ["release/**"]
+    trigger_refs: ["refs/tags/v*"]
     required_attestations: ["prompt_attestation", "corpus_attestation", "eval_attestation", "route_attestation", "slo_attestation"]
     message: "Release blocked: incomplete attestation set."
   - id: G006
@@ -554,14 +1556,30 @@ func newWebhookCommand() *cobra.Command {
 	}
 
 	var port int
-	var tlsCert, tlsKey, policy, schemaDir, registryPrefix string
-	var failOpen bool
+	var tlsCert, tlsKey, policy, schemaDir, registryPrefix, ociLayout, subjectMode string
+	var engine, regoPolicy, environment, policyDir, defaultPolicy string
+	var policyOverrides []string
+	var failOpen, referrers bool
 	var cacheTTLSeconds int
+	var trustFlags policyTrustFlags
+	var registry registryFlags
 
 	serveCmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Start the validating admission webhook server",
 		RunE: func(_ *cobra.Command, _ []string) error {
+			mode, err := verify.ParseSubjectMode(subjectMode)
+			if err != nil {
+				return err
+			}
+			policySignature, err := policySignatureOptions(trustFlags)
+			if err != nil {
+				return err
+			}
+			overrides, err := webhook.ParsePolicyOverrides(policyOverrides)
+			if err != nil {
+				return err
+			}
 			cfg := webhook.Config{
 				Port:            port,
 				TLSCertPath:     tlsCert,
@@ -569,11 +1587,30 @@ func newWebhookCommand() *cobra.Command {
 				PolicyPath:      policy,
 				SchemaDir:       schemaDir,
 				RegistryPrefix:  registryPrefix,
+				Referrers:       referrers,
+				OCILayout:       ociLayout,
+				Registry:        registry.options(),
 				FailOpen:        failOpen,
 				CacheTTLSeconds: cacheTTLSeconds,
+				SubjectMode:     mode,
+				PolicySignature: policySignature,
+				PolicyEngine:    engine,
+				RegoPolicyPath:  regoPolicy,
+				Environment:     environment,
+				PolicyDir:       policyDir,
+				DefaultPolicy:   defaultPolicy,
+				PolicyOverrides: overrides,
+			}
+			if err := cfg.CheckPolicy(); err != nil {
+				return cliError{code: verify.ExitSignatureFail, err: err}
+			}
+			if err := cfg.ValidatePolicy(); err != nil {
+				return err
 			}
 			mux := http.NewServeMux()
-			mux.Handle("/validate", webhook.Handler(cfg))
+			handler := webhook.Handler(cfg)
+			mux.Handle("/validate", handler)
+			mux.Handle("/validate/", handler)
 			mux.Handle("/healthz", webhook.HealthHandler())
 
 			addr := fmt.Sprintf(":%d", cfg.Port)
@@ -587,11 +1624,22 @@ func newWebhookCommand() *cobra.Command {
 	serveCmd.Flags().IntVar(&port, "port", 8443, "webhook listen port")
 	serveCmd.Flags().StringVar(&tlsCert, "tls-cert", "", "TLS certificate path")
 	serveCmd.Flags().StringVar(&tlsKey, "tls-key", "", "TLS key path")
-	serveCmd.Flags().StringVar(&policy, "policy", "", "policy YAML path")
+	serveCmd.Flags().StringVar(&policy, "policy", "", "policy YAML whose signer identity, chain, semantic rules and gates each image's attestations must satisfy")
+	serveCmd.Flags().StringVar(&policyDir, "policy-dir", "", "directory of named policies <name>.yaml (e.g. a mounted ConfigMap), selected by the "+webhook.PolicyAnnotation+" workload annotation or by namespaces routed to /validate/<name>")
+	serveCmd.Flags().StringVar(&defaultPolicy, "default-policy", "", "policy from --policy-dir applied when none is selected (default: --policy)")
+	serveCmd.Flags().StringArrayVar(&policyOverrides, "policy-override", nil, "<policy>=<name>[,<name>]: policies the "+webhook.PolicyAnnotation+" annotation may select where <policy> is the namespace or default policy (repeatable); any other annotation is denied")
+	serveCmd.Flags().StringVar(&engine, "engine", "yaml", "policy engine for the gates (yaml|rego)")
+	serveCmd.Flags().StringVar(&regoPolicy, "rego-policy", "", "rego policy path (used with --engine rego)")
+	serveCmd.Flags().StringVar(&environment, "env", "", "environment for trigger_environments gates, e.g. prod; gates with always: true apply to every image")
 	serveCmd.Flags().StringVar(&schemaDir, "schema-dir", "schemas/v1", "schema directory")
 	serveCmd.Flags().StringVar(&registryPrefix, "registry-prefix", "", "OCI registry prefix for attestation bundles")
+	serveCmd.Flags().BoolVar(&referrers, "referrers", false, "discover attestation bundles attached to each image via the OCI referrers API")
+	serveCmd.Flags().StringVar(&ociLayout, "oci-layout", "", "read attestation bundles from a mounted OCI image layout (tarball or directory) instead of a registry")
 	serveCmd.Flags().BoolVar(&failOpen, "fail-open", false, "allow pods when verification encounters an error")
 	serveCmd.Flags().IntVar(&cacheTTLSeconds, "cache-ttl-seconds", 300, "successful verification cache TTL in seconds")
+	serveCmd.Flags().StringVar(&subjectMode, "subjects", verify.SubjectsOptional, "subject verification mode (required|optional|skip)")
+	addPolicyTrustFlags(serveCmd, &trustFlags)
+	addRegistryFlags(serveCmd, &registry)
 
 	webhookCmd.AddCommand(serveCmd)
 	return webhookCmd
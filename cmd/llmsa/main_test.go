@@ -5,13 +5,19 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/store"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
+	"github.com/spf13/cobra"
 )
 
 func TestVerifyCommandWithOCISource(t *testing.T) {
@@ -24,7 +30,7 @@ func TestVerifyCommandWithOCISource(t *testing.T) {
 	t.Cleanup(func() { ociPullFunc = originalPull })
 
 	called := false
-	ociPullFunc = func(_ string, outPath string) error {
+	ociPullFunc = func(_ string, outPath string, _ store.RegistryOptions) error {
 		called = true
 		raw, err := os.ReadFile(bundlePath)
 		if err != nil {
@@ -77,7 +83,7 @@ gates: []
 	originalPull := ociPullFunc
 	t.Cleanup(func() { ociPullFunc = originalPull })
 
-	ociPullFunc = func(_ string, outPath string) error {
+	ociPullFunc = func(_ string, outPath string, _ store.RegistryOptions) error {
 		raw, err := os.ReadFile(bundlePath)
 		if err != nil {
 			return err
@@ -105,6 +111,43 @@ gates: []
 	}
 }
 
+func TestResolveBundleSource(t *testing.T) {
+	dir, cleanup, err := resolveBundleSource("verify", bundleSource{kind: "local", path: "bundles"})
+	if err != nil || dir != "bundles" {
+		t.Fatalf("local source: dir=%q err=%v", dir, err)
+	}
+	cleanup()
+
+	if _, _, err := resolveBundleSource("gate", bundleSource{kind: "ftp"}); err == nil || !strings.Contains(err.Error(), "unsupported source ftp") {
+		t.Fatalf("expected unsupported source error, got %v", err)
+	}
+
+	originalPull := ociPullFunc
+	t.Cleanup(func() { ociPullFunc = originalPull })
+	var pulled []string
+	ociPullFunc = func(ref, outPath string, _ store.RegistryOptions) error {
+		pulled = append(pulled, ref)
+		return os.WriteFile(outPath, []byte("{}"), 0o644)
+	}
+	dir, cleanup, err = resolveBundleSource("gate", bundleSource{kind: "oci", path: "ghcr.io/a/b:1, ghcr.io/a/c:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulled) != 2 || !fileExists(filepath.Join(dir, "oci_2.bundle.json")) {
+		t.Fatalf("expected two pulled bundles in %s, got %v", dir, pulled)
+	}
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected cleanup to remove %s", dir)
+	}
+
+	// A failed pull leaves nothing behind.
+	ociPullFunc = func(string, string, store.RegistryOptions) error { return errors.New("registry unavailable") }
+	if _, _, err := resolveBundleSource("gate", bundleSource{kind: "oci", path: "ghcr.io/a/b:1"}); err == nil || !strings.Contains(err.Error(), "registry unavailable") {
+		t.Fatalf("expected pull error, got %v", err)
+	}
+}
+
 func TestGateCommandWithRegoEngine(t *testing.T) {
 	tmp := t.TempDir()
 	bundlePath := writeSignedPromptBundle(t, tmp, "plaintext_explicit")
@@ -121,7 +164,7 @@ gates: []
 
 	originalPull := ociPullFunc
 	t.Cleanup(func() { ociPullFunc = originalPull })
-	ociPullFunc = func(_ string, outPath string) error {
+	ociPullFunc = func(_ string, outPath string, _ store.RegistryOptions) error {
 		raw, err := os.ReadFile(bundlePath)
 		if err != nil {
 			return err
@@ -151,6 +194,86 @@ gates: []
 	}
 }
 
+func TestGateCommandRegoVerificationIsOptIn(t *testing.T) {
+	tmp := t.TempDir()
+	writeSignedPromptBundle(t, tmp, "hash_only")
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("version: 1\ngates: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := repoRoot(t)
+	gate := func(extra ...string) error {
+		cmd := newGateCommand()
+		cmd.SetArgs(append([]string{
+			"--engine", "rego",
+			"--rego-policy", filepath.Join(root, "policy", "examples", "rego-verification.rego"),
+			"--schema-dir", filepath.Join(root, "schemas", "v1"),
+			"--policy", policyPath,
+			"--attestations", tmp,
+			"--allow-no-changes",
+		}, extra...))
+		return cmd.Execute()
+	}
+	writeReport := func(bundles int) string {
+		path := filepath.Join(t.TempDir(), "verify.json")
+		raw, _ := json.Marshal(verify.Report{Passed: true, BundleCount: bundles})
+		if err := os.WriteFile(path, raw, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	var ce cliError
+	if err := gate(); !errors.As(err, &ce) || ce.code != verify.ExitPolicyFail {
+		t.Fatalf("expected a policy failure without verification input, got %v", err)
+	}
+	if err := gate("--verify"); err != nil {
+		t.Fatalf("--verify: %v", err)
+	}
+	if err := gate("--verification", writeReport(1)); err != nil {
+		t.Fatalf("--verification: %v", err)
+	}
+	if err := gate("--verification", writeReport(2)); err == nil || !strings.Contains(err.Error(), "covers 2 bundles, the gate has 1") {
+		t.Fatalf("expected bundle count mismatch, got %v", err)
+	}
+	if err := gate("--verify", "--verification", writeReport(1)); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected mutually exclusive error, got %v", err)
+	}
+}
+
+func TestLoadChainConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "llmsa.yaml")
+	if err := os.WriteFile(cfgPath, []byte("chain:\n  rules:\n    - type: eval_attestation\n      requires: [prompt_attestation]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fromConfig, err := loadChainConfig(nil, cfgPath)
+	if err != nil {
+		t.Fatalf("load chain config: %v", err)
+	}
+	if fromConfig == nil || len(fromConfig.Rules) != 1 {
+		t.Fatalf("expected llmsa.yaml chain rules, got %+v", fromConfig)
+	}
+
+	policyChain := chain.DefaultConfig()
+	got, err := loadChainConfig(&policyChain, cfgPath)
+	if err != nil || got != &policyChain {
+		t.Fatalf("expected policy chain to win, got %+v %v", got, err)
+	}
+
+	if got, err := loadChainConfig(nil, filepath.Join(dir, "missing.yaml")); err != nil || got != nil {
+		t.Fatalf("expected built-in rules for missing config, got %+v %v", got, err)
+	}
+
+	if err := os.WriteFile(cfgPath, []byte("chain:\n  rules:\n    - type: a\n      requires: [a]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadChainConfig(nil, cfgPath); err == nil || !strings.Contains(err.Error(), "depend on itself") {
+		t.Fatalf("expected invalid chain error, got %v", err)
+	}
+}
+
 func TestDefaultBundlePathContract(t *testing.T) {
 	statement := map[string]any{
 		"attestation_type": "prompt_attestation",
@@ -194,7 +317,7 @@ func TestPublishCommandUsesOCIPublisher(t *testing.T) {
 	t.Cleanup(func() { ociPublishFunc = original })
 
 	called := false
-	ociPublishFunc = func(inPath string, ociRef string) (string, error) {
+	ociPublishFunc = func(inPath string, ociRef string, _ store.PublishOptions) (string, error) {
 		called = true
 		if inPath != bundlePath {
 			t.Fatalf("unexpected in path: %s", inPath)
@@ -218,6 +341,358 @@ func TestPublishCommandUsesOCIPublisher(t *testing.T) {
 	}
 }
 
+func TestPublishCommandWithSubject(t *testing.T) {
+	tmp := t.TempDir()
+	bundlePath := filepath.Join(tmp, "bundle.json")
+	if err := os.WriteFile(bundlePath, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	original := ociPublishFunc
+	t.Cleanup(func() { ociPublishFunc = original })
+
+	called := false
+	ociPublishFunc = func(inPath, ociRef string, opts store.PublishOptions) (string, error) {
+		called = true
+		if inPath != bundlePath || ociRef != "" || opts.Subject != "ghcr.io/acme/app:v1" {
+			t.Fatalf("unexpected publish args: %s %q %+v", inPath, ociRef, opts)
+		}
+		return "ghcr.io/acme/app@sha256:deadbeef", nil
+	}
+
+	cmd := newPublishCommand()
+	cmd.SetArgs([]string{"--in", bundlePath, "--subject", "ghcr.io/acme/app:v1"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("publish command failed: %v", err)
+	}
+	if !called {
+		t.Fatalf("expected subject publisher to be called")
+	}
+}
+
+func TestPublishCommand_RegistryFlags(t *testing.T) {
+	original := ociPublishFunc
+	t.Cleanup(func() { ociPublishFunc = original })
+	t.Setenv(store.RegistryUsernameEnv, "ci-bot")
+	t.Setenv("MIRROR_TOKEN", "s3cret")
+
+	var got store.RegistryOptions
+	ociPublishFunc = func(_, _ string, opts store.PublishOptions) (string, error) {
+		got = opts.Registry
+		return "mirror.local:5000/acme/attestations@sha256:deadbeef", nil
+	}
+
+	cmd := newPublishCommand()
+	cmd.SetArgs([]string{
+		"--in", "bundle.json",
+		"--oci", "acme/attestations:v1",
+		"--default-registry", "mirror.local:5000",
+		"--registry-token-env", "MIRROR_TOKEN",
+		"--insecure-registry", "mirror.local:5000",
+		"--registry-ca", "ca.pem",
+		"--registry-pull-secret", "/var/run/secrets/regcred",
+		"--registry-retries", "5",
+		"--registry-retry-delay", "2s",
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("publish command failed: %v", err)
+	}
+	want := store.RegistryOptions{
+		DefaultRegistry:    "mirror.local:5000",
+		Username:           "ci-bot",
+		Token:              "s3cret",
+		PullSecrets:        []string{"/var/run/secrets/regcred"},
+		CAFile:             "ca.pem",
+		InsecureRegistries: []string{"mirror.local:5000"},
+		Retries:            5,
+		RetryDelay:         2 * time.Second,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("registry options:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestVerifyCommandWithReferrersSource(t *testing.T) {
+	tmp := t.TempDir()
+	bundlePath := writeSignedPromptBundle(t, tmp, "hash_only")
+	schemaDir := filepath.Join(repoRoot(t), "schemas", "v1")
+	outPath := filepath.Join(tmp, "verify.json")
+
+	original := ociReferrersPullFunc
+	t.Cleanup(func() { ociReferrersPullFunc = original })
+
+	var images []string
+	ociReferrersPullFunc = func(image, outDir string, _ store.RegistryOptions) ([]string, error) {
+		images = append(images, image)
+		raw, err := os.ReadFile(bundlePath)
+		if err != nil {
+			return nil, err
+		}
+		out := filepath.Join(outDir, "sha256-abc.bundle.json")
+		return []string{out}, os.WriteFile(out, raw, 0o644)
+	}
+
+	cmd := newVerifyCommand()
+	cmd.SetArgs([]string{
+		"--source", "referrers",
+		"--attestations", "ghcr.io/acme/app:v1",
+		"--schema-dir", schemaDir,
+		"--format", "json",
+		"--out", outPath,
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("verify command failed: %v", err)
+	}
+	if len(images) != 1 || images[0] != "ghcr.io/acme/app:v1" {
+		t.Fatalf("unexpected referrers lookups: %v", images)
+	}
+
+	cmd = newVerifyCommand()
+	cmd.SetArgs([]string{"--source", "referrers", "--attestations", " , ", "--schema-dir", schemaDir})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "at least one image ref") {
+		t.Fatalf("expected missing image error, got %v", err)
+	}
+}
+
+func TestStoreCommandAndVerifyStoreSource(t *testing.T) {
+	tmp := t.TempDir()
+	bundlePath := writeSignedPromptBundle(t, tmp, "hash_only")
+	schemaDir := filepath.Join(repoRoot(t), "schemas", "v1")
+	storeDir := filepath.Join(tmp, "store")
+
+	run := func(cmd *cobra.Command, args ...string) error {
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+
+	if err := run(newStoreCommand(), "add", "--dir", storeDir, filepath.Dir(bundlePath)); err != nil {
+		t.Fatalf("store add: %v", err)
+	}
+	b, err := sign.ReadBundle(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := filepath.Join(tmp, "got.bundle.json")
+	if err := run(newStoreCommand(), "get", "--dir", storeDir, "--out", got, strings.TrimPrefix(b.Metadata.StatementHash, "sha256:")[:12]); err != nil {
+		t.Fatalf("store get: %v", err)
+	}
+	want, _ := os.ReadFile(bundlePath)
+	if raw, _ := os.ReadFile(got); string(raw) != string(want) {
+		t.Fatal("store get returned different bundle bytes")
+	}
+	if err := run(newStoreCommand(), "query", "--dir", storeDir, "--format", "yaml", "type=prompt_attestation"); err == nil {
+		t.Fatal("expected unsupported format error")
+	}
+
+	outPath := filepath.Join(tmp, "verify.json")
+	if err := run(newVerifyCommand(),
+		"--source", "store",
+		"--store-dir", storeDir,
+		"--query", "type=prompt_attestation,latest",
+		"--schema-dir", schemaDir,
+		"--format", "json",
+		"--out", outPath,
+	); err != nil {
+		t.Fatalf("verify from store: %v", err)
+	}
+	raw, _ := os.ReadFile(outPath)
+	var r verify.Report
+	if err := json.Unmarshal(raw, &r); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Passed || r.ExitCode != verify.ExitPass {
+		t.Fatalf("expected verify pass, got %+v", r)
+	}
+
+	err = run(newVerifyCommand(), "--source", "store", "--store-dir", storeDir, "--query", "type=eval_attestation", "--schema-dir", schemaDir)
+	if err == nil || !strings.Contains(err.Error(), "matched no bundles") {
+		t.Fatalf("expected empty query error, got %v", err)
+	}
+	if err := run(newStoreCommand(), "gc", "--dir", storeDir, "--keep-latest", "1"); err != nil {
+		t.Fatalf("store gc: %v", err)
+	}
+}
+
+func TestExportImportAndVerifyOCILayout(t *testing.T) {
+	tmp := t.TempDir()
+	attDir := filepath.Join(tmp, "attestations")
+	os.MkdirAll(attDir, 0o755)
+	bundlePath := writeSignedPromptBundle(t, attDir, "hash_only")
+	policyPath := filepath.Join(tmp, "gates.yaml")
+	os.WriteFile(policyPath, []byte("version: 1\n"), 0o644)
+	schemaDir := filepath.Join(repoRoot(t), "schemas", "v1")
+	layoutPath := filepath.Join(tmp, "set.tar")
+
+	run := func(cmd *cobra.Command, args ...string) error {
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+	if err := run(newExportCommand(),
+		"--oci-layout", layoutPath,
+		"--attestations", attDir,
+		"--policy", policyPath,
+		"--image", "ghcr.io/acme/app@sha256:"+strings.Repeat("ab", 32),
+	); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	if err := run(newVerifyCommand(), "--source", "oci-layout:"+layoutPath, "--schema-dir", schemaDir, "--out", filepath.Join(tmp, "verify.json")); err != nil {
+		t.Fatalf("verify from layout: %v", err)
+	}
+	if err := run(newVerifyCommand(), "--source", "oci-layout:"+layoutPath+":other", "--schema-dir", schemaDir); err == nil || !strings.Contains(err.Error(), `no attestation set tagged "other"`) {
+		t.Fatalf("expected missing tag error, got %v", err)
+	}
+	if err := run(newVerifyCommand(), "--source", "oci-layout", "--schema-dir", schemaDir); err == nil || !strings.Contains(err.Error(), "requires a layout path") {
+		t.Fatalf("expected missing path error, got %v", err)
+	}
+
+	out := filepath.Join(tmp, "imported")
+	if err := run(newImportCommand(), "--oci-layout", layoutPath, "--out", out, "--tag", "sha256-"+strings.Repeat("ab", 32)); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	for _, p := range []string{
+		filepath.Join(out, "attestations", filepath.Base(bundlePath)),
+		filepath.Join(out, "policies", "gates.yaml"),
+	} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("expected imported file: %v", err)
+		}
+	}
+}
+
+// memBackend is an in-memory store.Backend.
+type memBackend struct {
+	bucket  string
+	objects map[string][]byte
+}
+
+func (m *memBackend) Put(key string, raw []byte) error { m.objects[key] = raw; return nil }
+
+func (m *memBackend) Get(key string) ([]byte, error) {
+	raw, ok := m.objects[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return raw, nil
+}
+
+func (m *memBackend) List(prefix string) ([]string, error) {
+	var keys []string
+	for k := range m.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (m *memBackend) URI(key string) string { return "s3://" + m.bucket + "/" + key }
+
+func TestPublishAndVerifyWithS3Source(t *testing.T) {
+	tmp := t.TempDir()
+	attDir := filepath.Join(tmp, "attestations")
+	os.MkdirAll(attDir, 0o755)
+	writeSignedPromptBundle(t, attDir, "hash_only")
+	schemaDir := filepath.Join(repoRoot(t), "schemas", "v1")
+
+	original := s3BackendFunc
+	t.Cleanup(func() { s3BackendFunc = original })
+	backend := &memBackend{bucket: "attestations", objects: map[string][]byte{}}
+	var endpoints []string
+	s3BackendFunc = func(bucket, endpoint string) (store.Backend, error) {
+		if bucket != backend.bucket {
+			t.Fatalf("unexpected bucket %s", bucket)
+		}
+		endpoints = append(endpoints, endpoint)
+		return backend, nil
+	}
+
+	run := func(cmd *cobra.Command, args ...string) error {
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+	for _, prefix := range []string{"release/a/", "release/b/"} {
+		if err := run(newPublishCommand(), "--in", attDir, "--s3", "s3://attestations/"+prefix, "--s3-endpoint", "http://minio:9000"); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	if len(backend.objects) != 2 {
+		t.Fatalf("expected one object per prefix, got %v", backend.objects)
+	}
+
+	outPath := filepath.Join(tmp, "verify.json")
+	if err := run(newVerifyCommand(),
+		"--source", "s3",
+		"--attestations", "s3://attestations/release/a/,s3://attestations/release/b/",
+		"--s3-endpoint", "http://minio:9000",
+		"--schema-dir", schemaDir,
+		"--format", "json",
+		"--out", outPath,
+	); err != nil {
+		t.Fatalf("verify from s3: %v", err)
+	}
+	raw, _ := os.ReadFile(outPath)
+	var r verify.Report
+	if err := json.Unmarshal(raw, &r); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Passed || len(r.Statements) != 2 {
+		t.Fatalf("expected both bundles verified, got %+v", r)
+	}
+	for _, e := range endpoints {
+		if e != "http://minio:9000" {
+			t.Fatalf("unexpected endpoint %q", e)
+		}
+	}
+
+	if err := run(newVerifyCommand(), "--source", "s3", "--schema-dir", schemaDir); err == nil || !strings.Contains(err.Error(), "must start with s3://") {
+		t.Fatalf("expected s3 uri error, got %v", err)
+	}
+}
+
+func TestMirrorCommandVerifiesBeforeCopying(t *testing.T) {
+	attDir := t.TempDir()
+	writeSignedPromptBundle(t, attDir, "hash_only")
+	schemaDir := filepath.Join(repoRoot(t), "schemas", "v1")
+
+	original := mirrorFunc
+	t.Cleanup(func() { mirrorFunc = original })
+	var got store.MirrorOptions
+	mirrorFunc = func(from, to string, opts store.MirrorOptions) ([]store.MirrorResult, error) {
+		got = opts
+		if err := opts.Verify(attDir); err != nil {
+			return nil, err
+		}
+		return []store.MirrorResult{{Source: from + "@sha256:abc", Destination: to + "@sha256:abc"}}, nil
+	}
+
+	run := func(cmd *cobra.Command, args ...string) error {
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+	args := []string{
+		"--from", "staging.local/acme/attestations:v1",
+		"--to", "prod.local/acme/attestations",
+		"--schema-dir", schemaDir,
+		"--insecure-registry", "staging.local",
+	}
+	if err := run(newMirrorCommand(), args...); err != nil {
+		t.Fatalf("mirror: %v", err)
+	}
+	if len(got.Registry.InsecureRegistries) != 1 || got.Registry.InsecureRegistries[0] != "staging.local" {
+		t.Fatalf("registry options not passed through: %+v", got.Registry)
+	}
+
+	os.WriteFile(filepath.Join(attDir, "forged.bundle.json"), []byte(`{"envelope":{}}`), 0o644)
+	if err := run(newMirrorCommand(), args...); err == nil || !strings.Contains(err.Error(), "forged.bundle.json") {
+		t.Fatalf("expected verification failure naming the forged bundle, got %v", err)
+	}
+	if err := run(newMirrorCommand(), "--from", "staging.local/acme/attestations"); err == nil || !strings.Contains(err.Error(), "--to") {
+		t.Fatalf("expected missing --to error, got %v", err)
+	}
+}
+
 func writeSignedPromptBundle(t *testing.T, dir string, privacyMode string) string {
 	t.Helper()
 
@@ -252,18 +727,19 @@ func writeSignedPromptBundle(t *testing.T, dir string, privacyMode string) strin
 			},
 		},
 		"predicate": map[string]any{
-			"prompt_bundle_digest": "sha256:bundle",
-			"system_prompt_digest": "sha256:system",
-			"template_digests":     []string{"sha256:template"},
-			"tool_schema_digests":  []string{"sha256:tool"},
-			"safety_policy_digest": "sha256:safety",
+			"prompt_bundle_digest": promptBundle(digest, digest, promptBundle(digest), promptBundle(digest)),
+			"system_prompt_digest": digest,
+			"template_digests":     []string{digest},
+			"tool_schema_digests":  []string{digest},
+			"safety_policy_digest": digest,
 		},
 		"privacy": map[string]any{
 			"mode": privacyMode,
 		},
+		"annotations": map[string]any{"predicate_binding": "v1"},
 	}
 
-	canonical, err := hash.CanonicalJSON(statement)
+	canonical, err := canonicalPayload(statement)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -290,6 +766,12 @@ func writeSignedPromptBundle(t *testing.T, dir string, privacyMode string) strin
 	return bundlePath
 }
 
+// promptBundle mirrors how the prompt collector derives prompt_bundle_digest.
+func promptBundle(parts ...string) string {
+	sort.Strings(parts)
+	return hash.DigestBytes([]byte(strings.Join(parts, "\n")))
+}
+
 func repoRoot(t *testing.T) string {
 	t.Helper()
 	_, filename, _, ok := runtime.Caller(0)
@@ -38,3 +38,15 @@ Selector labels.
 app.kubernetes.io/name: {{ include "llmsa-webhook.name" . }}
 app.kubernetes.io/instance: {{ .Release.Name }}
 {{- end }}
+
+{{/*
+ConfigMap holding the webhook policies: policy.configMap, or the chart's
+own copy of policy/examples/admission.yaml when policy.builtin is set.
+*/}}
+{{- define "llmsa-webhook.policyConfigMap" -}}
+{{- if .Values.policy.configMap }}
+{{- .Values.policy.configMap }}
+{{- else if .Values.policy.builtin }}
+{{- printf "%s-policy" (include "llmsa-webhook.fullname" .) | trunc 63 | trimSuffix "-" }}
+{{- end }}
+{{- end }}
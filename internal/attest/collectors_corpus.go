@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"path/filepath"
 
-	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
 )
 
@@ -25,6 +24,10 @@ func CollectCorpus(configPath string) (types.Statement, error) {
 	if err := LoadConfig(configPath, &cfg); err != nil {
 		return types.Statement{}, err
 	}
+	dg, err := loadDigester(configPath)
+	if err != nil {
+		return types.Statement{}, err
+	}
 	for i := range cfg.ConnectorConfigs {
 		cfg.ConnectorConfigs[i] = resolvePath(configPath, cfg.ConnectorConfigs[i])
 	}
@@ -56,22 +59,22 @@ func CollectCorpus(configPath string) (types.Statement, error) {
 		if err := requirePath(path, "connector_config"); err != nil {
 			return types.Statement{}, err
 		}
-		d, _, err := hash.DigestFile(path)
+		d, err := dg.file(path)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		connectorDigests = append(connectorDigests, types.NamedDigest{Name: filepath.Base(path), Digest: d})
-		s, err := subjectFromPath(path)
+		s, err := dg.subject(path)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		subjects = append(subjects, s)
 	}
 
-	docDigest, _, _ := hash.DigestFile(cfg.DocumentManifest)
-	chunkDigest, _, _ := hash.DigestFile(cfg.ChunkingConfig)
-	embedInputDigest, _, _ := hash.DigestFile(cfg.EmbeddingInput)
-	vectorDigest, _, err := hash.DigestFile(cfg.VectorIndex)
+	docDigest, _ := dg.file(cfg.DocumentManifest)
+	chunkDigest, _ := dg.file(cfg.ChunkingConfig)
+	embedInputDigest, _ := dg.file(cfg.EmbeddingInput)
+	vectorDigest, err := dg.file(cfg.VectorIndex)
 	if err != nil {
 		return types.Statement{}, err
 	}
@@ -87,11 +90,11 @@ func CollectCorpus(configPath string) (types.Statement, error) {
 		VectorIndexDigest:       vectorDigest,
 	}
 	if cfg.BuildCommand != "" {
-		predicate.BuildCommandDigest = digestOfString(cfg.BuildCommand)
+		predicate.BuildCommandDigest = dg.bytes([]byte(cfg.BuildCommand))
 	}
 
 	for _, p := range []string{cfg.DocumentManifest, cfg.ChunkingConfig, cfg.EmbeddingInput, cfg.VectorIndex} {
-		s, err := subjectFromPath(p)
+		s, err := dg.subject(p)
 		if err != nil {
 			return types.Statement{}, err
 		}
@@ -2,9 +2,8 @@ package attest
 
 import (
 	"fmt"
-	"strings"
 
-	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/semantic"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
 )
 
@@ -24,6 +23,10 @@ func CollectEval(configPath string) (types.Statement, error) {
 	if err := LoadConfig(configPath, &cfg); err != nil {
 		return types.Statement{}, err
 	}
+	dg, err := loadDigester(configPath)
+	if err != nil {
+		return types.Statement{}, err
+	}
 	cfg.Testset = resolvePath(configPath, cfg.Testset)
 	cfg.ScoringConfig = resolvePath(configPath, cfg.ScoringConfig)
 	cfg.BaselineResults = resolvePath(configPath, cfg.BaselineResults)
@@ -41,26 +44,10 @@ func CollectEval(configPath string) (types.Statement, error) {
 		}
 	}
 
-	testsetDigest, _, _ := hash.DigestFile(cfg.Testset)
-	scoreDigest, _, _ := hash.DigestFile(cfg.ScoringConfig)
-	baselineDigest, _, _ := hash.DigestFile(cfg.BaselineResults)
-	candidateDigest, _, _ := hash.DigestFile(cfg.CandidateResults)
-
-	regression := false
-	for thresholdKey, thresholdValue := range cfg.Thresholds {
-		if strings.HasSuffix(thresholdKey, "_min") {
-			metric := strings.TrimSuffix(thresholdKey, "_min")
-			if cfg.Metrics[metric] < thresholdValue {
-				regression = true
-			}
-		}
-		if strings.HasSuffix(thresholdKey, "_max") {
-			metric := strings.TrimSuffix(thresholdKey, "_max")
-			if cfg.Metrics[metric] > thresholdValue {
-				regression = true
-			}
-		}
-	}
+	testsetDigest, _ := dg.file(cfg.Testset)
+	scoreDigest, _ := dg.file(cfg.ScoringConfig)
+	baselineDigest, _ := dg.file(cfg.BaselineResults)
+	candidateDigest, _ := dg.file(cfg.CandidateResults)
 
 	predicate := types.EvalPredicate{
 		EvalSuiteID:           cfg.EvalSuiteID,
@@ -70,25 +57,30 @@ func CollectEval(configPath string) (types.Statement, error) {
 		CandidateResultDigest: candidateDigest,
 		Metrics:               cfg.Metrics,
 		Thresholds:            cfg.Thresholds,
-		RegressionDetected:    regression,
+		RegressionDetected:    semantic.EvalRegression(cfg.Metrics, cfg.Thresholds),
 	}
+	var materials []types.Subject
 	if cfg.RunEnvironment != "" {
-		d, _, err := hash.DigestFile(cfg.RunEnvironment)
+		d, err := dg.file(cfg.RunEnvironment)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		predicate.RunEnvironmentDigest = d
+		m, err := dg.subject(cfg.RunEnvironment)
+		if err != nil {
+			return types.Statement{}, err
+		}
+		materials = append(materials, m)
 	}
 
 	subjects := make([]types.Subject, 0, 4)
 	for _, p := range []string{cfg.Testset, cfg.ScoringConfig, cfg.BaselineResults, cfg.CandidateResults} {
-		s, err := subjectFromPath(p)
+		s, err := dg.subject(p)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		subjects = append(subjects, s)
 	}
-	statement := newStatement(types.AttestationEval, predicate, subjects, nil)
-	setDependsOn(&statement, types.AttestationPrompt, types.AttestationCorpus)
+	statement := newStatement(types.AttestationEval, predicate, subjects, materials)
 	return statement, nil
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
 )
 
@@ -42,7 +43,7 @@ func TestCollectEval(t *testing.T) {
 }
 
 func TestCollectEval_DependsOnPromptAndCorpus(t *testing.T) {
-	st, err := CollectEval("../../examples/tiny-rag/configs/eval.yaml")
+	st, err := collect(CreateOptions{Type: types.AttestationEval, ConfigPath: "../../examples/tiny-rag/configs/eval.yaml", SkipUpstreamPins: true})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -55,6 +56,29 @@ func TestCollectEval_DependsOnPromptAndCorpus(t *testing.T) {
 	}
 }
 
+func TestCollectEval_DependsOnFollowsChainRules(t *testing.T) {
+	cfg := chain.DefaultConfig()
+	cfg.Services = map[string][]chain.Rule{
+		"chatbot": {{Type: types.AttestationEval, Requires: []string{types.AttestationPrompt}}},
+	}
+	opts := CreateOptions{Type: types.AttestationEval, ConfigPath: "../../examples/tiny-rag/configs/eval.yaml", Chain: &cfg, Service: "chatbot", SkipUpstreamPins: true}
+	st, err := collect(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deps := st.Annotations["depends_on"]; deps != types.AttestationPrompt {
+		t.Fatalf("depends_on = %q, want only prompt_attestation", deps)
+	}
+
+	cfg.Services["chatbot"][0].Optional = []string{types.AttestationCorpus}
+	if st, err = collect(opts); err != nil {
+		t.Fatal(err)
+	}
+	if deps := st.Annotations["depends_on"]; deps != "corpus_attestation,prompt_attestation" {
+		t.Fatalf("depends_on = %q, want optional corpus included", deps)
+	}
+}
+
 func TestCollectEval_RegressionDetected(t *testing.T) {
 	dir := t.TempDir()
 	for _, f := range []string{"testset.json", "scoring.yaml", "baseline.json", "candidate.json"} {
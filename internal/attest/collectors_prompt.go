@@ -15,6 +15,9 @@ type PromptConfig struct {
 	RenderConfig      string   `yaml:"render_config"`
 	TestSuite         string   `yaml:"test_suite"`
 	SensitivityLabels []string `yaml:"sensitivity_labels"`
+	SymlinkMode       string   `yaml:"symlink_mode"`
+	IncludeEmptyDirs  bool     `yaml:"include_empty_dirs"`
+	IncludeFileModes  bool     `yaml:"include_file_modes"`
 }
 
 func CollectPrompt(configPath string) (types.Statement, error) {
@@ -22,6 +25,10 @@ func CollectPrompt(configPath string) (types.Statement, error) {
 	if err := LoadConfig(configPath, &cfg); err != nil {
 		return types.Statement{}, err
 	}
+	dg, err := loadDigester(configPath)
+	if err != nil {
+		return types.Statement{}, err
+	}
 	cfg.SystemPrompt = resolvePath(configPath, cfg.SystemPrompt)
 	cfg.TemplatesDir = resolvePath(configPath, cfg.TemplatesDir)
 	cfg.ToolSchemasDir = resolvePath(configPath, cfg.ToolSchemasDir)
@@ -37,52 +44,74 @@ func CollectPrompt(configPath string) (types.Statement, error) {
 		}
 	}
 
-	systemDigest, _, err := hash.DigestFile(cfg.SystemPrompt)
+	symlinkMode, err := hash.ParseSymlinkMode(cfg.SymlinkMode)
+	if err != nil {
+		return types.Statement{}, err
+	}
+	if symlinkMode == "" {
+		// Links that leave the prompt directories are an error unless the
+		// config opts into following them.
+		symlinkMode = hash.SymlinkFollowWithinRoot
+	}
+	dg.tree = hash.TreeOptions{Symlinks: symlinkMode, EmptyDirs: cfg.IncludeEmptyDirs, FileModes: cfg.IncludeFileModes}
+
+	systemDigest, err := dg.file(cfg.SystemPrompt)
 	if err != nil {
 		return types.Statement{}, fmt.Errorf("digest system prompt: %w", err)
 	}
-	templateDigests, templateSubjects, err := sortedFileDigests(cfg.TemplatesDir)
+	templateDigests, templateSubjects, err := dg.entries(cfg.TemplatesDir)
 	if err != nil {
 		return types.Statement{}, fmt.Errorf("digest templates: %w", err)
 	}
-	toolDigests, toolSubjects, err := sortedFileDigests(cfg.ToolSchemasDir)
+	toolDigests, toolSubjects, err := dg.entries(cfg.ToolSchemasDir)
 	if err != nil {
 		return types.Statement{}, fmt.Errorf("digest tool schemas: %w", err)
 	}
-	safetyDigest, _, err := hash.DigestFile(cfg.SafetyPolicy)
+	safetyDigest, err := dg.file(cfg.SafetyPolicy)
 	if err != nil {
 		return types.Statement{}, fmt.Errorf("digest safety policy: %w", err)
 	}
 
 	predicate := types.PromptPredicate{
-		PromptBundleDigest: bundleDigest(systemDigest, safetyDigest, bundleDigest(templateDigests...), bundleDigest(toolDigests...)),
+		PromptBundleDigest: dg.bundle(systemDigest, safetyDigest, dg.bundle(templateDigests...), dg.bundle(toolDigests...)),
 		SystemPromptDigest: systemDigest,
 		TemplateDigests:    templateDigests,
 		ToolSchemaDigests:  toolDigests,
 		SafetyPolicyDigest: safetyDigest,
 		SensitivityLabels:  cfg.SensitivityLabels,
 	}
+	materials := make([]types.Subject, 0, 2)
 	if cfg.RenderConfig != "" {
-		d, _, err := hash.DigestFile(cfg.RenderConfig)
+		d, err := dg.file(cfg.RenderConfig)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		predicate.PromptRenderConfigDigest = d
+		m, err := dg.subject(cfg.RenderConfig)
+		if err != nil {
+			return types.Statement{}, err
+		}
+		materials = append(materials, m)
 	}
 	if cfg.TestSuite != "" {
-		d, _, err := hash.DigestFile(cfg.TestSuite)
+		d, err := dg.file(cfg.TestSuite)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		predicate.PromptTestSuiteDigest = d
+		m, err := dg.subject(cfg.TestSuite)
+		if err != nil {
+			return types.Statement{}, err
+		}
+		materials = append(materials, m)
 	}
 
 	subjects := make([]types.Subject, 0, 2+len(templateSubjects)+len(toolSubjects))
-	sysSubject, err := subjectFromPath(cfg.SystemPrompt)
+	sysSubject, err := dg.subject(cfg.SystemPrompt)
 	if err != nil {
 		return types.Statement{}, err
 	}
-	safetySubject, err := subjectFromPath(cfg.SafetyPolicy)
+	safetySubject, err := dg.subject(cfg.SafetyPolicy)
 	if err != nil {
 		return types.Statement{}, err
 	}
@@ -90,5 +119,5 @@ func CollectPrompt(configPath string) (types.Statement, error) {
 	subjects = append(subjects, templateSubjects...)
 	subjects = append(subjects, toolSubjects...)
 
-	return newStatement(types.AttestationPrompt, predicate, subjects, nil), nil
+	return newStatement(types.AttestationPrompt, predicate, subjects, materials), nil
 }
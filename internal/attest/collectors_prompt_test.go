@@ -1,8 +1,12 @@
 package attest
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
 )
 
@@ -17,4 +21,46 @@ func TestCollectPrompt(t *testing.T) {
 	if len(st.Subject) == 0 {
 		t.Fatalf("expected subjects")
 	}
+	for _, s := range st.Subject {
+		if s.Tree == nil || s.Tree.Symlinks != string(hash.SymlinkFollowWithinRoot) {
+			t.Fatalf("subject %s should record the default follow_within_root mode: %+v", s.Name, s.Tree)
+		}
+	}
+}
+
+func TestCollectPromptRejectsSymlinkOutsideRootByDefault(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{"templates", "tools"} {
+		if err := os.Mkdir(filepath.Join(dir, p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []string{"system.txt", "safety.txt", "tools/schema.json"} {
+		if err := os.WriteFile(filepath.Join(dir, p), []byte(p), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "templates", "leak.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	config := "system_prompt: system.txt\ntemplates_dir: templates\ntool_schemas_dir: tools\nsafety_policy: safety.txt\n"
+	cfgPath := filepath.Join(dir, "prompt.yaml")
+	if err := os.WriteFile(cfgPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CollectPrompt(cfgPath); err == nil || !strings.Contains(err.Error(), "escapes root") {
+		t.Fatalf("expected the outside link to be rejected, got %v", err)
+	}
+
+	if err := os.WriteFile(cfgPath, []byte(config+"symlink_mode: follow\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CollectPrompt(cfgPath); err != nil {
+		t.Fatalf("explicit follow mode: %v", err)
+	}
 }
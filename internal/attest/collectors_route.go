@@ -3,7 +3,6 @@ package attest
 import (
 	"fmt"
 
-	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
 )
 
@@ -22,6 +21,10 @@ func CollectRoute(configPath string) (types.Statement, error) {
 	if err := LoadConfig(configPath, &cfg); err != nil {
 		return types.Statement{}, err
 	}
+	dg, err := loadDigester(configPath)
+	if err != nil {
+		return types.Statement{}, err
+	}
 	cfg.RouteConfig = resolvePath(configPath, cfg.RouteConfig)
 	cfg.BudgetPolicy = resolvePath(configPath, cfg.BudgetPolicy)
 	cfg.FallbackGraph = resolvePath(configPath, cfg.FallbackGraph)
@@ -42,9 +45,9 @@ func CollectRoute(configPath string) (types.Statement, error) {
 		return types.Statement{}, fmt.Errorf("provider_set is required")
 	}
 
-	routeDigest, _, _ := hash.DigestFile(cfg.RouteConfig)
-	budgetDigest, _, _ := hash.DigestFile(cfg.BudgetPolicy)
-	fallbackDigest, _, _ := hash.DigestFile(cfg.FallbackGraph)
+	routeDigest, _ := dg.file(cfg.RouteConfig)
+	budgetDigest, _ := dg.file(cfg.BudgetPolicy)
+	fallbackDigest, _ := dg.file(cfg.FallbackGraph)
 
 	predicate := types.RoutePredicate{
 		RouteConfigDigest:   routeDigest,
@@ -53,30 +56,40 @@ func CollectRoute(configPath string) (types.Statement, error) {
 		FallbackGraphDigest: fallbackDigest,
 		RoutingStrategy:     cfg.RoutingStrategy,
 	}
+	var materials []types.Subject
 	if cfg.CanaryConfig != "" {
-		d, _, err := hash.DigestFile(cfg.CanaryConfig)
+		d, err := dg.file(cfg.CanaryConfig)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		predicate.CanaryConfigDigest = d
+		m, err := dg.subject(cfg.CanaryConfig)
+		if err != nil {
+			return types.Statement{}, err
+		}
+		materials = append(materials, m)
 	}
 	if cfg.SimulationResult != "" {
-		d, _, err := hash.DigestFile(cfg.SimulationResult)
+		d, err := dg.file(cfg.SimulationResult)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		predicate.SimulationResultDigest = d
+		m, err := dg.subject(cfg.SimulationResult)
+		if err != nil {
+			return types.Statement{}, err
+		}
+		materials = append(materials, m)
 	}
 
 	subjects := make([]types.Subject, 0, 3)
 	for _, p := range []string{cfg.RouteConfig, cfg.BudgetPolicy, cfg.FallbackGraph} {
-		s, err := subjectFromPath(p)
+		s, err := dg.subject(p)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		subjects = append(subjects, s)
 	}
-	statement := newStatement(types.AttestationRoute, predicate, subjects, nil)
-	setDependsOn(&statement, types.AttestationEval)
+	statement := newStatement(types.AttestationRoute, predicate, subjects, materials)
 	return statement, nil
 }
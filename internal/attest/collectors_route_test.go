@@ -42,7 +42,7 @@ func TestCollectRoute(t *testing.T) {
 }
 
 func TestCollectRoute_DependsOnEval(t *testing.T) {
-	st, err := CollectRoute("../../examples/tiny-rag/configs/route.yaml")
+	st, err := collect(CreateOptions{Type: types.AttestationRoute, ConfigPath: "../../examples/tiny-rag/configs/route.yaml", SkipUpstreamPins: true})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -61,6 +61,15 @@ func TestCollectRoute_OptionalCanary(t *testing.T) {
 	if pred.CanaryConfigDigest == "" {
 		t.Error("expected canary_config_digest to be populated from tiny-rag example")
 	}
+	found := false
+	for _, m := range st.Materials {
+		if "sha256:"+m.Digest.SHA256() == pred.CanaryConfigDigest {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected canary config to be recorded as a material")
+	}
 }
 
 func TestCollectRoute_MissingStrategy(t *testing.T) {
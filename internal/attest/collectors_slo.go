@@ -3,7 +3,6 @@ package attest
 import (
 	"fmt"
 
-	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
 )
 
@@ -25,6 +24,10 @@ func CollectSLO(configPath string) (types.Statement, error) {
 	if err := LoadConfig(configPath, &cfg); err != nil {
 		return types.Statement{}, err
 	}
+	dg, err := loadDigester(configPath)
+	if err != nil {
+		return types.Statement{}, err
+	}
 	cfg.ObservabilityQuery = resolvePath(configPath, cfg.ObservabilityQuery)
 	if cfg.SLOProfileID == "" || cfg.WindowStart == "" || cfg.WindowEnd == "" {
 		return types.Statement{}, fmt.Errorf("slo_profile_id, window_start and window_end are required")
@@ -45,18 +48,17 @@ func CollectSLO(configPath string) (types.Statement, error) {
 		if err := requirePath(cfg.ObservabilityQuery, "observability_query"); err != nil {
 			return types.Statement{}, err
 		}
-		d, _, err := hash.DigestFile(cfg.ObservabilityQuery)
+		d, err := dg.file(cfg.ObservabilityQuery)
 		if err != nil {
 			return types.Statement{}, err
 		}
 		predicate.ObservabilityQueryDigest = d
-		s, err := subjectFromPath(cfg.ObservabilityQuery)
+		s, err := dg.subject(cfg.ObservabilityQuery)
 		if err != nil {
 			return types.Statement{}, err
 		}
 			subjects = append(subjects, s)
 		}
 	statement := newStatement(types.AttestationSLO, predicate, subjects, nil)
-	setDependsOn(&statement, types.AttestationRoute)
 	return statement, nil
 }
@@ -30,7 +30,7 @@ func TestCollectSLO(t *testing.T) {
 }
 
 func TestCollectSLO_DependsOnRoute(t *testing.T) {
-	st, err := CollectSLO("../../examples/tiny-rag/configs/slo.yaml")
+	st, err := collect(CreateOptions{Type: types.AttestationSLO, ConfigPath: "../../examples/tiny-rag/configs/slo.yaml", SkipUpstreamPins: true})
 	if err != nil {
 		t.Fatal(err)
 	}
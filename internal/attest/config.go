@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
 	"gopkg.in/yaml.v3"
 )
 
 type ProjectConfig struct {
 	Collectors map[string]string   `yaml:"collectors"`
 	PathRules  map[string][]string `yaml:"path_rules"`
+	Chain      *chain.Config       `yaml:"chain"`
 }
 
 func LoadConfig(path string, out any) error {
@@ -0,0 +1,163 @@
+package attest
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
+)
+
+type digestConfig struct {
+	DigestAlgorithms []string `yaml:"digest_algorithms"`
+}
+
+// digester computes collector digests. Predicate fields use the primary (first
+// configured) algorithm; subjects carry every configured algorithm and always
+// include sha256 so verifiers that predate multi-algorithm digests still work.
+type digester struct {
+	algorithms []string
+	tree       hash.TreeOptions
+}
+
+func loadDigester(configPath string) (digester, error) {
+	cfg := digestConfig{}
+	if err := LoadConfig(configPath, &cfg); err != nil {
+		return digester{}, err
+	}
+	return newDigester(cfg.DigestAlgorithms)
+}
+
+func newDigester(names []string) (digester, error) {
+	algs := make([]string, 0, len(names))
+	seen := make(map[string]struct{})
+	for _, n := range names {
+		alg, err := hash.ParseAlgorithm(n)
+		if err != nil {
+			return digester{}, fmt.Errorf("digest_algorithms: %w", err)
+		}
+		if _, ok := seen[alg]; ok {
+			continue
+		}
+		seen[alg] = struct{}{}
+		algs = append(algs, alg)
+	}
+	return digester{algorithms: algs}, nil
+}
+
+func (d digester) primary() string {
+	if len(d.algorithms) == 0 {
+		return hash.SHA256
+	}
+	return d.algorithms[0]
+}
+
+func (d digester) subjectAlgorithms() []string {
+	algs := []string{d.primary()}
+	for _, alg := range d.algorithms {
+		if alg != algs[0] {
+			algs = append(algs, alg)
+		}
+	}
+	for _, alg := range algs {
+		if alg == hash.SHA256 {
+			return algs
+		}
+	}
+	return append(algs, hash.SHA256)
+}
+
+func (d digester) file(path string) (string, error) {
+	digest, _, err := hash.DigestFileWith(path, d.primary())
+	return digest, err
+}
+
+func (d digester) bytes(raw []byte) string {
+	// The algorithm was validated by newDigester, so hashing cannot fail.
+	digest, _ := hash.DigestBytesWith(d.primary(), raw)
+	return digest
+}
+
+func (d digester) bundle(parts ...string) string {
+	sort.Strings(parts)
+	return d.bytes([]byte(strings.Join(parts, "\n")))
+}
+
+func (d digester) subject(path string) (types.Subject, error) {
+	subject := types.Subject{
+		Name:   filepath.Base(path),
+		URI:    filepath.ToSlash(path),
+		Digest: types.Digest{},
+	}
+	for _, alg := range d.subjectAlgorithms() {
+		opts := d.tree
+		opts.Algorithm = alg
+		entry, err := hash.DigestPath("", path, opts)
+		if err != nil {
+			return types.Subject{}, err
+		}
+		subject.Digest[alg] = strings.TrimPrefix(entry.Digest, alg+":")
+		subject.SizeBytes = entry.Size
+	}
+	subject.Tree = treeSpec("", d.tree)
+	return subject, nil
+}
+
+// entries digests every file under dir, returning the sorted primary-algorithm
+// digests for predicate fields and one subject per file.
+func (d digester) entries(dir string) ([]string, []types.Subject, error) {
+	var digests []string
+	var subjects []types.Subject
+	index := make(map[string]int)
+	for i, alg := range d.subjectAlgorithms() {
+		opts := d.tree
+		opts.Algorithm = alg
+		_, _, entries, err := hash.DigestTreeWithOptions(dir, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, e := range entries {
+			if e.Kind == hash.EntryDir {
+				continue
+			}
+			if i == 0 {
+				digests = append(digests, e.Digest)
+				index[e.Path] = len(subjects)
+				subjects = append(subjects, types.Subject{
+					Name:      e.Path,
+					URI:       filepath.ToSlash(filepath.Join(dir, e.Path)),
+					Digest:    types.Digest{},
+					SizeBytes: e.Size,
+					Tree:      treeSpec(filepath.ToSlash(dir), d.tree),
+				})
+			}
+			pos, ok := index[e.Path]
+			if !ok {
+				return nil, nil, fmt.Errorf("tree %s changed while hashing", dir)
+			}
+			subjects[pos].Digest[alg] = strings.TrimPrefix(e.Digest, alg+":")
+		}
+	}
+	if digests == nil {
+		digests = []string{}
+		subjects = []types.Subject{}
+	}
+	sort.Strings(digests)
+	return digests, subjects, nil
+}
+
+// treeSpec returns the subject tree annotation for non-default options only,
+// so statements hashed with the defaults keep their original shape.
+func treeSpec(root string, opts hash.TreeOptions) *types.TreeSpec {
+	if opts.Symlinks == "" && !opts.EmptyDirs && !opts.FileModes {
+		return nil
+	}
+	return &types.TreeSpec{
+		Root:      root,
+		Symlinks:  string(opts.Symlinks),
+		EmptyDirs: opts.EmptyDirs,
+		FileModes: opts.FileModes,
+	}
+}
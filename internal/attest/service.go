@@ -4,11 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/changes"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
 )
@@ -18,6 +19,23 @@ type CreateOptions struct {
 	ConfigPath       string
 	OutDir           string
 	DeterminismCheck int
+	// StoreDir holds signed upstream bundles whose statement hashes are pinned
+	// in depends_on_digests. Defaults to OutDir.
+	StoreDir string
+	// Chain and Service select the chain rules the statement's depends_on is
+	// derived from. A nil Chain means the built-in rules.
+	Chain   *chain.Config
+	Service string
+	// VerifyUpstream checks each upstream bundle before it is pinned. It is
+	// required when the statement has depends_on types to pin.
+	VerifyUpstream UpstreamVerifier
+	// SkipUpstreamPins writes depends_on without depends_on_digests, pinning
+	// nothing, instead of failing when upstream bundles cannot be pinned.
+	// Such statements only verify with verify.Options.AllowLegacyStatements.
+	SkipUpstreamPins bool
+	// Images are digest-pinned image refs recorded as image:// subjects, for
+	// bundles attached to those images through OCI referrers.
+	Images []string
 }
 
 func CreateByType(opts CreateOptions) ([]string, error) {
@@ -27,7 +45,10 @@ func CreateByType(opts CreateOptions) ([]string, error) {
 	if err := os.MkdirAll(opts.OutDir, 0o755); err != nil {
 		return nil, fmt.Errorf("create out dir: %w", err)
 	}
-	statement, err := collectByType(opts.Type, opts.ConfigPath)
+	if opts.StoreDir == "" {
+		opts.StoreDir = opts.OutDir
+	}
+	statement, err := collect(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +62,7 @@ func CreateByType(opts CreateOptions) ([]string, error) {
 			return nil, err
 		}
 		for i := 0; i < opts.DeterminismCheck-1; i++ {
-			again, err := collectByType(opts.Type, opts.ConfigPath)
+			again, err := collect(opts)
 			if err != nil {
 				return nil, err
 			}
@@ -71,13 +92,20 @@ func CreateByType(opts CreateOptions) ([]string, error) {
 }
 
 func CreateChangedOnly(gitRef, outDir string, determinismCheck int) ([]string, error) {
+	return CreateChangedFrom(changes.Source{GitRange: gitRef}, CreateOptions{OutDir: outDir, DeterminismCheck: determinismCheck})
+}
+
+// CreateChangedFrom creates attestations for the types whose path rules match
+// the files changed according to src. Type and ConfigPath in opts are set per
+// type; a nil opts.Chain falls back to the chain in llmsa.yaml.
+func CreateChangedFrom(src changes.Source, opts CreateOptions) ([]string, error) {
 	cfg := DefaultProjectConfig()
 	if hash.FileExists("llmsa.yaml") {
 		if err := LoadConfig("llmsa.yaml", &cfg); err != nil {
 			return nil, err
 		}
 	}
-	changed, err := changedFiles(gitRef)
+	changed, err := changes.Resolve(src)
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +120,13 @@ func CreateChangedOnly(gitRef, outDir string, determinismCheck int) ([]string, e
 		if cfgPath == "" {
 			return nil, fmt.Errorf("missing collector config for %s", attType)
 		}
-		out, err := CreateByType(CreateOptions{Type: attType, ConfigPath: cfgPath, OutDir: outDir, DeterminismCheck: determinismCheck})
+		typeOpts := opts
+		typeOpts.Type = attType
+		typeOpts.ConfigPath = cfgPath
+		if typeOpts.Chain == nil {
+			typeOpts.Chain = cfg.Chain
+		}
+		out, err := CreateByType(typeOpts)
 		if err != nil {
 			return nil, err
 		}
@@ -101,6 +135,26 @@ func CreateChangedOnly(gitRef, outDir string, determinismCheck int) ([]string, e
 	return created, nil
 }
 
+func collect(opts CreateOptions) (types.Statement, error) {
+	statement, err := collectByType(opts.Type, opts.ConfigPath)
+	if err != nil {
+		return types.Statement{}, err
+	}
+	if err := addImageSubjects(&statement, opts.Images); err != nil {
+		return types.Statement{}, err
+	}
+	if err := applyChainRules(&statement, opts.Chain, opts.Service); err != nil {
+		return types.Statement{}, err
+	}
+	if opts.SkipUpstreamPins {
+		return statement, nil
+	}
+	if err := bindUpstream(&statement, opts.StoreDir, opts.VerifyUpstream); err != nil {
+		return types.Statement{}, err
+	}
+	return statement, nil
+}
+
 func collectByType(attType, configPath string) (types.Statement, error) {
 	switch attType {
 	case types.AttestationPrompt:
@@ -118,30 +172,6 @@ func collectByType(attType, configPath string) (types.Statement, error) {
 	}
 }
 
-func changedFiles(gitRef string) ([]string, error) {
-	if gitRef == "" {
-		gitRef = "HEAD~1"
-	}
-	if err := exec.Command("git", "rev-parse", "--verify", "HEAD").Run(); err != nil {
-		return []string{}, nil
-	}
-	cmd := exec.Command("git", "diff", "--name-only", gitRef+"...HEAD")
-	raw, err := cmd.Output()
-	if err != nil {
-		return []string{}, nil
-	}
-	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
-	out := make([]string, 0)
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		out = append(out, filepath.ToSlash(line))
-	}
-	return out, nil
-}
-
 func inferAttestationTypes(changed []string, rules map[string][]string) []string {
 	seen := make(map[string]struct{})
 	for _, path := range changed {
@@ -1,11 +1,15 @@
 package attest
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/changes"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
 )
 
@@ -304,7 +308,7 @@ func TestSubjectFromPathDirectory(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if subj.Digest.SHA256 == "" {
+	if subj.Digest.SHA256() == "" {
 		t.Error("expected non-empty digest for directory")
 	}
 	if subj.SizeBytes != 0 {
@@ -370,7 +374,7 @@ func TestSortedFileDigests(t *testing.T) {
 	os.WriteFile(filepath.Join(tmp, "z.txt"), []byte("zzz"), 0o644)
 	os.WriteFile(filepath.Join(tmp, "a.txt"), []byte("aaa"), 0o644)
 
-	digests, subjects, err := sortedFileDigests(tmp)
+	digests, subjects, err := sortedFileDigests(tmp, hash.TreeOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -435,6 +439,24 @@ func TestCollectByTypeSLO(t *testing.T) {
 	}
 }
 
+func TestCollectRecordsImageSubjects(t *testing.T) {
+	image := "ghcr.io/acme/model-server@sha256:" + strings.Repeat("ab", 32)
+	st, err := collect(CreateOptions{Type: types.AttestationSLO, ConfigPath: "../../examples/tiny-rag/configs/slo.yaml", Images: []string{image}, SkipUpstreamPins: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := st.Subject[len(st.Subject)-1]
+	if last.URI != "image://"+image || last.Digest.SHA256() != strings.Repeat("ab", 32) {
+		t.Fatalf("expected image subject, got %+v", last)
+	}
+
+	for _, bad := range []string{"ghcr.io/acme/model-server:v1", "ghcr.io/acme/model-server@sha256:abc"} {
+		if _, err := collect(CreateOptions{Type: types.AttestationSLO, ConfigPath: "../../examples/tiny-rag/configs/slo.yaml", Images: []string{bad}}); err == nil || !strings.Contains(err.Error(), "pinned by sha256 digest") {
+			t.Fatalf("%s: expected digest error, got %v", bad, err)
+		}
+	}
+}
+
 // --- CreateByType() happy path ---
 
 func TestCreateByTypeHappyPath(t *testing.T) {
@@ -502,42 +524,38 @@ func TestCreateByTypeReadOnlyOutDir(t *testing.T) {
 	}
 }
 
-// --- changedFiles() ---
+// --- CreateChangedFrom() ---
 
-func TestChangedFilesNonGitDir(t *testing.T) {
-	// In a temp dir that isn't a git repo, changedFiles should return empty slice without error
+func TestCreateChangedFromNonGitDir(t *testing.T) {
+	// Outside a git repo the git source must fail rather than create nothing.
 	orig, _ := os.Getwd()
 	tmp := t.TempDir()
 	os.Chdir(tmp)
 	t.Cleanup(func() { os.Chdir(orig) })
 
-	files, err := changedFiles("")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	if _, err := CreateChangedFrom(changes.Source{}, CreateOptions{OutDir: tmp, DeterminismCheck: 1}); err == nil || !strings.Contains(err.Error(), "not a git repository") {
+		t.Fatalf("expected git error, got %v", err)
 	}
-	if len(files) != 0 {
-		t.Fatalf("expected 0 changed files in non-git dir, got %d: %v", len(files), files)
+	_, err := CreateChangedFrom(changes.Source{AllowNoChanges: true}, CreateOptions{OutDir: tmp, DeterminismCheck: 1})
+	if err == nil || !strings.Contains(err.Error(), "no changed artifacts") {
+		t.Fatalf("expected no changed artifacts with --allow-no-changes, got %v", err)
 	}
 }
 
-func TestChangedFilesDefaultRef(t *testing.T) {
-	// When gitRef is empty, it should default to HEAD~1
-	// In the actual repo, this should work without error
-	files, err := changedFiles("")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	// We just check it doesn't error, file list depends on git state
-	_ = files
-}
+func TestCreateChangedFromFileList(t *testing.T) {
+	orig, _ := os.Getwd()
+	tmp := t.TempDir()
+	os.Chdir(tmp)
+	t.Cleanup(func() { os.Chdir(orig) })
 
-func TestChangedFilesCustomRef(t *testing.T) {
-	// Use a custom ref - should not panic
-	files, err := changedFiles("HEAD~5")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	list := filepath.Join(tmp, "changed.txt")
+	if err := os.WriteFile(list, []byte("README.md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := CreateChangedFrom(changes.Source{FilesFrom: list}, CreateOptions{OutDir: tmp, DeterminismCheck: 1})
+	if err == nil || !strings.Contains(err.Error(), "no changed artifacts") {
+		t.Fatalf("expected unmapped file list to create nothing, got %v", err)
 	}
-	_ = files
 }
 
 // --- matches() additional edge cases ---
@@ -584,4 +602,91 @@ func TestNewStatementFields(t *testing.T) {
 	if stmt.Annotations["generated_by"] != "llmsa attest create" {
 		t.Error("missing generated_by annotation")
 	}
+	if stmt.Annotations[predicateBindingAnnotation] != predicateBindingVersion {
+		t.Error("missing predicate_binding annotation")
+	}
+}
+
+func TestSortedFileDigestsRecordsTreeMode(t *testing.T) {
+	tmp := t.TempDir()
+	os.WriteFile(filepath.Join(tmp, "a.txt"), []byte("aaa"), 0o644)
+	if err := os.Symlink("a.txt", filepath.Join(tmp, "b.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if _, _, err := sortedFileDigests(tmp, hash.TreeOptions{Symlinks: hash.SymlinkReject}); err == nil {
+		t.Fatal("expected reject mode to fail on the symlink")
+	}
+	_, subjects, err := sortedFileDigests(tmp, hash.TreeOptions{Symlinks: hash.SymlinkHashTargetPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subjects) != 2 {
+		t.Fatalf("expected 2 subjects, got %d", len(subjects))
+	}
+	for _, s := range subjects {
+		if s.Tree == nil || s.Tree.Symlinks != "hash_target_path" || s.Tree.Root != filepath.ToSlash(tmp) {
+			t.Fatalf("subject %s missing tree spec: %+v", s.Name, s.Tree)
+		}
+	}
+}
+
+func TestDigesterConfiguredAlgorithms(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "a.txt")
+	os.WriteFile(path, []byte("aaa"), 0o644)
+
+	dg, err := newDigester([]string{"sha512", "sha3-256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := dg.file(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(d, "sha512:") {
+		t.Errorf("predicate digest should use primary algorithm, got %q", d)
+	}
+	subj, err := dg.subject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, alg := range []string{"sha512", "sha3-256", "sha256"} {
+		if subj.Digest[alg] == "" {
+			t.Errorf("subject missing %s digest: %v", alg, subj.Digest)
+		}
+	}
+
+	if _, err := newDigester([]string{"md5"}); err == nil {
+		t.Fatal("expected unsupported algorithm error")
+	}
+}
+
+// --- predicate binding ---
+
+func TestCollectedPredicatesAreBound(t *testing.T) {
+	collectors := map[string]func(string) (types.Statement, error){
+		"prompt": CollectPrompt,
+		"corpus": CollectCorpus,
+		"eval":   CollectEval,
+		"route":  CollectRoute,
+		"slo":    CollectSLO,
+	}
+	for name, collect := range collectors {
+		st, err := collect("../../examples/tiny-rag/configs/" + name + ".yaml")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		raw, err := json.Marshal(st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			t.Fatal(err)
+		}
+		if err := verify.VerifyPredicateBinding(doc); err != nil {
+			t.Errorf("%s: collected predicate is not bound: %v", name, err)
+		}
+	}
 }
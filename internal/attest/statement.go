@@ -9,10 +9,19 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
 )
 
+// predicateBindingAnnotation marks statements whose predicate file digests
+// are all recorded as subjects or materials, so verify can tell them from
+// statements signed before predicate_binding existed.
+const (
+	predicateBindingAnnotation = "predicate_binding"
+	predicateBindingVersion    = "v1"
+)
+
 func newStatement(attType string, predicate any, subjects []types.Subject, materials []types.Subject) types.Statement {
 	return types.Statement{
 		SchemaVersion:   "1.0.0",
@@ -32,11 +41,53 @@ func newStatement(attType string, predicate any, subjects []types.Subject, mater
 			Mode: "hash_only",
 		},
 		Annotations: map[string]string{
-			"generated_by": "llmsa attest create",
+			"generated_by":             "llmsa attest create",
+			predicateBindingAnnotation: predicateBindingVersion,
 		},
 	}
 }
 
+// applyChainRules sets depends_on to the types the statement's chain rule
+// requires or optionally consumes, so statements only reference types the
+// verifier expects.
+func applyChainRules(statement *types.Statement, cfg *chain.Config, service string) error {
+	rulesCfg := chain.DefaultConfig()
+	if cfg != nil {
+		rulesCfg = *cfg
+	}
+	rules, err := rulesCfg.Resolve(service)
+	if err != nil {
+		return fmt.Errorf("chain rules: %w", err)
+	}
+	delete(statement.Annotations, "depends_on")
+	for _, r := range rules {
+		if r.Type == statement.AttestationType {
+			setDependsOn(statement, append(append([]string(nil), r.Requires...), r.Optional...)...)
+		}
+	}
+	return nil
+}
+
+// addImageSubjects lists each digest-pinned image as an image://<ref>
+// subject, so bundles attached to the image through OCI referrers can be
+// checked against a signed statement.
+func addImageSubjects(statement *types.Statement, images []string) error {
+	for _, image := range images {
+		image = strings.TrimSpace(image)
+		_, pinned, _ := strings.Cut(image, "@")
+		alg, value, _ := strings.Cut(pinned, ":")
+		if alg != types.DigestSHA256 || len(value) != 64 {
+			return fmt.Errorf("image %q must be pinned by sha256 digest (<repo>@sha256:<hex>)", image)
+		}
+		statement.Subject = append(statement.Subject, types.Subject{
+			Name:   image,
+			URI:    "image://" + image,
+			Digest: types.Digest{types.DigestSHA256: value},
+		})
+	}
+	return nil
+}
+
 func setDependsOn(statement *types.Statement, deps ...string) {
 	if statement == nil {
 		return
@@ -71,62 +122,19 @@ func readGitSHA() string {
 }
 
 func subjectFromPath(path string) (types.Subject, error) {
-	fi, err := os.Stat(path)
-	if err != nil {
-		return types.Subject{}, err
-	}
-	if fi.IsDir() {
-		digest, _, _, err := hash.DigestTree(path)
-		if err != nil {
-			return types.Subject{}, err
-		}
-		return types.Subject{
-			Name:      filepath.Base(path),
-			URI:       filepath.ToSlash(path),
-			Digest:    types.Digest{SHA256: strings.TrimPrefix(digest, "sha256:")},
-			SizeBytes: 0,
-		}, nil
-	}
-	digest, size, err := hash.DigestFile(path)
-	if err != nil {
-		return types.Subject{}, err
-	}
-	return types.Subject{
-		Name:      filepath.Base(path),
-		URI:       filepath.ToSlash(path),
-		Digest:    types.Digest{SHA256: strings.TrimPrefix(digest, "sha256:")},
-		SizeBytes: size,
-	}, nil
+	return digester{}.subject(path)
 }
 
-func sortedFileDigests(dir string) ([]string, []types.Subject, error) {
-	d, _, entries, err := hash.DigestTree(dir)
-	if err != nil {
-		return nil, nil, err
-	}
-	_ = d
-	digests := make([]string, 0, len(entries))
-	subjects := make([]types.Subject, 0, len(entries))
-	for _, e := range entries {
-		digests = append(digests, e.Digest)
-		subjects = append(subjects, types.Subject{
-			Name:      e.Path,
-			URI:       filepath.ToSlash(filepath.Join(dir, e.Path)),
-			Digest:    types.Digest{SHA256: strings.TrimPrefix(e.Digest, "sha256:")},
-			SizeBytes: e.Size,
-		})
-	}
-	sort.Strings(digests)
-	return digests, subjects, nil
+func sortedFileDigests(dir string, opts hash.TreeOptions) ([]string, []types.Subject, error) {
+	return digester{tree: opts}.entries(dir)
 }
 
 func digestOfString(value string) string {
-	return hash.DigestBytes([]byte(value))
+	return digester{}.bytes([]byte(value))
 }
 
 func bundleDigest(parts ...string) string {
-	sort.Strings(parts)
-	return hash.DigestBytes([]byte(strings.Join(parts, "\n")))
+	return digester{}.bundle(parts...)
 }
 
 func requirePath(path string, name string) error {
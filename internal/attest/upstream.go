@@ -0,0 +1,114 @@
+package attest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
+)
+
+// dependsOnDigestsAnnotation pins each depends_on type to the statement hash
+// of the exact upstream bundle consumed, as "type=sha256:<hex>" pairs.
+const dependsOnDigestsAnnotation = "depends_on_digests"
+
+// UpstreamVerifier checks an upstream bundle before its statement hash is
+// pinned. attest cannot import verify, so the caller supplies the check.
+type UpstreamVerifier func(path string, bundle sign.Bundle) error
+
+type upstreamBundle struct {
+	path          string
+	bundle        sign.Bundle
+	statementHash string
+	generatedAt   time.Time
+}
+
+// bindUpstream records the statement hashes of the newest bundle of each
+// depends_on type found in storeDir, after verifyBundle accepts it. A
+// depends_on type that cannot be pinned, because the store has no bundle of
+// that type or there is no verifier, is an error: verify would reject the
+// statement as unpinned_dependency.
+func bindUpstream(statement *types.Statement, storeDir string, verifyBundle UpstreamVerifier) error {
+	if statement == nil {
+		return nil
+	}
+	deps := splitAnnotation(statement.Annotations["depends_on"])
+	if len(deps) == 0 {
+		return nil
+	}
+	if verifyBundle == nil {
+		return fmt.Errorf("pin upstream bundles: no upstream verifier")
+	}
+	latest, err := latestBundlesByType(storeDir)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, dep := range deps {
+		if _, ok := latest[dep]; !ok {
+			missing = append(missing, dep)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("pin upstream bundles: no bundle for %s in %s; sign each upstream statement before attesting %s, or skip upstream pins", strings.Join(missing, ", "), storeDir, statement.AttestationType)
+	}
+	pins := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		b := latest[dep]
+		if err := verifyBundle(b.path, b.bundle); err != nil {
+			return fmt.Errorf("verify upstream %s bundle %s: %w", dep, b.path, err)
+		}
+		pins = append(pins, dep+"="+b.statementHash)
+	}
+	sort.Strings(pins)
+	statement.Annotations[dependsOnDigestsAnnotation] = strings.Join(pins, ",")
+	return nil
+}
+
+func latestBundlesByType(storeDir string) (map[string]upstreamBundle, error) {
+	entries, err := os.ReadDir(storeDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read attestation store %s: %w", storeDir, err)
+	}
+	out := map[string]upstreamBundle{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".bundle.json") {
+			continue
+		}
+		path := filepath.Join(storeDir, e.Name())
+		bundle, err := sign.ReadBundle(path)
+		if err != nil {
+			return nil, fmt.Errorf("read upstream bundle %s: %w", path, err)
+		}
+		var st struct {
+			AttestationType string `json:"attestation_type"`
+			GeneratedAt     string `json:"generated_at"`
+		}
+		if err := sign.DecodePayload(bundle, &st); err != nil {
+			return nil, fmt.Errorf("decode upstream bundle %s: %w", path, err)
+		}
+		generatedAt, _ := time.Parse(time.RFC3339, st.GeneratedAt)
+		cur, ok := out[st.AttestationType]
+		if !ok || generatedAt.After(cur.generatedAt) || (generatedAt.Equal(cur.generatedAt) && bundle.Metadata.StatementHash > cur.statementHash) {
+			out[st.AttestationType] = upstreamBundle{path: path, bundle: bundle, statementHash: bundle.Metadata.StatementHash, generatedAt: generatedAt}
+		}
+	}
+	return out, nil
+}
+
+func splitAnnotation(raw string) []string {
+	out := make([]string, 0)
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
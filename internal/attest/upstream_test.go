@@ -0,0 +1,173 @@
+package attest
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
+)
+
+func writeStoreBundle(t *testing.T, dir, name, attType, generatedAt string) sign.Bundle {
+	t.Helper()
+	statement := map[string]any{
+		"statement_id":     name,
+		"attestation_type": attType,
+		"generated_at":     generatedAt,
+	}
+	bundle, err := sign.CreateBundle(statement, sign.SignMaterial{KeyID: "k", SigB64: "s", Provider: "pem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sign.WriteBundle(filepath.Join(dir, name+".bundle.json"), bundle); err != nil {
+		t.Fatal(err)
+	}
+	return bundle
+}
+
+func acceptUpstream(string, sign.Bundle) error { return nil }
+
+func TestBindUpstreamPinsNewestBundlePerType(t *testing.T) {
+	store := t.TempDir()
+	writeStoreBundle(t, store, "prompt-old", types.AttestationPrompt, "2026-02-17T10:00:00Z")
+	newest := writeStoreBundle(t, store, "prompt-new", types.AttestationPrompt, "2026-02-17T11:00:00Z")
+	corpus := writeStoreBundle(t, store, "corpus", types.AttestationCorpus, "2026-02-17T10:30:00Z")
+	writeStoreBundle(t, store, "route", types.AttestationRoute, "2026-02-17T12:00:00Z")
+	os.WriteFile(filepath.Join(store, "statement_eval.json"), []byte("{}"), 0o644)
+
+	st := newStatement(types.AttestationEval, nil, nil, nil)
+	setDependsOn(&st, types.AttestationPrompt, types.AttestationCorpus)
+	if err := bindUpstream(&st, store, acceptUpstream); err != nil {
+		t.Fatal(err)
+	}
+	want := types.AttestationCorpus + "=" + corpus.Metadata.StatementHash + "," + types.AttestationPrompt + "=" + newest.Metadata.StatementHash
+	if got := st.Annotations[dependsOnDigestsAnnotation]; got != want {
+		t.Fatalf("depends_on_digests = %q, want %q", got, want)
+	}
+}
+
+func TestBindUpstreamFailsWithoutUpstreamBundles(t *testing.T) {
+	st := newStatement(types.AttestationRoute, nil, nil, nil)
+	setDependsOn(&st, types.AttestationEval)
+	err := bindUpstream(&st, filepath.Join(t.TempDir(), "missing"), acceptUpstream)
+	if err == nil || !strings.Contains(err.Error(), "no bundle for eval_attestation") {
+		t.Fatalf("expected missing upstream error, got %v", err)
+	}
+	if _, ok := st.Annotations[dependsOnDigestsAnnotation]; ok {
+		t.Fatalf("expected no pins, got %q", st.Annotations[dependsOnDigestsAnnotation])
+	}
+
+	// One missing type fails the statement even when the others could be pinned.
+	store := t.TempDir()
+	writeStoreBundle(t, store, "prompt", types.AttestationPrompt, "2026-02-17T10:00:00Z")
+	eval := newStatement(types.AttestationEval, nil, nil, nil)
+	setDependsOn(&eval, types.AttestationPrompt, types.AttestationCorpus)
+	if err := bindUpstream(&eval, store, acceptUpstream); err == nil || !strings.Contains(err.Error(), "no bundle for corpus_attestation") {
+		t.Fatalf("expected missing corpus error, got %v", err)
+	}
+
+	noDeps := newStatement(types.AttestationPrompt, nil, nil, nil)
+	if err := bindUpstream(&noDeps, t.TempDir(), acceptUpstream); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := noDeps.Annotations[dependsOnDigestsAnnotation]; ok {
+		t.Fatal("expected statements without depends_on to stay unpinned")
+	}
+}
+
+func TestBindUpstreamRejectsCorruptBundle(t *testing.T) {
+	store := t.TempDir()
+	os.WriteFile(filepath.Join(store, "bad.bundle.json"), []byte("{not json"), 0o644)
+	st := newStatement(types.AttestationSLO, nil, nil, nil)
+	setDependsOn(&st, types.AttestationRoute)
+	if err := bindUpstream(&st, store, acceptUpstream); err == nil || !strings.Contains(err.Error(), "bad.bundle.json") {
+		t.Fatalf("expected corrupt bundle error, got %v", err)
+	}
+}
+
+func TestBindUpstreamRejectsUnverifiedBundle(t *testing.T) {
+	store := t.TempDir()
+	writeStoreBundle(t, store, "route", types.AttestationRoute, "2026-02-17T10:00:00Z")
+	st := newStatement(types.AttestationSLO, nil, nil, nil)
+	setDependsOn(&st, types.AttestationRoute)
+	reject := func(string, sign.Bundle) error {
+		return errors.New("signature verification failed")
+	}
+	err := bindUpstream(&st, store, reject)
+	if err == nil || !strings.Contains(err.Error(), "route.bundle.json") || !strings.Contains(err.Error(), "signature verification failed") {
+		t.Fatalf("expected upstream verification error, got %v", err)
+	}
+	if _, ok := st.Annotations[dependsOnDigestsAnnotation]; ok {
+		t.Fatal("expected no pins for an unverified upstream bundle")
+	}
+}
+
+func TestBindUpstreamRequiresVerifier(t *testing.T) {
+	store := t.TempDir()
+	writeStoreBundle(t, store, "route", types.AttestationRoute, "2026-02-17T10:00:00Z")
+	st := newStatement(types.AttestationSLO, nil, nil, nil)
+	setDependsOn(&st, types.AttestationRoute)
+	if err := bindUpstream(&st, store, nil); err == nil || !strings.Contains(err.Error(), "no upstream verifier") {
+		t.Fatalf("expected missing verifier error, got %v", err)
+	}
+}
+
+func TestCreateByTypeRecordsUpstreamDigests(t *testing.T) {
+	store := t.TempDir()
+	route := writeStoreBundle(t, store, "route", types.AttestationRoute, "2026-02-17T10:00:00Z")
+	out := t.TempDir()
+
+	files, err := CreateByType(CreateOptions{
+		Type:             types.AttestationSLO,
+		ConfigPath:       "../../examples/tiny-rag/configs/slo.yaml",
+		OutDir:           out,
+		StoreDir:         store,
+		DeterminismCheck: 2,
+		VerifyUpstream:   acceptUpstream,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st types.Statement
+	if err := json.Unmarshal(raw, &st); err != nil {
+		t.Fatal(err)
+	}
+	if got := st.Annotations[dependsOnDigestsAnnotation]; got != types.AttestationRoute+"="+route.Metadata.StatementHash {
+		t.Fatalf("depends_on_digests = %q", got)
+	}
+}
+
+func TestCreateByTypeSkipUpstreamPins(t *testing.T) {
+	files, err := CreateByType(CreateOptions{
+		Type:             types.AttestationSLO,
+		ConfigPath:       "../../examples/tiny-rag/configs/slo.yaml",
+		OutDir:           t.TempDir(),
+		DeterminismCheck: 1,
+		SkipUpstreamPins: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st types.Statement
+	if err := json.Unmarshal(raw, &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Annotations["depends_on"] != types.AttestationRoute {
+		t.Fatalf("depends_on = %q", st.Annotations["depends_on"])
+	}
+	if _, ok := st.Annotations[dependsOnDigestsAnnotation]; ok {
+		t.Fatalf("expected no pins, got %q", st.Annotations[dependsOnDigestsAnnotation])
+	}
+}
@@ -0,0 +1,173 @@
+// Package chain holds the attestation dependency rules shared by attestation
+// creation, policy loading and verification.
+package chain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rule declares which attestation types a statement type depends on.
+// Required edges must be satisfied; optional edges are checked only when a
+// predecessor of that type is present.
+type Rule struct {
+	Type     string   `yaml:"type" json:"type"`
+	Requires []string `yaml:"requires" json:"requires,omitempty"`
+	Optional []string `yaml:"optional" json:"optional,omitempty"`
+}
+
+// Config is the provenance DAG declared in a policy file or llmsa.yaml.
+// Services override the base rules per attestation type.
+type Config struct {
+	Rules    []Rule            `yaml:"rules" json:"rules"`
+	Services map[string][]Rule `yaml:"services" json:"services,omitempty"`
+}
+
+// DefaultConfig returns the built-in prompt/corpus -> eval -> route -> slo chain.
+func DefaultConfig() Config {
+	return Config{Rules: []Rule{
+		{Type: "eval_attestation", Requires: []string{"prompt_attestation", "corpus_attestation"}},
+		{Type: "route_attestation", Requires: []string{"eval_attestation"}},
+		{Type: "slo_attestation", Requires: []string{"route_attestation"}},
+	}}
+}
+
+// Validate checks every rule set (base and each service) for malformed rules
+// and cycles.
+func (c Config) Validate() error {
+	if _, err := c.Resolve(""); err != nil {
+		return err
+	}
+	services := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	for _, name := range services {
+		if _, err := c.Resolve(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve returns the effective rules for a service, sorted by type. An empty
+// service yields the base rules; a service without a services entry is an
+// error, so a misspelt name cannot silently verify against the base rules.
+func (c Config) Resolve(service string) ([]Rule, error) {
+	byType := map[string]Rule{}
+	if err := mergeRules(byType, c.Rules, "rules"); err != nil {
+		return nil, err
+	}
+	if service != "" {
+		overrides, ok := c.Services[service]
+		if !ok {
+			return nil, fmt.Errorf("unknown chain service %q (%s)", service, c.serviceList())
+		}
+		if err := mergeRules(byType, overrides, "services."+service); err != nil {
+			return nil, err
+		}
+	}
+	rules := make([]Rule, 0, len(byType))
+	for _, r := range byType {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Type < rules[j].Type })
+	if err := checkCycles(rules); err != nil {
+		if service != "" {
+			return nil, fmt.Errorf("service %s: %w", service, err)
+		}
+		return nil, err
+	}
+	return rules, nil
+}
+
+// serviceList describes the services c defines, for error messages.
+func (c Config) serviceList() string {
+	if len(c.Services) == 0 {
+		return "no services are defined"
+	}
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "defined: " + strings.Join(names, ", ")
+}
+
+func mergeRules(byType map[string]Rule, rules []Rule, scope string) error {
+	seen := map[string]struct{}{}
+	for _, r := range rules {
+		r.Type = strings.TrimSpace(r.Type)
+		if r.Type == "" {
+			return fmt.Errorf("%s: chain rule type is required", scope)
+		}
+		if _, dup := seen[r.Type]; dup {
+			return fmt.Errorf("%s: duplicate chain rule for %s", scope, r.Type)
+		}
+		seen[r.Type] = struct{}{}
+		deps := map[string]struct{}{}
+		for _, dep := range append(append([]string(nil), r.Requires...), r.Optional...) {
+			if dep == r.Type {
+				return fmt.Errorf("%s: %s cannot depend on itself", scope, r.Type)
+			}
+			if _, dup := deps[dep]; dup {
+				return fmt.Errorf("%s: %s lists %s more than once", scope, r.Type, dep)
+			}
+			deps[dep] = struct{}{}
+		}
+		byType[r.Type] = r
+	}
+	return nil
+}
+
+// checkCycles rejects rule sets whose dependency graph (required and
+// optional edges) contains a cycle.
+func checkCycles(rules []Rule) error {
+	edges := map[string][]string{}
+	for _, r := range rules {
+		deps := append(append([]string(nil), r.Requires...), r.Optional...)
+		sort.Strings(deps)
+		edges[r.Type] = deps
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+	var visit func(string) error
+	visit = func(node string) error {
+		switch state[node] {
+		case visiting:
+			start := 0
+			for i, n := range stack {
+				if n == node {
+					start = i
+				}
+			}
+			cycle := append(append([]string(nil), stack[start:]...), node)
+			return fmt.Errorf("chain rules contain a cycle: %s", strings.Join(cycle, " -> "))
+		case done:
+			return nil
+		}
+		state[node] = visiting
+		stack = append(stack, node)
+		for _, dep := range edges[node] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[node] = done
+		return nil
+	}
+	for _, r := range rules {
+		if err := visit(r.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
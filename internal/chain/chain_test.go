@@ -0,0 +1,90 @@
+package chain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultChainConfigResolve(t *testing.T) {
+	rules, err := DefaultConfig().Resolve("")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if len(rules) != 3 || rules[0].Type != "eval_attestation" || rules[2].Type != "slo_attestation" {
+		t.Fatalf("unexpected default rules: %+v", rules)
+	}
+}
+
+func TestChainConfigServiceOverride(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Services = map[string][]Rule{
+		"chatbot": {{Type: "eval_attestation", Requires: []string{"prompt_attestation"}, Optional: []string{"corpus_attestation"}}},
+	}
+
+	base, err := cfg.Resolve("")
+	if err != nil {
+		t.Fatalf("resolve base: %v", err)
+	}
+	if len(base[0].Requires) != 2 {
+		t.Fatalf("base eval rule should be unchanged: %+v", base[0])
+	}
+
+	svc, err := cfg.Resolve("chatbot")
+	if err != nil {
+		t.Fatalf("resolve service: %v", err)
+	}
+	if len(svc) != 3 {
+		t.Fatalf("expected override to keep other rules, got %+v", svc)
+	}
+	if len(svc[0].Requires) != 1 || len(svc[0].Optional) != 1 {
+		t.Fatalf("expected eval override, got %+v", svc[0])
+	}
+
+	if _, err := cfg.Resolve("unknown"); err == nil || !strings.Contains(err.Error(), `unknown chain service "unknown" (defined: chatbot)`) {
+		t.Fatalf("expected unknown service error, got %v", err)
+	}
+	if _, err := DefaultConfig().Resolve("chatbot"); err == nil || !strings.Contains(err.Error(), "no services are defined") {
+		t.Fatalf("expected unknown service error for the built-in rules, got %v", err)
+	}
+}
+
+func TestChainConfigCycle(t *testing.T) {
+	cfg := Config{Rules: []Rule{
+		{Type: "a", Requires: []string{"b"}},
+		{Type: "b", Optional: []string{"a"}},
+	}}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "cycle: a -> b -> a") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestChainConfigServiceCycle(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Services = map[string][]Rule{
+		"loop": {{Type: "prompt_attestation", Requires: []string{"slo_attestation"}}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "service loop") {
+		t.Fatalf("expected service cycle error, got %v", err)
+	}
+}
+
+func TestChainConfigMalformedRules(t *testing.T) {
+	cases := map[string]Rule{
+		"type is required": {Requires: []string{"a"}},
+		"depend on itself": {Type: "a", Requires: []string{"a"}},
+		"more than once":   {Type: "a", Requires: []string{"b"}, Optional: []string{"b"}},
+	}
+	for want, rule := range cases {
+		err := Config{Rules: []Rule{rule}}.Validate()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%+v: expected %q, got %v", rule, want, err)
+		}
+	}
+
+	dup := Config{Rules: []Rule{{Type: "a"}, {Type: "a"}}}
+	if err := dup.Validate(); err == nil || !strings.Contains(err.Error(), "duplicate chain rule") {
+		t.Fatalf("expected duplicate rule error, got %v", err)
+	}
+}
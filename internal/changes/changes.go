@@ -0,0 +1,301 @@
+// Package changes resolves the list of changed files that drives gate
+// triggers and changed-only attestation (a file list, a unified diff, or a
+// git range) and detects the git ref being built for ref and branch triggers.
+package changes
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultGitRef is diffed against HEAD when no source is given.
+const DefaultGitRef = "HEAD~1"
+
+// Source selects where changed files come from. FilesFrom wins over
+// PatchFile, which wins over GitRange.
+type Source struct {
+	// FilesFrom is a file with one path per line; "-" reads stdin.
+	FilesFrom string
+	// PatchFile is a unified diff (git diff or diff -u output).
+	PatchFile string
+	// GitRange is "base...head" (diff from their merge base), "base..head"
+	// (direct diff) or a single ref meaning "ref...HEAD".
+	GitRange string
+	// AllowNoChanges turns git failures into an empty list. Without it a
+	// missing repository or unknown ref is an error, since an empty list
+	// silently disables every gate.
+	AllowNoChanges bool
+}
+
+// Resolve returns the changed files for src as slash-separated paths.
+func Resolve(src Source) ([]string, error) {
+	switch {
+	case src.FilesFrom != "":
+		return FromFileList(src.FilesFrom)
+	case src.PatchFile != "":
+		return FromPatch(src.PatchFile)
+	default:
+		files, err := FromGit(src.GitRange)
+		if err != nil {
+			if src.AllowNoChanges {
+				return []string{}, nil
+			}
+			return nil, err
+		}
+		return files, nil
+	}
+}
+
+// FromFileList reads one path per line, skipping blank lines and # comments.
+func FromFileList(path string) ([]string, error) {
+	raw, err := readInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("read changed files list: %w", err)
+	}
+	out := newPathSet()
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out.add(line)
+	}
+	return out.paths, nil
+}
+
+// FromPatch lists the files touched by a unified diff. Deleted files are
+// reported under their old path, renamed files under both paths.
+func FromPatch(path string) ([]string, error) {
+	raw, err := readInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("read patch: %w", err)
+	}
+	files, err := ParsePatch(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse patch %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// ParsePatch lists the files touched by a unified diff. Hunk bodies are
+// skipped by their line counts so content lines are never read as headers.
+func ParsePatch(raw []byte) ([]string, error) {
+	out := newPathSet()
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	oldPath := ""
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			if a, b, ok := splitGitHeader(strings.TrimPrefix(line, "diff --git ")); ok {
+				out.add(a)
+				out.add(b)
+			}
+		case strings.HasPrefix(line, "rename from "):
+			out.add(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to "):
+			out.add(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "--- "):
+			oldPath = headerPath(strings.TrimPrefix(line, "--- "))
+		case strings.HasPrefix(line, "+++ "):
+			newPath := headerPath(strings.TrimPrefix(line, "+++ "))
+			if newPath != "" {
+				out.add(newPath)
+			} else if oldPath != "" {
+				out.add(oldPath)
+			}
+			oldPath = ""
+		case strings.HasPrefix(line, "@@ "):
+			oldLines, newLines, err := hunkCounts(line)
+			if err != nil {
+				return nil, err
+			}
+			for (oldLines > 0 || newLines > 0) && sc.Scan() {
+				body := sc.Text()
+				switch {
+				case strings.HasPrefix(body, "\\"):
+				case strings.HasPrefix(body, "-"):
+					oldLines--
+				case strings.HasPrefix(body, "+"):
+					newLines--
+				default:
+					oldLines--
+					newLines--
+				}
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return out.paths, nil
+}
+
+// FromGit lists files changed in a git range. "base...head" diffs head
+// against the merge base of base and head; a single ref means "ref...HEAD".
+func FromGit(rangeSpec string) ([]string, error) {
+	if rangeSpec == "" {
+		rangeSpec = DefaultGitRef
+	}
+	if _, err := git("rev-parse", "--verify", "HEAD"); err != nil {
+		return nil, fmt.Errorf("changed files: not a git repository with commits: %w", err)
+	}
+	var args []string
+	switch {
+	case strings.Contains(rangeSpec, "..."):
+		base, head, _ := strings.Cut(rangeSpec, "...")
+		if head == "" {
+			head = "HEAD"
+		}
+		mergeBase, err := git("merge-base", base, head)
+		if err != nil {
+			return nil, fmt.Errorf("changed files: merge base of %s and %s: %w", base, head, err)
+		}
+		args = []string{strings.TrimSpace(mergeBase), head}
+	case strings.Contains(rangeSpec, ".."):
+		base, head, _ := strings.Cut(rangeSpec, "..")
+		if head == "" {
+			head = "HEAD"
+		}
+		args = []string{base, head}
+	default:
+		return FromGit(rangeSpec + "...HEAD")
+	}
+	raw, err := git(append([]string{"diff", "--name-only"}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("changed files: git diff %s: %w", rangeSpec, err)
+	}
+	out := newPathSet()
+	for _, line := range strings.Split(raw, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out.add(line)
+		}
+	}
+	return out.paths, nil
+}
+
+// DetectRef returns the full git ref being built: GITHUB_REF on GitHub
+// Actions, CI_COMMIT_TAG or CI_COMMIT_BRANCH on GitLab, otherwise the
+// checked-out branch or a tag pointing at HEAD. It returns "" when no ref is
+// known, e.g. on a detached, untagged checkout.
+func DetectRef() string {
+	if ref := os.Getenv("GITHUB_REF"); ref != "" {
+		return ref
+	}
+	if tag := os.Getenv("CI_COMMIT_TAG"); tag != "" {
+		return "refs/tags/" + tag
+	}
+	if branch := os.Getenv("CI_COMMIT_BRANCH"); branch != "" {
+		return "refs/heads/" + branch
+	}
+	if ref, err := git("symbolic-ref", "-q", "HEAD"); err == nil {
+		return strings.TrimSpace(ref)
+	}
+	if tag, err := git("describe", "--tags", "--exact-match", "HEAD"); err == nil {
+		return "refs/tags/" + strings.TrimSpace(tag)
+	}
+	return ""
+}
+
+func git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	raw, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// splitGitHeader splits "a/x b/y" from a diff --git line.
+func splitGitHeader(rest string) (string, string, bool) {
+	idx := strings.LastIndex(rest, " b/")
+	if idx < 0 || !strings.HasPrefix(rest, "a/") {
+		return "", "", false
+	}
+	return strings.TrimPrefix(rest[:idx], "a/"), rest[idx+len(" b/"):], true
+}
+
+// headerPath extracts the path from a ---/+++ header, dropping a trailing
+// timestamp and the a/ or b/ prefix. /dev/null yields "".
+func headerPath(v string) string {
+	if i := strings.IndexByte(v, '\t'); i >= 0 {
+		v = v[:i]
+	}
+	v = strings.TrimSpace(v)
+	if v == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(v, "a/") || strings.HasPrefix(v, "b/") {
+		v = v[2:]
+	}
+	return v
+}
+
+// hunkCounts parses "@@ -l[,s] +l[,s] @@"; an omitted count is 1.
+func hunkCounts(line string) (int, int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, fmt.Errorf("malformed hunk header %q", line)
+	}
+	count := func(spec string) (int, error) {
+		start, n, ok := strings.Cut(spec[1:], ",")
+		if _, err := strconv.Atoi(start); err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 1, nil
+		}
+		return strconv.Atoi(n)
+	}
+	oldLines, err := count(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk header %q", line)
+	}
+	newLines, err := count(fields[2])
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk header %q", line)
+	}
+	return oldLines, newLines, nil
+}
+
+// pathSet keeps slash-separated paths in first-seen order without duplicates.
+type pathSet struct {
+	paths []string
+	seen  map[string]struct{}
+}
+
+func newPathSet() *pathSet {
+	return &pathSet{paths: make([]string, 0), seen: make(map[string]struct{})}
+}
+
+func (s *pathSet) add(p string) {
+	p = strings.TrimPrefix(filepath.ToSlash(p), "./")
+	if p == "" {
+		return
+	}
+	if _, ok := s.seen[p]; ok {
+		return
+	}
+	s.seen[p] = struct{}{}
+	s.paths = append(s.paths, p)
+}
@@ -0,0 +1,217 @@
+package changes
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	orig, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(orig) })
+}
+
+func run(t *testing.T, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func commitFile(t *testing.T, name, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run(t, "add", name)
+	run(t, "commit", "-q", "-m", name)
+}
+
+// gitRepo creates a repo where main and feature diverge after "base.txt".
+func gitRepo(t *testing.T) {
+	t.Helper()
+	chdir(t, t.TempDir())
+	run(t, "init", "-q", "-b", "main")
+	commitFile(t, "base.txt", "base")
+	run(t, "checkout", "-q", "-b", "feature")
+	commitFile(t, "prompts/system.txt", "prompt")
+	run(t, "checkout", "-q", "main")
+	commitFile(t, "main-only.txt", "main")
+	run(t, "checkout", "-q", "feature")
+}
+
+// --- git ---
+
+func TestFromGitMergeBase(t *testing.T) {
+	gitRepo(t)
+	// Merge-base semantics ignore what landed on main after the branch point.
+	files, err := FromGit("main...feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{"prompts/system.txt"}) {
+		t.Fatalf("unexpected files: %v", files)
+	}
+	files, err = FromGit("main")
+	if err != nil || !reflect.DeepEqual(files, []string{"prompts/system.txt"}) {
+		t.Fatalf("bare ref should mean ref...HEAD: %v %v", files, err)
+	}
+	files, err = FromGit("main..feature")
+	if err != nil || !reflect.DeepEqual(files, []string{"main-only.txt", "prompts/system.txt"}) {
+		t.Fatalf("two-dot range should diff directly: %v %v", files, err)
+	}
+}
+
+func TestFromGitErrors(t *testing.T) {
+	gitRepo(t)
+	if _, err := FromGit("no-such-ref"); err == nil || !strings.Contains(err.Error(), "merge base") {
+		t.Fatalf("expected unknown ref error, got %v", err)
+	}
+
+	chdir(t, t.TempDir())
+	if _, err := FromGit(""); err == nil || !strings.Contains(err.Error(), "not a git repository") {
+		t.Fatalf("expected not a repository error, got %v", err)
+	}
+	files, err := Resolve(Source{AllowNoChanges: true})
+	if err != nil || len(files) != 0 {
+		t.Fatalf("AllowNoChanges should degrade to no files: %v %v", files, err)
+	}
+	if _, err := Resolve(Source{}); err == nil {
+		t.Fatal("expected git failure without AllowNoChanges")
+	}
+}
+
+// --- ref detection ---
+
+func clearCIRefs(t *testing.T) {
+	t.Helper()
+	for _, k := range []string{"GITHUB_REF", "CI_COMMIT_TAG", "CI_COMMIT_BRANCH"} {
+		t.Setenv(k, "")
+	}
+}
+
+func TestDetectRefFromCI(t *testing.T) {
+	clearCIRefs(t)
+	t.Setenv("CI_COMMIT_TAG", "v1.2.0")
+	if got := DetectRef(); got != "refs/tags/v1.2.0" {
+		t.Fatalf("gitlab tag: got %q", got)
+	}
+	t.Setenv("GITHUB_REF", "refs/heads/release/1.2")
+	if got := DetectRef(); got != "refs/heads/release/1.2" {
+		t.Fatalf("GITHUB_REF should win, got %q", got)
+	}
+}
+
+func TestDetectRefFromGit(t *testing.T) {
+	clearCIRefs(t)
+	gitRepo(t)
+	if got := DetectRef(); got != "refs/heads/feature" {
+		t.Fatalf("expected checked-out branch, got %q", got)
+	}
+	run(t, "tag", "v2.0.0")
+	run(t, "checkout", "-q", "--detach", "v2.0.0")
+	if got := DetectRef(); got != "refs/tags/v2.0.0" {
+		t.Fatalf("expected tag at detached HEAD, got %q", got)
+	}
+	run(t, "checkout", "-q", "--detach", "main")
+	if got := DetectRef(); got != "" {
+		t.Fatalf("expected no ref for untagged detached HEAD, got %q", got)
+	}
+}
+
+// --- file list ---
+
+func TestFromFileList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "changed.txt")
+	body := "# from CI\nprompts/system.txt\n\n./corpus/a.md\r\nprompts/system.txt\n"
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := Resolve(Source{FilesFrom: path, GitRange: "ignored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{"prompts/system.txt", "corpus/a.md"}) {
+		t.Fatalf("unexpected files: %v", files)
+	}
+	if _, err := FromFileList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatal("expected missing list error")
+	}
+}
+
+// --- patch ---
+
+const gitPatch = `diff --git a/prompts/system.txt b/prompts/system.txt
+index 1111111..2222222 100644
+--- a/prompts/system.txt
++++ b/prompts/system.txt
+@@ -1,3 +1,3 @@
+ keep
+---- not a header
++++++ not a header either
+ keep
+diff --git a/old.md b/old.md
+deleted file mode 100644
+--- a/old.md
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/routes/a.yaml b/routes/b.yaml
+similarity index 100%
+rename from routes/a.yaml
+rename to routes/b.yaml
+`
+
+func TestParsePatchGit(t *testing.T) {
+	files, err := ParsePatch([]byte(gitPatch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"prompts/system.txt", "old.md", "routes/a.yaml", "routes/b.yaml"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("got %v, want %v", files, want)
+	}
+}
+
+func TestParsePatchPlainDiff(t *testing.T) {
+	patch := "--- eval/config.yaml\t2026-10-01 10:00:00\n+++ eval/config.yaml\t2026-10-02 10:00:00\n@@ -2 +2 @@\n-a\n+b\n--- /dev/null\n+++ corpus/new.md\n@@ -0,0 +1 @@\n+new\n"
+	files, err := ParsePatch([]byte(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{"eval/config.yaml", "corpus/new.md"}) {
+		t.Fatalf("unexpected files: %v", files)
+	}
+	if _, err := ParsePatch([]byte("--- a\n+++ b\n@@ -x +1 @@\n")); err == nil {
+		t.Fatal("expected malformed hunk error")
+	}
+}
+
+func TestFromPatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "change.patch")
+	if err := os.WriteFile(path, []byte(gitPatch), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := Resolve(Source{PatchFile: path})
+	if err != nil || len(files) != 4 {
+		t.Fatalf("unexpected patch files: %v %v", files, err)
+	}
+}
@@ -0,0 +1,113 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	gohash "hash"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	SHA256  = "sha256"
+	SHA512  = "sha512"
+	SHA3256 = "sha3-256"
+)
+
+// SupportedAlgorithms lists the digest algorithms accepted in subjects and predicates.
+var SupportedAlgorithms = []string{SHA256, SHA512, SHA3256}
+
+func ParseAlgorithm(raw string) (string, error) {
+	alg := strings.ToLower(strings.TrimSpace(raw))
+	switch alg {
+	case SHA256, SHA512, SHA3256:
+		return alg, nil
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %q", raw)
+	}
+}
+
+func newHasher(alg string) (gohash.Hash, error) {
+	switch alg {
+	case "", SHA256:
+		return sha256.New(), nil
+	case SHA512:
+		return sha512.New(), nil
+	case SHA3256:
+		return sha3.New256(), nil
+	default:
+		return nil, fmt.Errorf("unsupported digest algorithm %q", alg)
+	}
+}
+
+// SplitDigest separates an "alg:hex" digest string. Values without a prefix
+// are treated as legacy sha256 hex.
+func SplitDigest(digest string) (string, string) {
+	if alg, value, ok := strings.Cut(digest, ":"); ok {
+		return alg, value
+	}
+	return SHA256, digest
+}
+
+func DigestBytesWith(alg string, raw []byte) (string, error) {
+	h, err := newHasher(alg)
+	if err != nil {
+		return "", err
+	}
+	h.Write(raw)
+	return algorithmName(alg) + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func DigestFileWith(path string, alg string) (string, int64, error) {
+	digests, size, err := DigestFileAll(path, []string{alg})
+	if err != nil {
+		return "", 0, err
+	}
+	return digests[algorithmName(alg)], size, nil
+}
+
+// DigestFileAll hashes a file once with every requested algorithm and returns
+// "alg:hex" digests keyed by algorithm name.
+func DigestFileAll(path string, algs []string) (map[string]string, int64, error) {
+	hashers := make(map[string]gohash.Hash, len(algs))
+	writers := make([]io.Writer, 0, len(algs))
+	for _, alg := range algs {
+		name := algorithmName(alg)
+		if _, ok := hashers[name]; ok {
+			continue
+		}
+		h, err := newHasher(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		hashers[name] = h
+		writers = append(writers, h)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("open file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(io.MultiWriter(writers...), f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("hash file %s: %w", path, err)
+	}
+	out := make(map[string]string, len(hashers))
+	for name, h := range hashers {
+		out[name] = name + ":" + hex.EncodeToString(h.Sum(nil))
+	}
+	return out, n, nil
+}
+
+func algorithmName(alg string) string {
+	if alg == "" {
+		return SHA256
+	}
+	return alg
+}
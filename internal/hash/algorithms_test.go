@@ -0,0 +1,108 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDigestBytesWith_Algorithms(t *testing.T) {
+	data := []byte("regulated payload")
+	s256 := sha256.Sum256(data)
+	s512 := sha512.Sum512(data)
+	s3 := sha3.Sum256(data)
+	tests := []struct {
+		alg  string
+		want string
+	}{
+		{"", "sha256:" + hex.EncodeToString(s256[:])},
+		{SHA256, "sha256:" + hex.EncodeToString(s256[:])},
+		{SHA512, "sha512:" + hex.EncodeToString(s512[:])},
+		{SHA3256, "sha3-256:" + hex.EncodeToString(s3[:])},
+	}
+	for _, tt := range tests {
+		got, err := DigestBytesWith(tt.alg, data)
+		if err != nil {
+			t.Fatalf("DigestBytesWith(%q): %v", tt.alg, err)
+		}
+		if got != tt.want {
+			t.Errorf("DigestBytesWith(%q) = %q, want %q", tt.alg, got, tt.want)
+		}
+	}
+}
+
+func TestDigestBytesWith_Unsupported(t *testing.T) {
+	if _, err := DigestBytesWith("md5", []byte("x")); err == nil {
+		t.Fatal("expected error for unsupported algorithm")
+	}
+}
+
+func TestDigestFileAll_SinglePass(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.bin")
+	os.WriteFile(path, []byte("hello world"), 0o644)
+
+	digests, size, err := DigestFileAll(path, []string{SHA256, SHA512, SHA3256, SHA256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 11 {
+		t.Errorf("size = %d, want 11", size)
+	}
+	if len(digests) != 3 {
+		t.Fatalf("digests = %d, want 3", len(digests))
+	}
+	legacy, _, _ := DigestFile(path)
+	if digests[SHA256] != legacy {
+		t.Errorf("sha256 digest %q does not match DigestFile %q", digests[SHA256], legacy)
+	}
+	if !strings.HasPrefix(digests[SHA512], "sha512:") || len(strings.TrimPrefix(digests[SHA512], "sha512:")) != 128 {
+		t.Errorf("unexpected sha512 digest %q", digests[SHA512])
+	}
+}
+
+func TestParseAlgorithmAndSplitDigest(t *testing.T) {
+	if alg, err := ParseAlgorithm(" SHA512 "); err != nil || alg != SHA512 {
+		t.Fatalf("ParseAlgorithm = %q, %v", alg, err)
+	}
+	if _, err := ParseAlgorithm("blake3"); err == nil {
+		t.Fatal("expected error for unsupported algorithm")
+	}
+	if alg, value := SplitDigest("sha3-256:abcd"); alg != SHA3256 || value != "abcd" {
+		t.Errorf("SplitDigest = %q, %q", alg, value)
+	}
+	if alg, value := SplitDigest("abcd"); alg != SHA256 || value != "abcd" {
+		t.Errorf("SplitDigest legacy = %q, %q", alg, value)
+	}
+}
+
+func TestDigestTreeWithOptions_AlgorithmRecorded(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644)
+
+	legacy, _, _, err := DigestTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	explicit256, _, _, err := DigestTreeWithOptions(dir, TreeOptions{Algorithm: SHA256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explicit256 != legacy {
+		t.Errorf("explicit sha256 tree digest should match legacy: %q vs %q", explicit256, legacy)
+	}
+	digest, manifest, entries, err := DigestTreeWithOptions(dir, TreeOptions{Algorithm: SHA512})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(digest, "sha512:") || !strings.HasPrefix(entries[0].Digest, "sha512:") {
+		t.Errorf("expected sha512 digests, got %q / %q", digest, entries[0].Digest)
+	}
+	if !strings.Contains(manifest, "algorithm=sha512") {
+		t.Errorf("manifest should record algorithm, got %q", manifest)
+	}
+}
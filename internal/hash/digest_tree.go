@@ -11,32 +11,124 @@ import (
 	"strings"
 )
 
+// SymlinkMode controls how DigestTree treats symbolic links found under the root.
+type SymlinkMode string
+
+const (
+	// SymlinkFollow hashes the contents of whatever file a symlink points to.
+	// It is the default, matching trees hashed before symlink modes existed.
+	SymlinkFollow SymlinkMode = "follow"
+	// SymlinkReject fails hashing when a symlink is encountered.
+	SymlinkReject SymlinkMode = "reject"
+	// SymlinkHashTargetPath records the link target path instead of following it.
+	SymlinkHashTargetPath SymlinkMode = "hash_target_path"
+	// SymlinkFollowWithinRoot hashes the target file, provided it resolves inside the root.
+	SymlinkFollowWithinRoot SymlinkMode = "follow_within_root"
+)
+
+const (
+	EntryFile    = "file"
+	EntrySymlink = "symlink"
+	EntryDir     = "dir"
+)
+
+// TreeOptions selects the optional tree-manifest behaviours. The zero value
+// follows symlinks and produces the legacy manifest format, so existing tree
+// digests are unchanged.
+type TreeOptions struct {
+	Algorithm string
+	Symlinks  SymlinkMode
+	EmptyDirs bool
+	FileModes bool
+}
+
 type TreeEntry struct {
 	Path     string
 	Digest   string
 	Size     int64
+	Kind     string
+	Mode     fs.FileMode
 	Manifest string
 }
 
+func ParseSymlinkMode(raw string) (SymlinkMode, error) {
+	switch SymlinkMode(strings.TrimSpace(raw)) {
+	case "":
+		return "", nil
+	case SymlinkFollow:
+		return SymlinkFollow, nil
+	case SymlinkReject:
+		return SymlinkReject, nil
+	case SymlinkHashTargetPath:
+		return SymlinkHashTargetPath, nil
+	case SymlinkFollowWithinRoot:
+		return SymlinkFollowWithinRoot, nil
+	default:
+		return "", fmt.Errorf("unsupported symlink mode %q", raw)
+	}
+}
+
+func (o TreeOptions) symlinkMode() SymlinkMode {
+	if o.Symlinks == "" {
+		return SymlinkFollow
+	}
+	return o.Symlinks
+}
+
+// explicit reports whether the options must be recorded in the manifest header.
+func (o TreeOptions) explicit() bool {
+	return algorithmName(o.Algorithm) != SHA256 || o.symlinkMode() != SymlinkFollow || o.EmptyDirs || o.FileModes
+}
+
+func (o TreeOptions) header() string {
+	return fmt.Sprintf("llmsa-tree/v1\x00algorithm=%s\x00symlinks=%s\x00empty_dirs=%t\x00file_modes=%t\n", algorithmName(o.Algorithm), o.symlinkMode(), o.EmptyDirs, o.FileModes)
+}
+
+func (o TreeOptions) validate() error {
+	if _, err := newHasher(o.Algorithm); err != nil {
+		return err
+	}
+	_, err := ParseSymlinkMode(string(o.Symlinks))
+	return err
+}
+
 func DigestTree(root string) (digest string, manifest string, entries []TreeEntry, err error) {
+	return DigestTreeWithOptions(root, TreeOptions{})
+}
+
+func DigestTreeWithOptions(root string, opts TreeOptions) (digest string, manifest string, entries []TreeEntry, err error) {
+	if err := opts.validate(); err != nil {
+		return "", "", nil, err
+	}
 	entries = make([]TreeEntry, 0)
 	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
 		}
-		if d.IsDir() {
-			return nil
-		}
 		rel, err := filepath.Rel(root, path)
 		if err != nil {
 			return err
 		}
 		norm := filepath.ToSlash(rel)
-		fileDigest, size, err := DigestFile(path)
+		if d.IsDir() {
+			if !opts.EmptyDirs || path == root {
+				return nil
+			}
+			children, err := os.ReadDir(path)
+			if err != nil {
+				return err
+			}
+			if len(children) == 0 {
+				entries = append(entries, TreeEntry{Path: norm, Kind: EntryDir})
+			}
+			return nil
+		}
+		entry, err := digestEntry(root, path, opts)
 		if err != nil {
 			return err
 		}
-		entries = append(entries, TreeEntry{Path: norm, Digest: fileDigest, Size: size})
+		entry.Path = norm
+		entries = append(entries, entry)
 		return nil
 	})
 	if err != nil {
@@ -48,15 +140,145 @@ func DigestTree(root string) (digest string, manifest string, entries []TreeEntr
 	})
 
 	var sb strings.Builder
+	if opts.explicit() {
+		sb.WriteString(opts.header())
+	}
 	for i := range entries {
-		line := fmt.Sprintf("%s\x00%s\x00%d\n", entries[i].Path, entries[i].Digest, entries[i].Size)
+		line := manifestLine(entries[i], opts)
 		entries[i].Manifest = line
 		sb.WriteString(line)
 	}
 
 	manifest = sb.String()
-	h := sha256.Sum256([]byte(manifest))
-	return "sha256:" + hex.EncodeToString(h[:]), manifest, entries, nil
+	digest, err = DigestBytesWith(opts.Algorithm, []byte(manifest))
+	if err != nil {
+		return "", "", nil, err
+	}
+	return digest, manifest, entries, nil
+}
+
+// DigestPath digests a single subject path under the given tree options. A
+// directory yields its tree digest; a symlink is handled according to
+// opts.Symlinks relative to root (the path's parent directory when empty).
+func DigestPath(root, path string, opts TreeOptions) (TreeEntry, error) {
+	if err := opts.validate(); err != nil {
+		return TreeEntry{}, err
+	}
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+	if fi.IsDir() {
+		digest, _, _, err := DigestTreeWithOptions(path, opts)
+		if err != nil {
+			return TreeEntry{}, err
+		}
+		return TreeEntry{Path: filepath.ToSlash(path), Digest: digest, Kind: EntryDir}, nil
+	}
+	if root == "" {
+		root = filepath.Dir(path)
+	}
+	entry, err := digestEntry(root, path, opts)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+	entry.Path = filepath.ToSlash(path)
+	return entry, nil
+}
+
+func digestEntry(root, path string, opts TreeOptions) (TreeEntry, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+	switch {
+	case fi.Mode()&fs.ModeSymlink != 0:
+		return digestSymlink(root, path, opts)
+	case fi.Mode().IsRegular():
+		return digestRegular(path, fi, opts)
+	case fi.IsDir():
+		return TreeEntry{}, fmt.Errorf("%s is a directory", path)
+	default:
+		return TreeEntry{}, fmt.Errorf("unsupported special file %s (%s)", path, fi.Mode().Type())
+	}
+}
+
+func digestRegular(path string, fi fs.FileInfo, opts TreeOptions) (TreeEntry, error) {
+	digest, size, err := DigestFileWith(path, opts.Algorithm)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+	entry := TreeEntry{Digest: digest, Size: size, Kind: EntryFile}
+	if opts.FileModes {
+		entry.Mode = fi.Mode().Perm()
+	}
+	return entry, nil
+}
+
+func digestSymlink(root, path string, opts TreeOptions) (TreeEntry, error) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+	switch opts.symlinkMode() {
+	case SymlinkFollow:
+		fi, err := os.Stat(path)
+		if err != nil {
+			return TreeEntry{}, fmt.Errorf("resolve symlink %s: %w", path, err)
+		}
+		if !fi.Mode().IsRegular() {
+			return TreeEntry{}, fmt.Errorf("symlink %s must resolve to a regular file", path)
+		}
+		return digestRegular(path, fi, opts)
+	case SymlinkHashTargetPath:
+		target = filepath.ToSlash(target)
+		digest, err := DigestBytesWith(opts.Algorithm, []byte(target))
+		if err != nil {
+			return TreeEntry{}, err
+		}
+		return TreeEntry{Digest: digest, Size: int64(len(target)), Kind: EntrySymlink}, nil
+	case SymlinkFollowWithinRoot:
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return TreeEntry{}, fmt.Errorf("resolve symlink %s: %w", path, err)
+		}
+		realRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			return TreeEntry{}, fmt.Errorf("resolve root %s: %w", root, err)
+		}
+		if !withinRoot(realRoot, resolved) {
+			return TreeEntry{}, fmt.Errorf("symlink %s -> %s escapes root %s", path, target, root)
+		}
+		fi, err := os.Stat(resolved)
+		if err != nil {
+			return TreeEntry{}, err
+		}
+		if !fi.Mode().IsRegular() {
+			return TreeEntry{}, fmt.Errorf("symlink %s must resolve to a regular file", path)
+		}
+		return digestRegular(resolved, fi, opts)
+	default:
+		return TreeEntry{}, fmt.Errorf("symlink %s -> %s rejected (symlink mode %s)", path, target, SymlinkReject)
+	}
+}
+
+func withinRoot(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func manifestLine(e TreeEntry, opts TreeOptions) string {
+	line := fmt.Sprintf("%s\x00%s\x00%d", e.Path, e.Digest, e.Size)
+	if e.Kind != "" && e.Kind != EntryFile {
+		line += "\x00kind=" + e.Kind
+	}
+	if opts.FileModes && e.Kind == EntryFile {
+		line += fmt.Sprintf("\x00mode=%04o", e.Mode)
+	}
+	return line + "\n"
 }
 
 func DigestBytes(raw []byte) string {
@@ -3,6 +3,7 @@ package hash
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -151,3 +152,131 @@ func TestFileExists_False(t *testing.T) {
 		t.Error("FileExists should return false for nonexistent file")
 	}
 }
+
+func TestDigestTree_SymlinkFollowedByDefault(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644)
+	target := filepath.Join(t.TempDir(), "target.txt")
+	os.WriteFile(target, []byte("target"), 0o644)
+	if err := os.Symlink(target, filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	_, manifest, entries, err := DigestTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Trees hashed before symlink modes existed read through links, so the
+	// default manifest must be the legacy one.
+	want := fmt.Sprintf("a.txt\x00%s\x001\nlink.txt\x00%s\x006\n", DigestBytes([]byte("a")), DigestBytes([]byte("target")))
+	if manifest != want || len(entries) != 2 || entries[1].Kind != EntryFile {
+		t.Fatalf("unexpected default manifest %q (%+v)", manifest, entries)
+	}
+}
+
+func TestDigestTree_SymlinkReject(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644)
+	if err := os.Symlink("/etc/hosts", filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	_, _, _, err := DigestTreeWithOptions(dir, TreeOptions{Symlinks: SymlinkReject})
+	if err == nil {
+		t.Fatal("expected symlink to be rejected")
+	}
+	if !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDigestTree_SymlinkHashTargetPath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Symlink("../outside.txt", filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	_, manifest, entries, err := DigestTreeWithOptions(dir, TreeOptions{Symlinks: SymlinkHashTargetPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Kind != EntrySymlink {
+		t.Fatalf("expected one symlink entry, got %+v", entries)
+	}
+	if entries[0].Digest != DigestBytes([]byte("../outside.txt")) {
+		t.Errorf("symlink digest = %q", entries[0].Digest)
+	}
+	if !strings.HasPrefix(manifest, "llmsa-tree/v1\x00algorithm=sha256\x00symlinks=hash_target_path") {
+		t.Errorf("manifest should record symlink mode, got %q", manifest)
+	}
+	if !strings.Contains(manifest, "kind=symlink") {
+		t.Errorf("manifest should mark symlink entry, got %q", manifest)
+	}
+}
+
+func TestDigestTree_SymlinkFollowWithinRoot(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "real.txt"), []byte("payload"), 0o644)
+	if err := os.Symlink("real.txt", filepath.Join(dir, "alias.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	_, _, entries, err := DigestTreeWithOptions(dir, TreeOptions{Symlinks: SymlinkFollowWithinRoot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+	if entries[0].Digest != entries[1].Digest {
+		t.Errorf("followed symlink should hash target content: %q vs %q", entries[0].Digest, entries[1].Digest)
+	}
+}
+
+func TestDigestTree_SymlinkFollowEscapingRoot(t *testing.T) {
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644)
+	dir := t.TempDir()
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	_, _, _, err := DigestTreeWithOptions(dir, TreeOptions{Symlinks: SymlinkFollowWithinRoot})
+	if err == nil {
+		t.Fatal("expected error for symlink escaping root")
+	}
+	if !strings.Contains(err.Error(), "escapes root") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDigestTree_EmptyDirsAndModes(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "empty"), 0o755)
+	os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh"), 0o755)
+
+	legacy, _, _, err := DigestTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, manifest, entries, err := DigestTreeWithOptions(dir, TreeOptions{EmptyDirs: true, FileModes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest == legacy {
+		t.Error("optional manifest fields should change the tree digest")
+	}
+	if len(entries) != 2 || entries[0].Kind != EntryDir {
+		t.Fatalf("expected empty dir entry first, got %+v", entries)
+	}
+	if !strings.Contains(manifest, "run.sh\x00") || !strings.Contains(manifest, "mode=0755") {
+		t.Errorf("manifest should record file mode, got %q", manifest)
+	}
+}
+
+func TestDigestTree_InvalidSymlinkMode(t *testing.T) {
+	_, _, _, err := DigestTreeWithOptions(t.TempDir(), TreeOptions{Symlinks: "sometimes"})
+	if err == nil {
+		t.Fatal("expected error for unsupported symlink mode")
+	}
+}
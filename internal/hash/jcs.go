@@ -0,0 +1,196 @@
+package hash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// CanonicalizationLegacy is the original llmsa canonical form produced by
+	// CanonicalJSON. Bundles without a recorded version use it.
+	CanonicalizationLegacy = "llmsa-c14n-v1"
+	// CanonicalizationJCS is the RFC 8785 JSON Canonicalization Scheme.
+	CanonicalizationJCS = "jcs-rfc8785"
+)
+
+// DefaultCanonicalization is used for newly signed bundles.
+const DefaultCanonicalization = CanonicalizationJCS
+
+// Canonicalize serialises v with the named canonicalization version.
+func Canonicalize(version string, v any) ([]byte, error) {
+	switch version {
+	case "", CanonicalizationLegacy:
+		return CanonicalJSON(v)
+	case CanonicalizationJCS:
+		return CanonicalJSONJCS(v)
+	default:
+		return nil, fmt.Errorf("unsupported canonicalization %q", version)
+	}
+}
+
+// CanonicalJSONJCS serialises v per RFC 8785: UTF-16 ordered object keys,
+// ECMAScript number formatting and minimal string escaping.
+func CanonicalJSONJCS(v any) ([]byte, error) {
+	input, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal for canonicalization: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	var normalized any
+	if err := dec.Decode(&normalized); err != nil {
+		return nil, fmt.Errorf("decode for canonicalization: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeJCS(buf, normalized); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJCS(w *bytes.Buffer, v any) error {
+	switch vv := v.(type) {
+	case nil:
+		w.WriteString("null")
+	case bool:
+		w.WriteString(strconv.FormatBool(vv))
+	case string:
+		writeJCSString(w, vv)
+	case json.Number:
+		f, err := strconv.ParseFloat(vv.String(), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q: %w", vv, err)
+		}
+		n, err := formatES6Number(f)
+		if err != nil {
+			return err
+		}
+		w.WriteString(n)
+	case []any:
+		w.WriteByte('[')
+		for i, item := range vv {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := writeJCS(w, item); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUTF16(keys[i], keys[j])
+		})
+		w.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeJCSString(w, k)
+			w.WriteByte(':')
+			if err := writeJCS(w, vv[k]); err != nil {
+				return err
+			}
+		}
+		w.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported canonical value %T", v)
+	}
+	return nil
+}
+
+// writeJCSString escapes only what JSON requires, as ECMAScript JSON.stringify does.
+func writeJCSString(sb *bytes.Buffer, s string) {
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(sb, `\u%04x`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+}
+
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// formatES6Number implements ECMAScript Number.prototype.toString for finite
+// doubles, which RFC 8785 mandates for JSON numbers.
+func formatES6Number(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("number %v is not representable in JSON", f)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+	mantissa, expPart, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp, err := strconv.Atoi(expPart)
+	if err != nil {
+		return "", fmt.Errorf("format number %v: %w", f, err)
+	}
+	k := len(digits)
+	n := exp + 1
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k), nil
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:], nil
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits, nil
+	}
+	e := n - 1
+	expSign := "+"
+	if e < 0 {
+		expSign = "-"
+		e = -e
+	}
+	m := digits[:1]
+	if k > 1 {
+		m += "." + digits[1:]
+	}
+	return sign + m + "e" + expSign + strconv.Itoa(e), nil
+}
@@ -0,0 +1,143 @@
+package hash
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Test vectors from RFC 8785 section 3.2.2 / 3.2.3 and Appendix B.
+
+func TestCanonicalJSONJCS_RFC8785Example(t *testing.T) {
+	input := json.RawMessage(`{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`)
+	want := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	got, err := CanonicalJSONJCS(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("JCS output mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestCanonicalJSONJCS_RFC8785PropertySorting(t *testing.T) {
+	input := json.RawMessage(`{
+  "\u20ac": "Euro Sign",
+  "\r": "Carriage Return",
+  "\ufb33": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "\ud83d\ude00": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "\u00f6": "Latin Small Letter O With Diaeresis"
+}`)
+	want := "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+
+	got, err := CanonicalJSONJCS(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("JCS sorting mismatch\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestFormatES6Number_RFC8785AppendixB(t *testing.T) {
+	tests := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+	for _, tt := range tests {
+		got, err := formatES6Number(math.Float64frombits(tt.bits))
+		if err != nil {
+			t.Fatalf("%016x: %v", tt.bits, err)
+		}
+		if got != tt.want {
+			t.Errorf("%016x = %q, want %q", tt.bits, got, tt.want)
+		}
+	}
+}
+
+func TestFormatES6Number_NonFinite(t *testing.T) {
+	for _, bits := range []uint64{0x7fffffffffffffff, 0x7ff0000000000000} {
+		if _, err := formatES6Number(math.Float64frombits(bits)); err == nil {
+			t.Errorf("%016x: expected error", bits)
+		}
+	}
+}
+
+func TestCanonicalJSONJCS_NoHTMLEscaping(t *testing.T) {
+	got, err := CanonicalJSONJCS(map[string]any{"tpl": "<b>a & b</b>", "ls": "\u2028"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"ls\":\"\u2028\",\"tpl\":\"<b>a & b</b>\"}"
+	if string(got) != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	legacy, err := CanonicalJSON(map[string]any{"tpl": "<b>a & b</b>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(legacy), `\u003c`) {
+		t.Fatalf("legacy canonical form is expected to keep HTML escaping, got %s", legacy)
+	}
+}
+
+func TestCanonicalize_Versions(t *testing.T) {
+	v := map[string]any{"a": "<x>"}
+	legacy, err := Canonicalize("", v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(legacy) != `{"a":"\u003cx\u003e"}` {
+		t.Errorf("legacy = %s", legacy)
+	}
+	named, err := Canonicalize(CanonicalizationLegacy, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(named) != string(legacy) {
+		t.Errorf("named legacy = %s, want %s", named, legacy)
+	}
+	jcs, err := Canonicalize(CanonicalizationJCS, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(jcs) != `{"a":"<x>"}` {
+		t.Errorf("jcs = %s", jcs)
+	}
+	if _, err := Canonicalize("c14n-v9", v); err == nil {
+		t.Fatal("expected unsupported canonicalization error")
+	}
+}
@@ -0,0 +1,226 @@
+// Package policytest runs fixture cases against the YAML and Rego policy
+// engines.
+package policytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	policyrego "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/rego"
+	policyyaml "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
+	goyaml "gopkg.in/yaml.v3"
+)
+
+// Engines accepted by Run.
+const (
+	EngineYAML = "yaml"
+	EngineRego = "rego"
+	EngineBoth = "both"
+)
+
+// Case is one fixture: the gate inputs and the expected outcome. Bundles and
+// Verification are passed only to the Rego engine; Waivers are added to the
+// policy's own for this case. Ref and Environment feed ref, branch and
+// environment triggers.
+type Case struct {
+	Name         string                        `json:"name"`
+	ChangedFiles []string                      `json:"changed_files"`
+	Ref          string                        `json:"ref,omitempty"`
+	Environment  string                        `json:"environment,omitempty"`
+	Statements   []policyyaml.StatementView    `json:"statements"`
+	Bundles      []policyrego.BundleInput      `json:"bundles,omitempty"`
+	Verification *policyrego.VerificationInput `json:"verification,omitempty"`
+	Waivers      []policyyaml.Waiver           `json:"waivers,omitempty"`
+	Expect       Expectation                   `json:"expect"`
+	File         string                        `json:"-"`
+}
+
+// Expectation is the expected gate outcome. When Violations is nil only
+// Allow is compared; an empty list asserts there are none.
+type Expectation struct {
+	Allow      bool     `json:"allow"`
+	Violations []string `json:"violations"`
+}
+
+// Options selects the policies and engines to run.
+type Options struct {
+	Policy     policyyaml.Policy
+	RegoPolicy string
+	Engine     string
+	// Parity additionally requires both engines to return the same outcome.
+	Parity bool
+}
+
+// Result is the outcome of one case on one engine ("parity" for the
+// cross-engine comparison). Diff lists the mismatches when Passed is false.
+type Result struct {
+	Case   string   `json:"case"`
+	File   string   `json:"file"`
+	Engine string   `json:"engine"`
+	Passed bool     `json:"passed"`
+	Diff   []string `json:"diff,omitempty"`
+}
+
+type outcome struct {
+	allow      bool
+	violations []string
+}
+
+// LoadCases reads every *.yaml, *.yml and *.json case file in dir, sorted by
+// file name. A case without a name is named after its file.
+func LoadCases(dir string) ([]Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read policy test cases: %w", err)
+	}
+	cases := make([]Case, 0, len(entries))
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		c, err := loadCase(path)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].File < cases[j].File })
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no policy test cases found in %s", dir)
+	}
+	return cases, nil
+}
+
+// loadCase decodes a YAML (or JSON) case through JSON so StatementView and the
+// Rego input types keep their JSON field names.
+func loadCase(path string) (Case, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Case{}, fmt.Errorf("read case %s: %w", path, err)
+	}
+	var doc map[string]any
+	if err := goyaml.Unmarshal(raw, &doc); err != nil {
+		return Case{}, fmt.Errorf("parse case %s: %w", path, err)
+	}
+	asJSON, err := json.Marshal(doc)
+	if err != nil {
+		return Case{}, fmt.Errorf("parse case %s: %w", path, err)
+	}
+	var c Case
+	if err := json.Unmarshal(asJSON, &c); err != nil {
+		return Case{}, fmt.Errorf("parse case %s: %w", path, err)
+	}
+	c.File = path
+	if c.Name == "" {
+		c.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return c, nil
+}
+
+// Run evaluates every case on the selected engines and returns one result
+// per case and engine, plus a parity result per case when requested.
+func Run(cases []Case, opts Options) ([]Result, error) {
+	engine := opts.Engine
+	if engine == "" {
+		engine = EngineYAML
+	}
+	if engine != EngineYAML && engine != EngineRego && engine != EngineBoth {
+		return nil, fmt.Errorf("unsupported policy engine %s", engine)
+	}
+	runYAML := engine != EngineRego || opts.Parity
+	runRego := engine != EngineYAML || opts.Parity
+	if runRego && opts.RegoPolicy == "" {
+		return nil, fmt.Errorf("rego policy path is required for engine %s", engine)
+	}
+
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		var yamlOut, regoOut outcome
+		policy := opts.Policy
+		if len(c.Waivers) > 0 {
+			policy.Waivers = append(append([]policyyaml.Waiver{}, policy.Waivers...), c.Waivers...)
+		}
+		trigger := policyyaml.TriggerContext{Ref: c.Ref, Environment: c.Environment}
+		if runYAML {
+			ev, err := policyyaml.EvaluateContext(policy, c.Statements, c.ChangedFiles, trigger)
+			if err != nil {
+				return nil, fmt.Errorf("case %s: yaml evaluate: %w", c.Name, err)
+			}
+			yamlOut = outcome{allow: len(ev.Violations) == 0, violations: sorted(ev.Violations)}
+			if engine != EngineRego {
+				results = append(results, compareExpected(c, EngineYAML, yamlOut))
+			}
+		}
+		if runRego {
+			input := policyrego.BuildInputWithOptions(policy, c.Statements, c.ChangedFiles, policyrego.InputOptions{Bundles: c.Bundles, Trigger: trigger})
+			input.Verification = c.Verification
+			res, err := policyrego.Evaluate(opts.RegoPolicy, input)
+			if err != nil {
+				return nil, fmt.Errorf("case %s: %w", c.Name, err)
+			}
+			regoOut = outcome{allow: res.Allow, violations: sorted(res.Violations)}
+			if engine != EngineYAML {
+				results = append(results, compareExpected(c, EngineRego, regoOut))
+			}
+		}
+		if opts.Parity {
+			results = append(results, compareParity(c, yamlOut, regoOut))
+		}
+	}
+	return results, nil
+}
+
+func compareExpected(c Case, engine string, got outcome) Result {
+	r := Result{Case: c.Name, File: c.File, Engine: engine}
+	if got.allow != c.Expect.Allow {
+		r.Diff = append(r.Diff, fmt.Sprintf("allow: want %t, got %t", c.Expect.Allow, got.allow))
+	}
+	if c.Expect.Violations != nil {
+		r.Diff = append(r.Diff, diffViolations(sorted(c.Expect.Violations), got.violations, "- missing: ", "+ unexpected: ")...)
+	}
+	r.Passed = len(r.Diff) == 0
+	return r
+}
+
+func compareParity(c Case, yamlOut, regoOut outcome) Result {
+	r := Result{Case: c.Name, File: c.File, Engine: "parity"}
+	if yamlOut.allow != regoOut.allow {
+		r.Diff = append(r.Diff, fmt.Sprintf("allow: yaml %t, rego %t", yamlOut.allow, regoOut.allow))
+	}
+	r.Diff = append(r.Diff, diffViolations(yamlOut.violations, regoOut.violations, "- yaml only: ", "+ rego only: ")...)
+	r.Passed = len(r.Diff) == 0
+	return r
+}
+
+// diffViolations lists entries of want missing from got and of got missing
+// from want, each with its prefix. Both inputs must be sorted.
+func diffViolations(want, got []string, missingPrefix, extraPrefix string) []string {
+	var out []string
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case j == len(got) || (i < len(want) && want[i] < got[j]):
+			out = append(out, missingPrefix+want[i])
+			i++
+		case i == len(want) || got[j] < want[i]:
+			out = append(out, extraPrefix+got[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+func sorted(in []string) []string {
+	out := append([]string{}, in...)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,165 @@
+package policytest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	policyyaml "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
+)
+
+func repoRoot(t *testing.T) string {
+	t.Helper()
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatalf("cannot resolve test file path")
+	}
+	return filepath.Clean(filepath.Join(filepath.Dir(filename), "..", "..", ".."))
+}
+
+func mvpOptions(t *testing.T, engine string, parity bool) Options {
+	t.Helper()
+	policy, err := policyyaml.LoadPolicy(filepath.Join(repoRoot(t), "policy", "examples", "mvp-gates.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Options{
+		Policy:     policy,
+		RegoPolicy: filepath.Join(repoRoot(t), "policy", "examples", "rego-gates.rego"),
+		Engine:     engine,
+		Parity:     parity,
+	}
+}
+
+func writeCase(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// --- shipped fixtures ---
+
+func TestMVPFixturesPassOnBothEngines(t *testing.T) {
+	cases, err := LoadCases(filepath.Join(repoRoot(t), "policy", "tests", "mvp-gates"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := Run(cases, mvpOptions(t, EngineBoth, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3*len(cases) {
+		t.Fatalf("expected yaml, rego and parity results per case, got %d for %d cases", len(results), len(cases))
+	}
+	for _, r := range results {
+		if !r.Passed {
+			t.Errorf("%s %s failed: %v", r.Engine, r.Case, r.Diff)
+		}
+	}
+}
+
+// --- diffs ---
+
+func TestRunReportsDiff(t *testing.T) {
+	dir := t.TempDir()
+	writeCase(t, dir, "wrong.yaml", `changed_files: [examples/tiny-rag/route/route.yaml]
+statements:
+  - attestation_type: route_attestation
+    statement_id: route-1
+    privacy_mode: hash_only
+expect:
+  allow: true
+  violations: ["Something else."]
+`)
+	cases, err := LoadCases(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cases[0].Name != "wrong" {
+		t.Fatalf("expected case named after file, got %q", cases[0].Name)
+	}
+	results, err := Run(cases, mvpOptions(t, EngineYAML, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Passed {
+		t.Fatalf("expected one failing result, got %+v", results)
+	}
+	want := []string{
+		"allow: want true, got false",
+		"+ unexpected: Route changed without valid SLO attestation.",
+		"- missing: Something else.",
+	}
+	if !reflect.DeepEqual(results[0].Diff, want) {
+		t.Fatalf("diff = %q, want %q", results[0].Diff, want)
+	}
+}
+
+func TestRunParityMismatch(t *testing.T) {
+	dir := t.TempDir()
+	writeCase(t, dir, "case.yaml", `changed_files: [app/main.go]
+statements: []
+expect:
+  allow: false
+`)
+	cases, err := LoadCases(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := mvpOptions(t, EngineYAML, true)
+	// Without a message, the engines format the missing-attestation list differently.
+	opts.Policy = policyyaml.Policy{Gates: []policyyaml.Gate{{ID: "G1", TriggerPaths: []string{"app/**"}, RequiredAttestations: []string{"prompt_attestation"}}}}
+	results, err := Run(cases, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !results[0].Passed || results[1].Engine != "parity" || results[1].Passed {
+		t.Fatalf("expected yaml pass and parity failure, got %+v", results)
+	}
+	joined := strings.Join(results[1].Diff, "\n")
+	if !strings.Contains(joined, "- yaml only: G1 missing attestations: prompt_attestation") || !strings.Contains(joined, "+ rego only: ") {
+		t.Fatalf("unexpected parity diff: %v", results[1].Diff)
+	}
+}
+
+func TestDiffViolations(t *testing.T) {
+	got := diffViolations([]string{"a", "c", "d"}, []string{"b", "c", "e"}, "-", "+")
+	want := []string{"-a", "+b", "-d", "+e"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if d := diffViolations(nil, nil, "-", "+"); len(d) != 0 {
+		t.Fatalf("expected no diff, got %v", d)
+	}
+}
+
+// --- errors ---
+
+func TestLoadCasesErrors(t *testing.T) {
+	if _, err := LoadCases(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expected error for missing dir")
+	}
+	empty := t.TempDir()
+	writeCase(t, empty, "README.md", "not a case")
+	if _, err := LoadCases(empty); err == nil || !strings.Contains(err.Error(), "no policy test cases") {
+		t.Fatalf("expected no-cases error, got %v", err)
+	}
+	bad := t.TempDir()
+	writeCase(t, bad, "bad.yaml", "changed_files: [unterminated")
+	if _, err := LoadCases(bad); err == nil || !strings.Contains(err.Error(), "parse case") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestRunOptionErrors(t *testing.T) {
+	if _, err := Run(nil, Options{Engine: "opa"}); err == nil || !strings.Contains(err.Error(), "unsupported policy engine") {
+		t.Fatalf("expected engine error, got %v", err)
+	}
+	if _, err := Run(nil, Options{Engine: EngineYAML, Parity: true}); err == nil || !strings.Contains(err.Error(), "rego policy path is required") {
+		t.Fatalf("expected rego path error, got %v", err)
+	}
+}
@@ -92,6 +92,20 @@ result := {"allow": false, "violations": ["missing prompt attestation", "missing
 	}
 }
 
+func TestEvaluateModule(t *testing.T) {
+	module := []byte(`package llmsa.gates
+
+result := {"allow": true, "violations": []}
+`)
+	r, err := EvaluateModule("inline.rego", module, Input{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Allow {
+		t.Fatal("expected allow=true")
+	}
+}
+
 // --- decodeResult edge cases ---
 
 func TestDecodeResult_NonObjectInput(t *testing.T) {
@@ -6,16 +6,24 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
 	oparego "github.com/open-policy-agent/opa/rego"
 )
 
 type Input struct {
+	InputVersion       string               `json:"input_version"`
 	ChangedFiles       []string             `json:"changed_files"`
 	Statements         []yaml.StatementView `json:"statements"`
 	Gates              []yaml.Gate          `json:"gates"`
 	PlaintextAllowlist []string             `json:"plaintext_allowlist"`
+	Waivers            []yaml.Waiver        `json:"waivers"`
+	Ref                string               `json:"ref"`
+	Branch             string               `json:"branch"`
+	Environment        string               `json:"environment"`
+	Bundles            []BundleInput        `json:"bundles"`
+	Verification       *VerificationInput   `json:"verification,omitempty"`
 }
 
 type Result struct {
@@ -24,12 +32,29 @@ type Result struct {
 }
 
 func BuildInput(policy yaml.Policy, statements []yaml.StatementView, changed []string) Input {
-	return Input{
+	in := Input{
+		InputVersion:       InputVersion,
 		ChangedFiles:       changed,
 		Statements:         statements,
 		Gates:              policy.Gates,
 		PlaintextAllowlist: policy.PlaintextAllowlist,
+		Waivers:            yaml.ActiveWaivers(policy, time.Now()),
+		Bundles:            []BundleInput{},
 	}
+	// Keep list fields as arrays, never null, so policies can iterate them.
+	if in.ChangedFiles == nil {
+		in.ChangedFiles = []string{}
+	}
+	if in.Statements == nil {
+		in.Statements = []yaml.StatementView{}
+	}
+	if in.Gates == nil {
+		in.Gates = []yaml.Gate{}
+	}
+	if in.PlaintextAllowlist == nil {
+		in.PlaintextAllowlist = []string{}
+	}
+	return in
 }
 
 func Evaluate(policyPath string, input Input) (Result, error) {
@@ -37,10 +62,15 @@ func Evaluate(policyPath string, input Input) (Result, error) {
 	if err != nil {
 		return Result{}, fmt.Errorf("read rego policy: %w", err)
 	}
+	return EvaluateModule(filepath.Base(policyPath), raw, input)
+}
 
+// EvaluateModule is Evaluate for a rego module already read into memory,
+// e.g. the bytes whose signature was just checked.
+func EvaluateModule(name string, module []byte, input Input) (Result, error) {
 	query, err := oparego.New(
 		oparego.Query("data.llmsa.gates.result"),
-		oparego.Module(filepath.Base(policyPath), string(raw)),
+		oparego.Module(name, string(module)),
 		oparego.Input(input),
 	).PrepareForEval(context.Background())
 	if err != nil {
@@ -0,0 +1,149 @@
+package rego
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
+)
+
+// InputVersion is the version of the Rego input document, described by
+// schemas/rego/input-v2.schema.json. Version 1 carried only changed_files,
+// statements, gates and plaintext_allowlist, all of which version 2 keeps.
+const InputVersion = "2"
+
+// BundleInput is one signed bundle as seen by Rego: the full decoded
+// statement plus the envelope's signature metadata.
+type BundleInput struct {
+	Path             string           `json:"path"`
+	StatementHash    string           `json:"statement_hash"`
+	Canonicalization string           `json:"canonicalization,omitempty"`
+	Statement        map[string]any   `json:"statement"`
+	Signatures       []SignatureInput `json:"signatures"`
+}
+
+// SignatureInput is the signer identity of one DSSE signature. Signature
+// bytes and key material are omitted.
+type SignatureInput struct {
+	KeyID          string `json:"key_id"`
+	Provider       string `json:"provider"`
+	OIDCIssuer     string `json:"oidc_issuer,omitempty"`
+	OIDCIdentity   string `json:"oidc_identity,omitempty"`
+	HasCertificate bool   `json:"has_certificate"`
+}
+
+// VerificationInput carries the outcome of verify.Run.
+type VerificationInput struct {
+	Passed          bool                 `json:"passed"`
+	ExitCode        int                  `json:"exit_code"`
+	Checks          []verify.CheckResult `json:"checks"`
+	ChainValid      bool                 `json:"chain_valid"`
+	ChainEdges      []verify.ChainEdge   `json:"chain_edges"`
+	ChainViolations []string             `json:"chain_violations"`
+}
+
+// InputOptions adds bundle, verification and trigger context to the Rego
+// input.
+type InputOptions struct {
+	Bundles      []BundleInput
+	Verification *verify.Report
+	Trigger      yaml.TriggerContext
+}
+
+// NewVerificationInput converts a verify report to its Rego form.
+func NewVerificationInput(r verify.Report) *VerificationInput {
+	out := &VerificationInput{
+		Passed:          r.Passed,
+		ExitCode:        r.ExitCode,
+		Checks:          r.Checks,
+		ChainValid:      r.Chain.Valid,
+		ChainEdges:      r.Chain.Edges,
+		ChainViolations: r.Chain.Violations,
+	}
+	if out.Checks == nil {
+		out.Checks = []verify.CheckResult{}
+	}
+	if out.ChainEdges == nil {
+		out.ChainEdges = []verify.ChainEdge{}
+	}
+	if out.ChainViolations == nil {
+		out.ChainViolations = []string{}
+	}
+	return out
+}
+
+// LoadBundles reads every *.bundle.json under source (a file or directory)
+// and decodes its statement and signature metadata.
+func LoadBundles(source string) ([]BundleInput, error) {
+	fi, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{source}
+	if fi.IsDir() {
+		entries, err := os.ReadDir(source)
+		if err != nil {
+			return nil, err
+		}
+		paths = paths[:0]
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), ".bundle.json") {
+				paths = append(paths, filepath.Join(source, e.Name()))
+			}
+		}
+	} else if !strings.HasSuffix(source, ".bundle.json") {
+		return []BundleInput{}, nil
+	}
+	sort.Strings(paths)
+
+	out := make([]BundleInput, 0, len(paths))
+	for _, p := range paths {
+		bundle, err := sign.ReadBundle(p)
+		if err != nil {
+			return nil, fmt.Errorf("read bundle %s: %w", p, err)
+		}
+		var statement map[string]any
+		if err := sign.DecodePayload(bundle, &statement); err != nil {
+			return nil, fmt.Errorf("decode bundle %s: %w", p, err)
+		}
+		sigs := make([]SignatureInput, 0, len(bundle.Envelope.Signatures))
+		for _, s := range bundle.Envelope.Signatures {
+			sigs = append(sigs, SignatureInput{
+				KeyID:          s.KeyID,
+				Provider:       s.Provider,
+				OIDCIssuer:     s.OIDCIssuer,
+				OIDCIdentity:   s.OIDCIdentity,
+				HasCertificate: s.CertificatePEM != "",
+			})
+		}
+		out = append(out, BundleInput{
+			Path:             p,
+			StatementHash:    bundle.Metadata.StatementHash,
+			Canonicalization: bundle.Metadata.Canonicalization,
+			Statement:        statement,
+			Signatures:       sigs,
+		})
+	}
+	return out, nil
+}
+
+// BuildInputWithOptions builds the versioned Rego input, including bundles
+// and verification results when given.
+func BuildInputWithOptions(policy yaml.Policy, statements []yaml.StatementView, changed []string, opts InputOptions) Input {
+	in := BuildInput(policy, statements, changed)
+	if opts.Bundles != nil {
+		in.Bundles = opts.Bundles
+	}
+	if opts.Verification != nil {
+		in.Verification = NewVerificationInput(*opts.Verification)
+	}
+	in.Ref = opts.Trigger.Ref
+	in.Branch = opts.Trigger.Branch()
+	in.Environment = opts.Trigger.Environment
+	return in
+}
@@ -0,0 +1,285 @@
+package rego
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	policyyaml "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/schema"
+)
+
+func writeTestBundle(t *testing.T, dir, name string, statement map[string]any, material sign.SignMaterial) {
+	t.Helper()
+	bundle, err := sign.CreateBundle(statement, material)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sign.WriteBundle(filepath.Join(dir, name), bundle); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func evalBundleStatement(faithfulness float64) map[string]any {
+	return map[string]any{
+		"statement_id":     "eval-1",
+		"attestation_type": "eval_attestation",
+		"predicate": map[string]any{
+			"metrics":             map[string]any{"faithfulness": faithfulness},
+			"regression_detected": false,
+		},
+	}
+}
+
+func sampleInput(t *testing.T, faithfulness float64, material sign.SignMaterial, report *verify.Report) Input {
+	t.Helper()
+	dir := t.TempDir()
+	writeTestBundle(t, dir, "eval.bundle.json", evalBundleStatement(faithfulness), material)
+	bundles, err := LoadBundles(dir)
+	if err != nil {
+		t.Fatalf("load bundles: %v", err)
+	}
+	return BuildInputWithOptions(policyyaml.Policy{}, nil, nil, InputOptions{Bundles: bundles, Verification: report})
+}
+
+var sigstoreMaterial = sign.SignMaterial{
+	KeyID:          "fulcio",
+	SigB64:         "c2ln",
+	Provider:       "sigstore",
+	CertificatePEM: "cert",
+	OIDCIssuer:     "https://token.actions.githubusercontent.com",
+	OIDCIdentity:   "https://github.com/your-org/app/.github/workflows/release.yml@refs/heads/main",
+}
+
+// --- LoadBundles ---
+
+func TestLoadBundles(t *testing.T) {
+	in := sampleInput(t, 0.95, sigstoreMaterial, nil)
+	if len(in.Bundles) != 1 {
+		t.Fatalf("expected 1 bundle, got %d", len(in.Bundles))
+	}
+	b := in.Bundles[0]
+	if b.StatementHash == "" || b.Canonicalization == "" {
+		t.Fatalf("expected bundle metadata, got %+v", b)
+	}
+	if b.Statement["statement_id"] != "eval-1" {
+		t.Fatalf("expected decoded statement, got %+v", b.Statement)
+	}
+	sig := b.Signatures[0]
+	if sig.Provider != "sigstore" || sig.OIDCIdentity != sigstoreMaterial.OIDCIdentity || !sig.HasCertificate {
+		t.Fatalf("unexpected signature input: %+v", sig)
+	}
+}
+
+func TestLoadBundlesSkipsNonBundleFile(t *testing.T) {
+	bundles, err := LoadBundles(filepath.Join(repoRoot(t), "policy", "examples", "mvp-gates.yaml"))
+	if err != nil || len(bundles) != 0 {
+		t.Fatalf("expected no bundles, got %v %v", bundles, err)
+	}
+}
+
+// --- input schema ---
+
+func TestBuildInputMatchesSchema(t *testing.T) {
+	report := verify.Report{
+		Passed: true,
+		Checks: []verify.CheckResult{{Bundle: "eval.bundle.json", Check: "signature", Passed: true, Message: "ok"}},
+		Chain: verify.ChainReport{Valid: true, Edges: []verify.ChainEdge{
+			{FromStatementID: "eval-1", FromType: "eval_attestation", ToType: "prompt_attestation", Satisfied: true},
+		}},
+	}
+	schemaPath := filepath.Join(repoRoot(t), "schemas", "rego", "input-v2.schema.json")
+	for name, in := range map[string]Input{
+		"thin":     BuildInput(policyyaml.Policy{}, []policyyaml.StatementView{{AttestationType: "eval_attestation"}}, []string{"a"}),
+		"complete": sampleInput(t, 0.95, sigstoreMaterial, &report),
+		"waived": BuildInput(policyyaml.Policy{
+			Gates:   []policyyaml.Gate{{ID: "G001"}},
+			Waivers: []policyyaml.Waiver{{Gate: "G001", Justification: "hotfix", Approver: "alice", Expires: "2099-01-01T00:00:00Z"}},
+		}, nil, nil),
+		"triggers": BuildInputWithOptions(policyyaml.Policy{
+			Gates: []policyyaml.Gate{{ID: "G005", TriggerRefs: []string{"refs/tags/v*"}, TriggerEnvironments: []string{"prod"}, Always: true}},
+		}, nil, nil, InputOptions{Trigger: policyyaml.TriggerContext{Ref: "refs/heads/main", Environment: "prod"}}),
+	} {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]any
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			t.Fatal(err)
+		}
+		errs, err := schema.Validate(schemaPath, doc)
+		if err != nil {
+			t.Fatalf("%s: validate: %v", name, err)
+		}
+		if len(errs) > 0 {
+			t.Fatalf("%s: input does not match schema: %v", name, errs)
+		}
+		if doc["input_version"] != InputVersion {
+			t.Fatalf("%s: input_version = %v", name, doc["input_version"])
+		}
+	}
+}
+
+// --- waivers ---
+
+func TestBuildInputWaivers(t *testing.T) {
+	policy := policyyaml.Policy{
+		Gates: []policyyaml.Gate{{ID: "G001", TriggerPaths: []string{"prompts/**"}, RequiredAttestations: []string{"eval_attestation"}}},
+		Waivers: []policyyaml.Waiver{
+			{Gate: "G001", Paths: []string{"prompts/hotfix.txt"}, Justification: "hotfix", Approver: "alice", Expires: "2099-01-01T00:00:00Z"},
+			{Gate: "G001", Justification: "old", Approver: "bob", Expires: "2020-01-01T00:00:00Z"},
+		},
+	}
+	regoPath := filepath.Join(repoRoot(t), "policy", "examples", "rego-gates.rego")
+
+	in := BuildInput(policy, nil, []string{"prompts/hotfix.txt"})
+	if len(in.Waivers) != 1 || in.Waivers[0].Approver != "alice" {
+		t.Fatalf("expected only the active waiver, got %+v", in.Waivers)
+	}
+	res, err := Evaluate(regoPath, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allow {
+		t.Fatalf("waived change should pass, got %v", res.Violations)
+	}
+
+	res, err = Evaluate(regoPath, BuildInput(policy, nil, []string{"prompts/hotfix.txt", "prompts/other.txt"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allow {
+		t.Fatal("change outside the waiver paths should still be gated")
+	}
+}
+
+// --- triggers ---
+
+func TestBuildInputTriggers(t *testing.T) {
+	policy := policyyaml.Policy{
+		Gates: []policyyaml.Gate{
+			{ID: "G005", TriggerRefs: []string{"refs/tags/v*"}, RequiredAttestations: []string{"slo_attestation"}, Message: "release blocked"},
+			{ID: "G010", TriggerBranches: []string{"release/**"}, RequiredAttestations: []string{"eval_attestation"}, Message: "release branch needs eval"},
+			{ID: "G020", TriggerEnvironments: []string{"prod"}, RequiredAttestations: []string{"route_attestation"}, Message: "prod needs route"},
+			{ID: "G030", Always: true, RequiredAttestations: []string{"prompt_attestation"}, Message: "prompt always required"},
+		},
+	}
+	regoPath := filepath.Join(repoRoot(t), "policy", "examples", "rego-gates.rego")
+
+	in := BuildInputWithOptions(policy, nil, nil, InputOptions{Trigger: policyyaml.TriggerContext{Ref: "refs/heads/release/1.2", Environment: "staging"}})
+	if in.Ref != "refs/heads/release/1.2" || in.Branch != "release/1.2" || in.Environment != "staging" {
+		t.Fatalf("unexpected trigger context: ref=%q branch=%q env=%q", in.Ref, in.Branch, in.Environment)
+	}
+	for _, tc := range []struct {
+		trigger policyyaml.TriggerContext
+		want    []string
+	}{
+		{policyyaml.TriggerContext{}, []string{"prompt always required"}},
+		{policyyaml.TriggerContext{Ref: "refs/tags/v1.2.0"}, []string{"prompt always required", "release blocked"}},
+		{policyyaml.TriggerContext{Ref: "refs/heads/release/1.2"}, []string{"prompt always required", "release branch needs eval"}},
+		{policyyaml.TriggerContext{Environment: "prod"}, []string{"prod needs route", "prompt always required"}},
+	} {
+		res, err := Evaluate(regoPath, BuildInputWithOptions(policy, nil, nil, InputOptions{Trigger: tc.trigger}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res.Violations, tc.want) {
+			t.Fatalf("%+v: got %v, want %v", tc.trigger, res.Violations, tc.want)
+		}
+	}
+}
+
+// --- example policies ---
+
+func TestExamplePredicatesPolicy(t *testing.T) {
+	regoPath := filepath.Join(repoRoot(t), "policy", "examples", "rego-predicates.rego")
+	result, err := Evaluate(regoPath, sampleInput(t, 0.95, sigstoreMaterial, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allow {
+		t.Fatalf("expected allow, got %v", result.Violations)
+	}
+	result, err = Evaluate(regoPath, sampleInput(t, 0.8, sigstoreMaterial, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allow || len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "faithfulness 0.8") {
+		t.Fatalf("expected faithfulness violation, got %+v", result)
+	}
+}
+
+func TestExampleSignersPolicy(t *testing.T) {
+	regoPath := filepath.Join(repoRoot(t), "policy", "examples", "rego-signers.rego")
+	result, err := Evaluate(regoPath, sampleInput(t, 0.95, sigstoreMaterial, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allow {
+		t.Fatalf("expected allow, got %v", result.Violations)
+	}
+
+	untrusted := sigstoreMaterial
+	untrusted.OIDCIdentity = "https://github.com/someone/fork/.github/workflows/release.yml@refs/heads/main"
+	result, err = Evaluate(regoPath, sampleInput(t, 0.95, untrusted, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allow || !strings.Contains(strings.Join(result.Violations, ";"), "untrusted identity") {
+		t.Fatalf("expected identity violation, got %+v", result)
+	}
+
+	pem := sign.SignMaterial{KeyID: "dev-key", SigB64: "c2ln", Provider: "pem"}
+	result, err = Evaluate(regoPath, sampleInput(t, 0.95, pem, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allow || !strings.Contains(strings.Join(result.Violations, ";"), "pem key dev-key") {
+		t.Fatalf("expected key provider violation, got %+v", result)
+	}
+}
+
+func TestExampleVerificationPolicy(t *testing.T) {
+	regoPath := filepath.Join(repoRoot(t), "policy", "examples", "rego-verification.rego")
+	result, err := Evaluate(regoPath, sampleInput(t, 0.95, sigstoreMaterial, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allow || result.Violations[0] != "verification results missing from input" {
+		t.Fatalf("expected missing verification violation, got %+v", result)
+	}
+
+	report := verify.Report{
+		Passed: false,
+		Checks: []verify.CheckResult{{Bundle: "route.bundle.json", Check: "signature", Passed: false, Message: "bad sig"}},
+		Chain: verify.ChainReport{Edges: []verify.ChainEdge{
+			{FromStatementID: "route-1", FromType: "route_attestation", ToType: "eval_attestation", Satisfied: false, Detail: "missing_required_attestation_type"},
+		}},
+	}
+	result, err = Evaluate(regoPath, sampleInput(t, 0.95, sigstoreMaterial, &report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := strings.Join(result.Violations, ";")
+	for _, want := range []string{"verify check signature failed", "chain edge route_attestation -> eval_attestation unsatisfied", "route route-1 is not pinned"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected %q in %v", want, result.Violations)
+		}
+	}
+
+	report = verify.Report{Passed: true, Chain: verify.ChainReport{Valid: true, Edges: []verify.ChainEdge{
+		{FromStatementID: "route-1", FromType: "route_attestation", ToType: "eval_attestation", Satisfied: true, PinnedDigest: "sha256:abc"},
+	}}}
+	result, err = Evaluate(regoPath, sampleInput(t, 0.95, sigstoreMaterial, &report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allow {
+		t.Fatalf("expected allow, got %v", result.Violations)
+	}
+}
@@ -0,0 +1,238 @@
+// Package signed signs policy files into llmsa bundles and verifies them
+// against a policy-signer trust root before the gate, verify and webhook
+// commands use them.
+package signed
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
+)
+
+// StatementType identifies a signed policy statement.
+const StatementType = "https://llmsa.dev/policy/v1"
+
+// Statement is the signed payload: the policy file name and the digest of
+// its exact bytes.
+type Statement struct {
+	Type         string `json:"_type"`
+	PolicyName   string `json:"policy_name"`
+	PolicyDigest string `json:"policy_digest"`
+}
+
+// TrustRoot lists the signers allowed to sign policies. A PEM-signed bundle
+// must carry one of PublicKeys; a Sigstore bundle must carry a certificate
+// whose identity matches IdentityRegex (and OIDCIssuer when set).
+type TrustRoot struct {
+	PublicKeys    []ed25519.PublicKey
+	OIDCIssuer    string
+	IdentityRegex string
+}
+
+// Options controls policy signature checks. When Require is false unsigned
+// policies are accepted.
+type Options struct {
+	Require bool
+	Trust   TrustRoot
+}
+
+// Signer produces signature material over a canonical payload.
+type Signer interface {
+	Sign(canonicalPayload []byte) (sign.SignMaterial, error)
+}
+
+// BundlePath is where the signature bundle of policyPath is kept.
+func BundlePath(policyPath string) string {
+	return policyPath + ".bundle.json"
+}
+
+// LoadTrustRoot reads PEM public keys and combines them with an optional
+// Sigstore identity.
+func LoadTrustRoot(keyPaths []string, oidcIssuer, identityRegex string) (TrustRoot, error) {
+	trust := TrustRoot{OIDCIssuer: oidcIssuer, IdentityRegex: identityRegex}
+	if identityRegex != "" {
+		if _, err := regexp.Compile(identityRegex); err != nil {
+			return TrustRoot{}, fmt.Errorf("policy signer identity regex: %w", err)
+		}
+	}
+	for _, p := range keyPaths {
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			return TrustRoot{}, fmt.Errorf("read policy trust key: %w", err)
+		}
+		pub, err := parsePublicKey(raw)
+		if err != nil {
+			return TrustRoot{}, fmt.Errorf("policy trust key %s: %w", p, err)
+		}
+		trust.PublicKeys = append(trust.PublicKeys, pub)
+	}
+	return trust, nil
+}
+
+// Empty reports whether the trust root accepts no signer at all.
+func (t TrustRoot) Empty() bool {
+	return len(t.PublicKeys) == 0 && t.IdentityRegex == ""
+}
+
+// NewStatement builds the statement for the policy file at path.
+func NewStatement(path string) (Statement, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Statement{}, fmt.Errorf("read policy: %w", err)
+	}
+	return statementFor(path, raw), nil
+}
+
+func statementFor(path string, raw []byte) Statement {
+	return Statement{
+		Type:         StatementType,
+		PolicyName:   filepath.Base(path),
+		PolicyDigest: hash.DigestBytes(raw),
+	}
+}
+
+// Sign signs the policy file at path into a bundle.
+func Sign(path string, signer Signer) (sign.Bundle, error) {
+	st, err := NewStatement(path)
+	if err != nil {
+		return sign.Bundle{}, err
+	}
+	payload := map[string]any{
+		"_type":         st.Type,
+		"policy_name":   st.PolicyName,
+		"policy_digest": st.PolicyDigest,
+	}
+	canonical, err := hash.Canonicalize(hash.DefaultCanonicalization, payload)
+	if err != nil {
+		return sign.Bundle{}, err
+	}
+	material, err := signer.Sign(canonical)
+	if err != nil {
+		return sign.Bundle{}, err
+	}
+	return sign.CreateBundle(payload, material)
+}
+
+// Verify checks that bundlePath is a valid signature by a trusted signer
+// over the current contents of the policy file at path.
+func Verify(path, bundlePath string, trust TrustRoot) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read policy: %w", err)
+	}
+	return verifyBytes(path, raw, bundlePath, trust)
+}
+
+// verifyBytes is Verify over raw, the policy contents already read from path.
+func verifyBytes(path string, raw []byte, bundlePath string, trust TrustRoot) error {
+	if trust.Empty() {
+		return fmt.Errorf("policy trust root is empty: set trusted keys or a signer identity")
+	}
+	bundle, err := sign.ReadBundle(bundlePath)
+	if err != nil {
+		return fmt.Errorf("read policy bundle: %w", err)
+	}
+	if err := verify.VerifySignature(bundle, verify.SignerPolicy{OIDCIssuer: trust.OIDCIssuer, IdentityRegex: trust.IdentityRegex}); err != nil {
+		return fmt.Errorf("policy signature: %w", err)
+	}
+	if !trust.trusts(bundle.Envelope.Signatures[0]) {
+		return fmt.Errorf("policy signer %s is not in the policy trust root", bundle.Envelope.Signatures[0].KeyID)
+	}
+
+	var signed Statement
+	if err := sign.DecodePayload(bundle, &signed); err != nil {
+		return err
+	}
+	if signed.Type != StatementType {
+		return fmt.Errorf("bundle %s is not a signed policy", bundlePath)
+	}
+	current := statementFor(path, raw)
+	// The name is signed too, so a policy signed for one role (dev.yaml)
+	// cannot be copied with its bundle over another (prod.yaml).
+	if signed.PolicyName != current.PolicyName {
+		return fmt.Errorf("policy %s was signed as %q", path, signed.PolicyName)
+	}
+	if signed.PolicyDigest != current.PolicyDigest {
+		return fmt.Errorf("policy %s does not match its signed digest %s", path, signed.PolicyDigest)
+	}
+	return nil
+}
+
+// Check verifies the policy at path against BundlePath(path) when
+// opts.Require is set.
+func Check(path string, opts Options) error {
+	if !opts.Require {
+		return nil
+	}
+	_, err := Load(path, opts)
+	return err
+}
+
+// Load reads the policy at path once and, when opts.Require is set, checks
+// those bytes against BundlePath(path). Callers parse the returned bytes, so
+// the policy they apply is the one whose signature was checked.
+func Load(path string, opts Options) ([]byte, error) {
+	if opts.Require && path == "" {
+		return nil, fmt.Errorf("a policy path is required when signed policies are required")
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	if !opts.Require {
+		return raw, nil
+	}
+	bundlePath := BundlePath(path)
+	if _, err := os.Stat(bundlePath); err != nil {
+		return nil, fmt.Errorf("policy %s is not signed: %s not found", path, bundlePath)
+	}
+	if err := verifyBytes(path, raw, bundlePath, opts.Trust); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// trusts reports whether sig came from a trusted signer. Sigstore bundles
+// count only with a certificate, since VerifySignature has then checked the
+// identity through cosign; self-asserted OIDC claims are not trusted.
+func (t TrustRoot) trusts(sig sign.Signature) bool {
+	if sig.Provider == "sigstore" && strings.TrimSpace(sig.CertificatePEM) != "" && t.IdentityRegex != "" {
+		return true
+	}
+	pub, err := parsePublicKey([]byte(sig.PublicKeyPEM))
+	if err != nil {
+		return false
+	}
+	for _, k := range t.PublicKeys {
+		if bytes.Equal(k, pub) {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePublicKey(raw []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(raw))
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key pem")
+	}
+	pubAny, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	pub, ok := pubAny.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type: need ed25519")
+	}
+	return pub, nil
+}
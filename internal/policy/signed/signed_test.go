@@ -0,0 +1,215 @@
+package signed
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+)
+
+// newKey writes an ed25519 private key and its public key PEM to dir.
+func newKey(t *testing.T, dir, name string) (*sign.PEMSigner, string) {
+	t.Helper()
+	keyPath := filepath.Join(dir, name+".pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sign.NewPEMSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(signer.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPath := filepath.Join(dir, name+".pub")
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return signer, pubPath
+}
+
+func signedPolicy(t *testing.T, signer Signer) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gates.yaml")
+	if err := os.WriteFile(path, []byte("version: 1\ngates: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := Sign(path, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sign.WriteBundle(BundlePath(path), bundle); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// --- Verify ---
+
+func TestVerifyTrustedSigner(t *testing.T) {
+	signer, pubPath := newKey(t, t.TempDir(), "policy")
+	path := signedPolicy(t, signer)
+	trust, err := LoadTrustRoot([]string{pubPath}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(path, BundlePath(path), trust); err != nil {
+		t.Fatalf("expected trusted policy to verify: %v", err)
+	}
+}
+
+func TestVerifyRejectsUntrustedSigner(t *testing.T) {
+	dir := t.TempDir()
+	signer, _ := newKey(t, dir, "attacker")
+	_, trustedPub := newKey(t, dir, "policy")
+	path := signedPolicy(t, signer)
+	trust, err := LoadTrustRoot([]string{trustedPub}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Verify(path, BundlePath(path), trust)
+	if err == nil || !strings.Contains(err.Error(), "not in the policy trust root") {
+		t.Fatalf("expected untrusted signer error, got %v", err)
+	}
+}
+
+func TestVerifyRejectsModifiedPolicy(t *testing.T) {
+	signer, pubPath := newKey(t, t.TempDir(), "policy")
+	path := signedPolicy(t, signer)
+	if err := os.WriteFile(path, []byte("version: 1\ngates: []\nwaivers: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	trust, _ := LoadTrustRoot([]string{pubPath}, "", "")
+	err := Verify(path, BundlePath(path), trust)
+	if err == nil || !strings.Contains(err.Error(), "does not match its signed digest") {
+		t.Fatalf("expected digest mismatch, got %v", err)
+	}
+}
+
+func TestVerifyRejectsRenamedPolicy(t *testing.T) {
+	signer, pubPath := newKey(t, t.TempDir(), "policy")
+	path := signedPolicy(t, signer)
+	renamed := filepath.Join(filepath.Dir(path), "prod.yaml")
+	for src, dst := range map[string]string{path: renamed, BundlePath(path): BundlePath(renamed)} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	trust, _ := LoadTrustRoot([]string{pubPath}, "", "")
+	err := Verify(renamed, BundlePath(renamed), trust)
+	if err == nil || !strings.Contains(err.Error(), `was signed as "gates.yaml"`) {
+		t.Fatalf("expected policy name mismatch, got %v", err)
+	}
+	if _, err := Load(renamed, Options{Require: true, Trust: trust}); err == nil {
+		t.Fatal("expected Load to reject the renamed policy")
+	}
+}
+
+func TestVerifyRejectsSelfAssertedSigstoreIdentity(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	// Without a certificate the OIDC claims are only asserted by the signer.
+	path := signedPolicy(t, &sign.SigstoreSigner{PEMKeyPath: keyPath, Issuer: "https://issuer", Identity: "ci@example.com"})
+	trust, err := LoadTrustRoot(nil, "https://issuer", "^ci@example\\.com$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(path, BundlePath(path), trust); err == nil {
+		t.Fatal("expected certificate-less sigstore bundle to be untrusted")
+	}
+}
+
+func TestVerifyEmptyTrustRoot(t *testing.T) {
+	signer, _ := newKey(t, t.TempDir(), "policy")
+	path := signedPolicy(t, signer)
+	if err := Verify(path, BundlePath(path), TrustRoot{}); err == nil || !strings.Contains(err.Error(), "trust root is empty") {
+		t.Fatalf("expected empty trust root error, got %v", err)
+	}
+}
+
+// --- Check ---
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	unsigned := filepath.Join(dir, "unsigned.yaml")
+	if err := os.WriteFile(unsigned, []byte("version: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(unsigned, Options{}); err != nil {
+		t.Fatalf("unsigned policy must pass when not required: %v", err)
+	}
+	err := Check(unsigned, Options{Require: true})
+	if err == nil || !strings.Contains(err.Error(), "is not signed") {
+		t.Fatalf("expected unsigned policy error, got %v", err)
+	}
+
+	signer, pubPath := newKey(t, dir, "policy")
+	path := signedPolicy(t, signer)
+	trust, _ := LoadTrustRoot([]string{pubPath}, "", "")
+	if err := Check(path, Options{Require: true, Trust: trust}); err != nil {
+		t.Fatalf("signed policy: %v", err)
+	}
+}
+
+// --- Load ---
+
+func TestLoadReturnsVerifiedBytes(t *testing.T) {
+	signer, pubPath := newKey(t, t.TempDir(), "policy")
+	path := signedPolicy(t, signer)
+	trust, _ := LoadTrustRoot([]string{pubPath}, "", "")
+	opts := Options{Require: true, Trust: trust}
+
+	raw, err := Load(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "version: 1\ngates: []\n" {
+		t.Fatalf("unexpected policy bytes %q", raw)
+	}
+
+	if err := os.WriteFile(path, []byte("version: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, opts); err == nil || !strings.Contains(err.Error(), "does not match its signed digest") {
+		t.Fatalf("expected digest mismatch, got %v", err)
+	}
+	if raw, err := Load(path, Options{}); err != nil || string(raw) != "version: 1\n" {
+		t.Fatalf("unsigned load: %q %v", raw, err)
+	}
+	if _, err := Load("", Options{Require: true, Trust: trust}); err == nil || !strings.Contains(err.Error(), "policy path is required") {
+		t.Fatalf("expected missing path error, got %v", err)
+	}
+}
+
+func TestLoadTrustRootErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.pub")
+	if err := os.WriteFile(bad, []byte("not pem"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTrustRoot([]string{bad}, "", ""); err == nil {
+		t.Fatal("expected invalid key error")
+	}
+	if _, err := LoadTrustRoot([]string{filepath.Join(dir, "missing.pub")}, "", ""); err == nil {
+		t.Fatal("expected missing key error")
+	}
+	if _, err := LoadTrustRoot(nil, "", "("); err == nil {
+		t.Fatal("expected invalid regex error")
+	}
+	if !(TrustRoot{}).Empty() {
+		t.Fatal("zero trust root should be empty")
+	}
+}
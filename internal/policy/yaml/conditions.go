@@ -0,0 +1,266 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Condition operators.
+const (
+	OpEq        = "eq"
+	OpNe        = "ne"
+	OpGt        = "gt"
+	OpGte       = "gte"
+	OpLt        = "lt"
+	OpLte       = "lte"
+	OpIn        = "in"
+	OpNotIn     = "not_in"
+	OpMatches   = "matches"
+	OpExists    = "exists"
+	OpNotExists = "not_exists"
+)
+
+// Condition is a typed check over a decoded statement field, applied to every
+// statement of the given attestation type. Field is a dot path rooted at
+// "predicate" or "subjects" (e.g. predicate.metrics.faithfulness,
+// subjects.name); a path segment that meets a list applies to each element,
+// or to one element when the segment is an index.
+type Condition struct {
+	Attestation string `yaml:"attestation" json:"attestation"`
+	Field       string `yaml:"field" json:"field"`
+	Op          string `yaml:"op" json:"op"`
+	Value       any    `yaml:"value" json:"value,omitempty"`
+	Message     string `yaml:"message" json:"message,omitempty"`
+}
+
+// Validate checks that the condition has a known operator, a field rooted at
+// predicate or subjects, and a value of the shape the operator needs.
+func (c Condition) Validate() error {
+	if c.Attestation == "" {
+		return fmt.Errorf("condition on %s: attestation is required", c.Field)
+	}
+	root, _, _ := strings.Cut(c.Field, ".")
+	if root != "predicate" && root != "subjects" {
+		return fmt.Errorf("condition field %q must start with predicate or subjects", c.Field)
+	}
+	switch c.Op {
+	case OpEq, OpNe:
+		if c.Value == nil {
+			return fmt.Errorf("condition %s %s requires a value", c.Field, c.Op)
+		}
+	case OpGt, OpGte, OpLt, OpLte:
+		if _, ok := toFloat(c.Value); !ok {
+			return fmt.Errorf("condition %s %s requires a numeric value", c.Field, c.Op)
+		}
+	case OpIn, OpNotIn:
+		if _, ok := c.Value.([]any); !ok {
+			return fmt.Errorf("condition %s %s requires a list value", c.Field, c.Op)
+		}
+	case OpMatches:
+		pattern, ok := c.Value.(string)
+		if !ok {
+			return fmt.Errorf("condition %s matches requires a string pattern", c.Field)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("condition %s matches: %w", c.Field, err)
+		}
+	case OpExists, OpNotExists:
+	default:
+		return fmt.Errorf("condition %s: unsupported op %q", c.Field, c.Op)
+	}
+	return nil
+}
+
+// ConditionFailure is one failed condition on one statement.
+type ConditionFailure struct {
+	Attestation string `json:"attestation"`
+	StatementID string `json:"statement_id"`
+	Field       string `json:"field"`
+	Op          string `json:"op"`
+	Message     string `json:"message"`
+	Waived      bool   `json:"waived,omitempty"`
+}
+
+// evaluateConditions returns one failure per condition and statement of its
+// attestation type that does not satisfy it. Statements absent from the set
+// are not checked; required_attestations covers presence.
+func evaluateConditions(gate Gate, statements []StatementView) []ConditionFailure {
+	failures := make([]ConditionFailure, 0)
+	for _, cond := range gate.Conditions {
+		for _, st := range statements {
+			if st.AttestationType != cond.Attestation {
+				continue
+			}
+			if reason := checkCondition(cond, st); reason != "" {
+				msg := cond.Message
+				if msg == "" {
+					msg = fmt.Sprintf("%s condition failed for %s: %s", gate.ID, st.StatementID, reason)
+				}
+				failures = append(failures, ConditionFailure{
+					Attestation: cond.Attestation,
+					StatementID: st.StatementID,
+					Field:       cond.Field,
+					Op:          cond.Op,
+					Message:     msg,
+				})
+			}
+		}
+	}
+	return failures
+}
+
+// checkCondition returns a description of why st fails cond, or "".
+func checkCondition(cond Condition, st StatementView) string {
+	values := resolveField(st, cond.Field)
+	switch cond.Op {
+	case OpExists:
+		if len(values) == 0 {
+			return fmt.Sprintf("%s is missing", cond.Field)
+		}
+		return ""
+	case OpNotExists:
+		if len(values) > 0 {
+			return fmt.Sprintf("%s is present", cond.Field)
+		}
+		return ""
+	}
+	if len(values) == 0 {
+		return fmt.Sprintf("%s is missing", cond.Field)
+	}
+	for _, v := range values {
+		if !compare(cond.Op, v, cond.Value) {
+			return fmt.Sprintf("%s = %s, want %s %s", cond.Field, formatValue(v), cond.Op, formatValue(cond.Value))
+		}
+	}
+	return ""
+}
+
+func resolveField(st StatementView, field string) []any {
+	parts := strings.Split(field, ".")
+	var root any
+	switch parts[0] {
+	case "predicate":
+		if st.Predicate == nil {
+			return nil
+		}
+		root = st.Predicate
+	case "subjects":
+		items := make([]any, 0, len(st.Subjects))
+		for _, s := range st.Subjects {
+			items = append(items, s)
+		}
+		root = items
+	default:
+		return nil
+	}
+	return walk(root, parts[1:])
+}
+
+func walk(v any, path []string) []any {
+	if len(path) == 0 {
+		if v == nil {
+			return nil
+		}
+		return []any{v}
+	}
+	switch node := v.(type) {
+	case map[string]any:
+		child, ok := node[path[0]]
+		if !ok {
+			return nil
+		}
+		return walk(child, path[1:])
+	case []any:
+		if idx, err := strconv.Atoi(path[0]); err == nil {
+			if idx < 0 || idx >= len(node) {
+				return nil
+			}
+			return walk(node[idx], path[1:])
+		}
+		out := make([]any, 0, len(node))
+		for _, item := range node {
+			out = append(out, walk(item, path)...)
+		}
+		return out
+	}
+	return nil
+}
+
+func compare(op string, actual, want any) bool {
+	switch op {
+	case OpEq:
+		return equal(actual, want)
+	case OpNe:
+		return !equal(actual, want)
+	case OpGt, OpGte, OpLt, OpLte:
+		a, ok := toFloat(actual)
+		if !ok {
+			return false
+		}
+		w, _ := toFloat(want)
+		switch op {
+		case OpGt:
+			return a > w
+		case OpGte:
+			return a >= w
+		case OpLt:
+			return a < w
+		default:
+			return a <= w
+		}
+	case OpIn, OpNotIn:
+		list, _ := want.([]any)
+		found := false
+		for _, item := range list {
+			if equal(actual, item) {
+				found = true
+				break
+			}
+		}
+		return found == (op == OpIn)
+	case OpMatches:
+		s, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		pattern, _ := want.(string)
+		ok, _ = regexp.MatchString(pattern, s)
+		return ok
+	}
+	return false
+}
+
+func equal(a, b any) bool {
+	af, aNum := toFloat(a)
+	bf, bNum := toFloat(b)
+	if aNum || bNum {
+		return aNum && bNum && af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func formatValue(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,204 @@
+package yaml
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func conditionStatements() []StatementView {
+	return []StatementView{
+		{
+			AttestationType: "eval_attestation",
+			StatementID:     "eval-1",
+			Predicate: map[string]any{
+				"metrics": map[string]any{"faithfulness": 0.93, "toxicity": 0.01},
+			},
+		},
+		{
+			AttestationType: "route_attestation",
+			StatementID:     "route-1",
+			Predicate: map[string]any{
+				"routing_strategy": "latency_aware",
+				"provider_set": []any{
+					map[string]any{"provider": "openai", "model": "gpt-4o"},
+					map[string]any{"provider": "anthropic", "model": "claude-3-5-sonnet"},
+				},
+			},
+		},
+		{
+			AttestationType: "corpus_attestation",
+			StatementID:     "corpus-1",
+			Predicate:       map[string]any{"embedding_model": "text-embedding-3-large"},
+			Subjects: []map[string]any{
+				{"name": "docs/a.md", "uri": "file://docs/a.md"},
+			},
+		},
+	}
+}
+
+func evaluateCondition(t *testing.T, cond Condition) []string {
+	t.Helper()
+	if err := cond.Validate(); err != nil {
+		t.Fatalf("validate %+v: %v", cond, err)
+	}
+	policy := Policy{Gates: []Gate{{ID: "G100", TriggerPaths: []string{"**"}, Conditions: []Condition{cond}}}}
+	violations, err := EvaluateWithChanged(policy, conditionStatements(), []string{"app.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return violations
+}
+
+// --- operators ---
+
+func TestConditionOperators(t *testing.T) {
+	cases := []struct {
+		name string
+		cond Condition
+		pass bool
+	}{
+		{"gte pass", Condition{Attestation: "eval_attestation", Field: "predicate.metrics.faithfulness", Op: OpGte, Value: 0.9}, true},
+		{"gte fail", Condition{Attestation: "eval_attestation", Field: "predicate.metrics.faithfulness", Op: OpGte, Value: 0.95}, false},
+		{"lt int value", Condition{Attestation: "eval_attestation", Field: "predicate.metrics.toxicity", Op: OpLt, Value: 1}, true},
+		{"eq string", Condition{Attestation: "route_attestation", Field: "predicate.routing_strategy", Op: OpEq, Value: "latency_aware"}, true},
+		{"ne string", Condition{Attestation: "route_attestation", Field: "predicate.routing_strategy", Op: OpNe, Value: "latency_aware"}, false},
+		{"in allowlist", Condition{Attestation: "corpus_attestation", Field: "predicate.embedding_model", Op: OpIn, Value: []any{"text-embedding-3-large", "bge-m3"}}, true},
+		{"not in allowlist", Condition{Attestation: "corpus_attestation", Field: "predicate.embedding_model", Op: OpIn, Value: []any{"bge-m3"}}, false},
+		{"not_in denylist", Condition{Attestation: "route_attestation", Field: "predicate.provider_set.provider", Op: OpNotIn, Value: []any{"unvetted"}}, true},
+		{"list fan-out fails on one element", Condition{Attestation: "route_attestation", Field: "predicate.provider_set.provider", Op: OpEq, Value: "openai"}, false},
+		{"list index", Condition{Attestation: "route_attestation", Field: "predicate.provider_set.0.provider", Op: OpEq, Value: "openai"}, true},
+		{"matches", Condition{Attestation: "route_attestation", Field: "predicate.provider_set.model", Op: OpMatches, Value: "^(gpt|claude)-"}, true},
+		{"matches non-string", Condition{Attestation: "eval_attestation", Field: "predicate.metrics.faithfulness", Op: OpMatches, Value: "0.9"}, false},
+		{"exists", Condition{Attestation: "eval_attestation", Field: "predicate.metrics.faithfulness", Op: OpExists}, true},
+		{"exists missing", Condition{Attestation: "eval_attestation", Field: "predicate.metrics.recall", Op: OpExists}, false},
+		{"not_exists", Condition{Attestation: "eval_attestation", Field: "predicate.metrics.recall", Op: OpNotExists}, true},
+		{"subjects path", Condition{Attestation: "corpus_attestation", Field: "subjects.uri", Op: OpMatches, Value: "^file://"}, true},
+		{"missing field fails comparison", Condition{Attestation: "eval_attestation", Field: "predicate.metrics.recall", Op: OpGte, Value: 0.5}, false},
+		{"absent type not checked", Condition{Attestation: "slo_attestation", Field: "predicate.ttft_ms_p95", Op: OpLt, Value: 100}, true},
+	}
+	for _, tc := range cases {
+		violations := evaluateCondition(t, tc.cond)
+		if tc.pass && len(violations) != 0 {
+			t.Errorf("%s: expected pass, got %v", tc.name, violations)
+		}
+		if !tc.pass && len(violations) != 1 {
+			t.Errorf("%s: expected one violation, got %v", tc.name, violations)
+		}
+	}
+}
+
+func TestConditionViolationMessage(t *testing.T) {
+	violations := evaluateCondition(t, Condition{Attestation: "eval_attestation", Field: "predicate.metrics.faithfulness", Op: OpGte, Value: 0.95})
+	want := "G100 condition failed for eval-1: predicate.metrics.faithfulness = 0.93, want gte 0.95"
+	if len(violations) != 1 || violations[0] != want {
+		t.Fatalf("got %v, want %q", violations, want)
+	}
+	violations = evaluateCondition(t, Condition{Attestation: "route_attestation", Field: "predicate.routing_strategy", Op: OpEq, Value: "cost", Message: "routing must be cost based"})
+	if len(violations) != 1 || violations[0] != "routing must be cost based" {
+		t.Fatalf("expected custom message, got %v", violations)
+	}
+}
+
+func TestConditionNotEvaluatedWhenGateNotTriggered(t *testing.T) {
+	policy := Policy{Gates: []Gate{{
+		ID:           "G100",
+		TriggerPaths: []string{"eval/**"},
+		Conditions:   []Condition{{Attestation: "eval_attestation", Field: "predicate.metrics.faithfulness", Op: OpGte, Value: 0.99}},
+	}}}
+	violations, err := EvaluateWithChanged(policy, conditionStatements(), []string{"docs/readme.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 0 {
+		t.Fatalf("expected no violations, got %v", violations)
+	}
+}
+
+// --- validation ---
+
+func TestConditionValidate(t *testing.T) {
+	cases := map[string]Condition{
+		"attestation is required":   {Field: "predicate.x", Op: OpExists},
+		"must start with predicate": {Attestation: "eval_attestation", Field: "metrics.x", Op: OpExists},
+		"unsupported op":            {Attestation: "eval_attestation", Field: "predicate.x", Op: ">="},
+		"requires a numeric value":  {Attestation: "eval_attestation", Field: "predicate.x", Op: OpGt, Value: "high"},
+		"requires a list value":     {Attestation: "eval_attestation", Field: "predicate.x", Op: OpIn, Value: "a"},
+		"error parsing regexp":      {Attestation: "eval_attestation", Field: "predicate.x", Op: OpMatches, Value: "("},
+		"requires a value":          {Attestation: "eval_attestation", Field: "predicate.x", Op: OpEq},
+		"requires a string pattern": {Attestation: "eval_attestation", Field: "predicate.x", Op: OpMatches, Value: 1},
+	}
+	for want, cond := range cases {
+		if err := cond.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%+v: expected %q, got %v", cond, want, err)
+		}
+	}
+}
+
+func TestLoadPolicyConditions(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	content := `version: "1"
+gates:
+  - id: G010
+    trigger_paths: ["eval/**"]
+    required_attestations: [eval_attestation]
+    conditions:
+      - attestation: eval_attestation
+        field: predicate.metrics.faithfulness
+        op: gte
+        value: 0.9
+      - attestation: corpus_attestation
+        field: predicate.embedding_model
+        op: in
+        value: [text-embedding-3-large, bge-m3]
+`
+	if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPolicy(policyPath)
+	if err != nil {
+		t.Fatalf("LoadPolicy: %v", err)
+	}
+	if len(p.Gates[0].Conditions) != 2 || p.Gates[0].Conditions[1].Op != OpIn {
+		t.Fatalf("unexpected conditions: %+v", p.Gates[0].Conditions)
+	}
+	violations, err := EvaluateWithChanged(p, conditionStatements(), []string{"eval/run.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 0 {
+		t.Fatalf("expected no violations, got %v", violations)
+	}
+
+	bad := strings.Replace(content, "op: gte", "op: bigger", 1)
+	if err := os.WriteFile(policyPath, []byte(bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(policyPath); err == nil || !strings.Contains(err.Error(), "gate G010") {
+		t.Fatalf("expected gate validation error, got %v", err)
+	}
+}
+
+func TestLoadStatementsCarriesPredicateAndSubjects(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"attestation_type":"eval_attestation","statement_id":"e1","subject":[{"name":"testset.jsonl"}],"predicate":{"metrics":{"faithfulness":0.9}}}`
+	if err := os.WriteFile(filepath.Join(dir, "statement.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	statements, err := LoadStatements(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(statements))
+	}
+	st := statements[0]
+	if len(st.Subjects) != 1 || st.Subjects[0]["name"] != "testset.jsonl" {
+		t.Fatalf("unexpected subjects: %+v", st.Subjects)
+	}
+	if got := resolveField(st, "predicate.metrics.faithfulness"); len(got) != 1 {
+		t.Fatalf("expected decoded predicate, got %+v", st.Predicate)
+	}
+}
@@ -3,11 +3,14 @@ package yaml
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/changes"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/semantic"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
 	goyaml "gopkg.in/yaml.v3"
 )
@@ -18,6 +21,12 @@ type Policy struct {
 	IdentityRegex      string   `yaml:"identity_regex" json:"identity_regex"`
 	PlaintextAllowlist []string `yaml:"plaintext_allowlist" json:"plaintext_allowlist"`
 	Gates              []Gate   `yaml:"gates" json:"gates"`
+	// Chain, when set, replaces the built-in provenance chain rules.
+	Chain *chain.Config `yaml:"chain" json:"chain,omitempty"`
+	// Semantic rejects eval regressions and out-of-limit SLO values at verify time.
+	Semantic semantic.Policy `yaml:"semantic" json:"semantic"`
+	// Waivers temporarily exempt gates; expired waivers are ignored.
+	Waivers []Waiver `yaml:"waivers" json:"waivers,omitempty"`
 }
 
 type Gate struct {
@@ -25,13 +34,25 @@ type Gate struct {
 	TriggerPaths         []string `yaml:"trigger_paths" json:"trigger_paths"`
 	RequiredAttestations []string `yaml:"required_attestations" json:"required_attestations"`
 	Message              string   `yaml:"message" json:"message"`
+	// TriggerRefs, TriggerBranches and TriggerEnvironments fire the gate from
+	// the TriggerContext; Always fires it on every run. Any matching trigger
+	// kind is enough.
+	TriggerRefs         []string `yaml:"trigger_refs" json:"trigger_refs,omitempty"`
+	TriggerBranches     []string `yaml:"trigger_branches" json:"trigger_branches,omitempty"`
+	TriggerEnvironments []string `yaml:"trigger_environments" json:"trigger_environments,omitempty"`
+	Always              bool     `yaml:"always" json:"always,omitempty"`
+	// Conditions check predicate and subject fields of the statements
+	// present when the gate triggers.
+	Conditions []Condition `yaml:"conditions" json:"conditions,omitempty"`
 }
 
 type StatementView struct {
-	AttestationType string   `json:"attestation_type"`
-	StatementID     string   `json:"statement_id"`
-	PrivacyMode     string   `json:"privacy_mode"`
-	DependsOn       []string `json:"depends_on"`
+	AttestationType string           `json:"attestation_type"`
+	StatementID     string           `json:"statement_id"`
+	PrivacyMode     string           `json:"privacy_mode"`
+	DependsOn       []string         `json:"depends_on"`
+	Predicate       map[string]any   `json:"predicate,omitempty"`
+	Subjects        []map[string]any `json:"subjects,omitempty"`
 }
 
 func LoadPolicy(path string) (Policy, error) {
@@ -39,10 +60,38 @@ func LoadPolicy(path string) (Policy, error) {
 	if err != nil {
 		return Policy{}, err
 	}
+	return LoadPolicyBytes(raw)
+}
+
+// LoadPolicyBytes parses and validates a policy already read into memory,
+// e.g. the bytes whose signature was just checked.
+func LoadPolicyBytes(raw []byte) (Policy, error) {
 	var p Policy
 	if err := goyaml.Unmarshal(raw, &p); err != nil {
 		return Policy{}, err
 	}
+	if p.Chain != nil {
+		if err := p.Chain.Validate(); err != nil {
+			return Policy{}, fmt.Errorf("policy chain: %w", err)
+		}
+	}
+	if err := p.Semantic.Validate(); err != nil {
+		return Policy{}, fmt.Errorf("policy semantic: %w", err)
+	}
+	gateIDs := make(map[string]struct{}, len(p.Gates))
+	for _, gate := range p.Gates {
+		gateIDs[gate.ID] = struct{}{}
+		for _, cond := range gate.Conditions {
+			if err := cond.Validate(); err != nil {
+				return Policy{}, fmt.Errorf("gate %s: %w", gate.ID, err)
+			}
+		}
+	}
+	for _, w := range p.Waivers {
+		if err := w.Validate(gateIDs); err != nil {
+			return Policy{}, fmt.Errorf("policy waivers: %w", err)
+		}
+	}
 	return p, nil
 }
 
@@ -100,28 +149,11 @@ func LoadStatements(source string) ([]StatementView, error) {
 	return out, nil
 }
 
+// ChangedFiles lists files changed between gitRef and HEAD from their merge
+// base. Git failures are returned; use changes.Resolve with AllowNoChanges to
+// degrade to an empty list.
 func ChangedFiles(gitRef string) ([]string, error) {
-	if gitRef == "" {
-		gitRef = "HEAD~1"
-	}
-	if err := exec.Command("git", "rev-parse", "--verify", "HEAD").Run(); err != nil {
-		return []string{}, nil
-	}
-	cmd := exec.Command("git", "diff", "--name-only", gitRef+"...HEAD")
-	raw, err := cmd.Output()
-	if err != nil {
-		return []string{}, nil
-	}
-	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
-	out := make([]string, 0)
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		out = append(out, filepath.ToSlash(line))
-	}
-	return out, nil
+	return changes.FromGit(gitRef)
 }
 
 func Evaluate(policy Policy, statements []StatementView, gitRef string) ([]string, error) {
@@ -133,6 +165,28 @@ func Evaluate(policy Policy, statements []StatementView, gitRef string) ([]strin
 }
 
 func EvaluateWithChanged(policy Policy, statements []StatementView, changed []string) ([]string, error) {
+	ev, err := EvaluateWaivers(policy, statements, changed)
+	if err != nil {
+		return nil, err
+	}
+	return ev.Violations, nil
+}
+
+// EvaluateWaivers runs the gates like EvaluateWithChanged and also reports
+// the active waivers and every violation they suppressed.
+func EvaluateWaivers(policy Policy, statements []StatementView, changed []string) (Evaluation, error) {
+	return EvaluateContext(policy, statements, changed, TriggerContext{})
+}
+
+// EvaluateContext is EvaluateWaivers with the git ref and environment used by
+// ref, branch and environment triggers.
+func EvaluateContext(policy Policy, statements []StatementView, changed []string, tc TriggerContext) (Evaluation, error) {
+	ev := Evaluation{
+		Violations:    make([]string, 0),
+		Gates:         make([]GateResult, 0, len(policy.Gates)),
+		ActiveWaivers: ActiveWaivers(policy, now()),
+		Waived:        make([]WaivedViolation, 0),
+	}
 	present := make(map[string]struct{})
 	allowPlain := make(map[string]struct{})
 	for _, id := range policy.PlaintextAllowlist {
@@ -142,31 +196,87 @@ func EvaluateWithChanged(policy Policy, statements []StatementView, changed []st
 		present[st.AttestationType] = struct{}{}
 		if st.PrivacyMode == "plaintext_explicit" {
 			if _, ok := allowPlain[st.StatementID]; !ok {
-				return []string{"Sensitive payload exposure blocked by policy."}, nil
+				ev.Violations = []string{"Sensitive payload exposure blocked by policy."}
+				return ev, nil
 			}
 		}
 	}
 
-	violations := make([]string, 0)
 	for _, gate := range policy.Gates {
-		if !triggered(changed, gate.TriggerPaths) {
+		res := GateResult{
+			ID:                  gate.ID,
+			Status:              GateNotTriggered,
+			Message:             gate.Message,
+			TriggeredBy:         triggeringFiles(changed, gate.TriggerPaths),
+			Triggers:            contextTriggers(gate, tc),
+			MissingAttestations: make([]string, 0),
+			FailedConditions:    make([]ConditionFailure, 0),
+			Violations:          make([]string, 0),
+			WaiversApplied:      make([]Waiver, 0),
+		}
+		if len(res.TriggeredBy) == 0 && len(res.Triggers) == 0 {
+			ev.Gates = append(ev.Gates, res)
 			continue
 		}
-		missing := make([]string, 0)
+		waivers := waiversForGate(ev.ActiveWaivers, gate.ID)
+		gateW, gateCovered := gateWaiver(waivers, res.TriggeredBy, len(res.Triggers) > 0)
+		waive := func(msg string, w Waiver) {
+			ev.Waived = append(ev.Waived, WaivedViolation{Violation: msg, Waiver: w})
+			for _, applied := range res.WaiversApplied {
+				if reflect.DeepEqual(applied, w) {
+					return
+				}
+			}
+			res.WaiversApplied = append(res.WaiversApplied, w)
+		}
 		for _, req := range gate.RequiredAttestations {
 			if _, ok := present[req]; !ok {
-				missing = append(missing, req)
+				res.MissingAttestations = append(res.MissingAttestations, req)
 			}
 		}
-		if len(missing) > 0 {
+		if len(res.MissingAttestations) > 0 {
 			msg := gate.Message
 			if msg == "" {
-				msg = fmt.Sprintf("%s missing attestations: %s", gate.ID, strings.Join(missing, ", "))
+				msg = fmt.Sprintf("%s missing attestations: %s", gate.ID, strings.Join(res.MissingAttestations, ", "))
+			}
+			if gateCovered {
+				waive(msg, gateW)
+			} else {
+				res.Violations = append(res.Violations, msg)
 			}
-			violations = append(violations, msg)
+		}
+		for _, f := range evaluateConditions(gate, statements) {
+			if w, ok := statementWaiver(waivers, gateCovered, gateW, f.StatementID); ok {
+				f.Waived = true
+				waive(f.Message, w)
+			} else {
+				res.Violations = append(res.Violations, f.Message)
+			}
+			res.FailedConditions = append(res.FailedConditions, f)
+		}
+		switch {
+		case len(res.Violations) > 0:
+			res.Status = GateFailed
+		case len(res.WaiversApplied) > 0:
+			res.Status = GateWaived
+		default:
+			res.Status = GatePassed
+		}
+		ev.Violations = append(ev.Violations, res.Violations...)
+		ev.Gates = append(ev.Gates, res)
+	}
+	return ev, nil
+}
+
+// triggeringFiles returns the changed files matching any of the patterns.
+func triggeringFiles(changed []string, patterns []string) []string {
+	out := make([]string, 0)
+	for _, c := range changed {
+		if triggered([]string{c}, patterns) {
+			out = append(out, c)
 		}
 	}
-	return violations, nil
+	return out
 }
 
 func triggered(changed []string, patterns []string) bool {
@@ -208,11 +318,22 @@ func extract(payload map[string]any) StatementView {
 			}
 		}
 	}
+	predicate, _ := payload["predicate"].(map[string]any)
+	subjects := make([]map[string]any, 0)
+	if items, ok := payload["subject"].([]any); ok {
+		for _, item := range items {
+			if subject, ok := item.(map[string]any); ok {
+				subjects = append(subjects, subject)
+			}
+		}
+	}
 	return StatementView{
 		AttestationType: asString(payload["attestation_type"]),
 		StatementID:     asString(payload["statement_id"]),
 		PrivacyMode:     privacyMode,
 		DependsOn:       dependsOn,
+		Predicate:       predicate,
+		Subjects:        subjects,
 	}
 }
 
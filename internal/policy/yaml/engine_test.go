@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
@@ -51,6 +52,78 @@ gates:
 	}
 }
 
+func TestLoadPolicyChainRules(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	content := `version: "1"
+chain:
+  rules:
+    - type: eval_attestation
+      requires: [prompt_attestation]
+      optional: [corpus_attestation]
+  services:
+    chatbot:
+      - type: route_attestation
+        requires: [eval_attestation]
+`
+	if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPolicy(policyPath)
+	if err != nil {
+		t.Fatalf("LoadPolicy: %v", err)
+	}
+	if p.Chain == nil || len(p.Chain.Rules) != 1 || p.Chain.Rules[0].Optional[0] != "corpus_attestation" {
+		t.Fatalf("unexpected chain: %+v", p.Chain)
+	}
+	if len(p.Chain.Services["chatbot"]) != 1 {
+		t.Fatalf("expected chatbot override, got %+v", p.Chain.Services)
+	}
+
+	cyclic := `chain:
+  rules:
+    - type: a
+      requires: [b]
+    - type: b
+      requires: [a]
+`
+	if err := os.WriteFile(policyPath, []byte(cyclic), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(policyPath); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
+
+func TestLoadPolicySemantic(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	content := `version: "1"
+semantic:
+  reject_regression: true
+  slo_limits:
+    ttft_ms_p95_max: 800
+    error_budget_remaining_min: 0.1
+`
+	if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPolicy(policyPath)
+	if err != nil {
+		t.Fatalf("LoadPolicy: %v", err)
+	}
+	if !p.Semantic.RejectRegression || p.Semantic.SLOLimits["ttft_ms_p95_max"] != 800 {
+		t.Fatalf("unexpected semantic policy: %+v", p.Semantic)
+	}
+
+	if err := os.WriteFile(policyPath, []byte("semantic:\n  slo_limits:\n    ttft_ms_p95: 800\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(policyPath); err == nil || !strings.Contains(err.Error(), "policy semantic") {
+		t.Fatalf("expected semantic validation error, got %v", err)
+	}
+}
+
 func TestLoadPolicyFileNotFound(t *testing.T) {
 	_, err := LoadPolicy("/nonexistent/path.yaml")
 	if err == nil {
@@ -58,6 +131,19 @@ func TestLoadPolicyFileNotFound(t *testing.T) {
 	}
 }
 
+func TestLoadPolicyBytes(t *testing.T) {
+	p, err := LoadPolicyBytes([]byte("version: 1\ngates:\n  - id: G001\n    always: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Gates) != 1 || !p.Gates[0].Always {
+		t.Fatalf("unexpected policy %+v", p)
+	}
+	if _, err := LoadPolicyBytes([]byte("gates: [")); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
 func TestEvaluateWithChanged_NoViolations(t *testing.T) {
 	policy := Policy{
 		Gates: []Gate{
@@ -499,12 +585,10 @@ func TestChangedFiles_DefaultRef(t *testing.T) {
 }
 
 func TestChangedFiles_InvalidRef(t *testing.T) {
-	// A nonsense ref should return empty (graceful degradation), not error.
-	files, err := ChangedFiles("this-ref-definitely-does-not-exist-zzzz")
-	if err != nil {
-		t.Fatalf("ChangedFiles invalid ref: %v", err)
+	// A nonsense ref must fail: an empty list would silently skip every gate.
+	if _, err := ChangedFiles("this-ref-definitely-does-not-exist-zzzz"); err == nil {
+		t.Fatal("expected error for unknown git ref")
 	}
-	_ = files
 }
 
 // --- Evaluate Integration ---
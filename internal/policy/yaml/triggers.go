@@ -0,0 +1,39 @@
+package yaml
+
+import "strings"
+
+// TriggerContext describes where the gate runs, for gates that trigger on
+// something other than changed files.
+type TriggerContext struct {
+	// Ref is the full git ref, e.g. refs/tags/v1.2.0 or refs/heads/main.
+	Ref string `json:"ref"`
+	// Environment is the deployment target, e.g. prod.
+	Environment string `json:"environment"`
+}
+
+// Branch returns the branch name when Ref is a refs/heads/ ref.
+func (c TriggerContext) Branch() string {
+	if b, ok := strings.CutPrefix(c.Ref, "refs/heads/"); ok {
+		return b
+	}
+	return ""
+}
+
+// contextTriggers lists the non-path triggers of gate that fire in tc:
+// "always", "ref:<ref>", "branch:<name>" and "environment:<env>".
+func contextTriggers(gate Gate, tc TriggerContext) []string {
+	out := make([]string, 0)
+	if gate.Always {
+		out = append(out, "always")
+	}
+	if tc.Ref != "" && triggered([]string{tc.Ref}, gate.TriggerRefs) {
+		out = append(out, "ref:"+tc.Ref)
+	}
+	if branch := tc.Branch(); branch != "" && triggered([]string{branch}, gate.TriggerBranches) {
+		out = append(out, "branch:"+branch)
+	}
+	if tc.Environment != "" && triggered([]string{tc.Environment}, gate.TriggerEnvironments) {
+		out = append(out, "environment:"+tc.Environment)
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func releasePolicy(waivers ...Waiver) Policy {
+	return Policy{
+		Gates: []Gate{
+			{ID: "G005", TriggerPaths: []string{"release/**"}, TriggerRefs: []string{"refs/tags/v*"}, RequiredAttestations: []string{"slo_attestation"}, Message: "release blocked"},
+			{ID: "G010", TriggerBranches: []string{"release/**"}, RequiredAttestations: []string{"eval_attestation"}, Message: "release branch needs eval"},
+			{ID: "G020", TriggerEnvironments: []string{"prod", "prod-*"}, RequiredAttestations: []string{"route_attestation"}, Message: "prod needs route"},
+			{ID: "G030", Always: true, RequiredAttestations: []string{"prompt_attestation"}, Message: "prompt always required"},
+		},
+		Waivers: waivers,
+	}
+}
+
+func gateStatuses(ev Evaluation) map[string]string {
+	out := make(map[string]string, len(ev.Gates))
+	for _, g := range ev.Gates {
+		out[g.ID] = g.Status
+	}
+	return out
+}
+
+// --- TriggerContext ---
+
+func TestTriggerContextBranch(t *testing.T) {
+	if got := (TriggerContext{Ref: "refs/heads/release/1.2"}).Branch(); got != "release/1.2" {
+		t.Fatalf("Branch() = %q", got)
+	}
+	if got := (TriggerContext{Ref: "refs/tags/v1.2.0"}).Branch(); got != "" {
+		t.Fatalf("tag ref should have no branch, got %q", got)
+	}
+}
+
+// --- evaluation ---
+
+func TestEvaluateContext_TriggerKinds(t *testing.T) {
+	statements := []StatementView{{AttestationType: "prompt_attestation", StatementID: "p1"}}
+	tests := []struct {
+		name string
+		tc   TriggerContext
+		want map[string]string
+	}{
+		{"no context", TriggerContext{}, map[string]string{"G005": GateNotTriggered, "G010": GateNotTriggered, "G020": GateNotTriggered, "G030": GatePassed}},
+		{"release tag", TriggerContext{Ref: "refs/tags/v1.2.0"}, map[string]string{"G005": GateFailed, "G010": GateNotTriggered, "G020": GateNotTriggered, "G030": GatePassed}},
+		{"release branch", TriggerContext{Ref: "refs/heads/release/1.2"}, map[string]string{"G005": GateNotTriggered, "G010": GateFailed, "G020": GateNotTriggered, "G030": GatePassed}},
+		{"prod env", TriggerContext{Ref: "refs/heads/main", Environment: "prod-eu"}, map[string]string{"G005": GateNotTriggered, "G010": GateNotTriggered, "G020": GateFailed, "G030": GatePassed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := EvaluateContext(releasePolicy(), statements, nil, tt.tc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := gateStatuses(ev); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	ev, err := EvaluateContext(releasePolicy(), statements, []string{"release/notes.md"}, TriggerContext{Ref: "refs/tags/v1.2.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := ev.Gates[0]
+	if !reflect.DeepEqual(g.Triggers, []string{"ref:refs/tags/v1.2.0"}) || !reflect.DeepEqual(g.TriggeredBy, []string{"release/notes.md"}) {
+		t.Fatalf("expected both trigger kinds reported, got %+v", g)
+	}
+	if ev.Gates[3].Triggers[0] != "always" {
+		t.Fatalf("expected always trigger, got %+v", ev.Gates[3])
+	}
+}
+
+func TestEvaluateContext_Waivers(t *testing.T) {
+	fixedNow(t, "2026-10-18T12:00:00Z")
+	tag := TriggerContext{Ref: "refs/tags/v1.2.0"}
+	statements := []StatementView{{AttestationType: "prompt_attestation", StatementID: "p1"}}
+
+	pathWaiver := waiver("G005", "2026-10-19T00:00:00Z")
+	pathWaiver.Paths = []string{"release/**"}
+	ev, err := EvaluateContext(releasePolicy(pathWaiver), statements, []string{"release/notes.md"}, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Gates[0].Status != GateFailed {
+		t.Fatalf("path waiver must not cover a ref-triggered gate, got %+v", ev.Gates[0])
+	}
+
+	ev, err = EvaluateContext(releasePolicy(waiver("G005", "2026-10-19T00:00:00Z")), statements, nil, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Gates[0].Status != GateWaived || len(ev.Violations) != 0 {
+		t.Fatalf("whole-gate waiver should cover the release gate, got %+v", ev)
+	}
+}
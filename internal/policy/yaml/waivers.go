@@ -0,0 +1,175 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// now is the clock used to decide whether a waiver has expired.
+var now = time.Now
+
+// Waiver temporarily exempts a gate. With neither Paths nor StatementIDs it
+// covers the whole gate; Paths covers the gate only when every changed file
+// that triggers it matches one of them; StatementIDs covers condition
+// failures on those statements. Waivers stop applying at Expires.
+type Waiver struct {
+	Gate          string   `yaml:"gate" json:"gate"`
+	Paths         []string `yaml:"paths" json:"paths"`
+	StatementIDs  []string `yaml:"statement_ids" json:"statement_ids"`
+	Justification string   `yaml:"justification" json:"justification"`
+	Approver      string   `yaml:"approver" json:"approver"`
+	Expires       string   `yaml:"expires" json:"expires"`
+}
+
+// WaivedViolation is a violation suppressed by a waiver.
+type WaivedViolation struct {
+	Violation string `json:"violation"`
+	Waiver    Waiver `json:"waiver"`
+}
+
+// Gate result statuses.
+const (
+	GatePassed       = "passed"
+	GateFailed       = "failed"
+	GateWaived       = "waived"
+	GateNotTriggered = "not_triggered"
+)
+
+// GateResult is the outcome of one gate: the changed files that triggered
+// it, what was missing or failed, and the waivers that suppressed any of it.
+type GateResult struct {
+	ID          string   `json:"id"`
+	Status      string   `json:"status"`
+	Message     string   `json:"message,omitempty"`
+	TriggeredBy []string `json:"triggered_by"`
+	// Triggers lists the non-path triggers that fired, e.g. "ref:refs/tags/v1.2.0".
+	Triggers            []string           `json:"triggers"`
+	MissingAttestations []string           `json:"missing_attestations"`
+	FailedConditions    []ConditionFailure `json:"failed_conditions"`
+	Violations          []string           `json:"violations"`
+	WaiversApplied      []Waiver           `json:"waivers_applied"`
+}
+
+// Evaluation is the outcome of a YAML gate run: per-gate results, every
+// active waiver and the violations each one suppressed. Violations also
+// holds the plaintext block, which belongs to no gate.
+type Evaluation struct {
+	Violations    []string          `json:"violations"`
+	Gates         []GateResult      `json:"gates"`
+	ActiveWaivers []Waiver          `json:"active_waivers"`
+	Waived        []WaivedViolation `json:"waived"`
+}
+
+// Validate checks that the waiver names a gate, carries a justification and
+// approver, and has an RFC 3339 expiry.
+func (w Waiver) Validate(gateIDs map[string]struct{}) error {
+	if w.Gate == "" {
+		return fmt.Errorf("waiver gate is required")
+	}
+	if _, ok := gateIDs[w.Gate]; !ok {
+		return fmt.Errorf("waiver for unknown gate %s", w.Gate)
+	}
+	if w.Justification == "" || w.Approver == "" {
+		return fmt.Errorf("waiver for %s requires justification and approver", w.Gate)
+	}
+	if _, err := time.Parse(time.RFC3339, w.Expires); err != nil {
+		return fmt.Errorf("waiver for %s: invalid expires: %w", w.Gate, err)
+	}
+	return nil
+}
+
+// Scope describes what the waiver covers, e.g. "paths prompts/**".
+func (w Waiver) Scope() string {
+	scope := make([]string, 0, 2)
+	if len(w.Paths) > 0 {
+		scope = append(scope, "paths "+strings.Join(w.Paths, ","))
+	}
+	if len(w.StatementIDs) > 0 {
+		scope = append(scope, "statements "+strings.Join(w.StatementIDs, ","))
+	}
+	if len(scope) == 0 {
+		return "whole gate"
+	}
+	return strings.Join(scope, "; ")
+}
+
+// ActiveWaivers returns the policy's waivers that have not expired at t, with
+// list fields normalised to empty slices.
+func ActiveWaivers(policy Policy, t time.Time) []Waiver {
+	out := make([]Waiver, 0, len(policy.Waivers))
+	for _, w := range policy.Waivers {
+		expires, err := time.Parse(time.RFC3339, w.Expires)
+		if err != nil || !t.Before(expires) {
+			continue
+		}
+		if w.Paths == nil {
+			w.Paths = []string{}
+		}
+		if w.StatementIDs == nil {
+			w.StatementIDs = []string{}
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// gateWaiver picks the waiver, if any, that covers a gate's presence check:
+// a whole-gate waiver, or path waivers covering every triggering file. Path
+// waivers never cover a gate fired by a ref, branch, environment or always
+// trigger.
+func gateWaiver(waivers []Waiver, triggering []string, contextTriggered bool) (Waiver, bool) {
+	for _, w := range waivers {
+		if len(w.Paths) == 0 && len(w.StatementIDs) == 0 {
+			return w, true
+		}
+	}
+	if contextTriggered {
+		return Waiver{}, false
+	}
+	var covering *Waiver
+	for _, file := range triggering {
+		found := false
+		for i, w := range waivers {
+			if len(w.Paths) > 0 && triggered([]string{file}, w.Paths) {
+				found = true
+				if covering == nil {
+					covering = &waivers[i]
+				}
+				break
+			}
+		}
+		if !found {
+			return Waiver{}, false
+		}
+	}
+	if covering == nil {
+		return Waiver{}, false
+	}
+	return *covering, true
+}
+
+// statementWaiver picks the waiver covering a condition failure on statementID.
+func statementWaiver(waivers []Waiver, gateCovered bool, gate Waiver, statementID string) (Waiver, bool) {
+	if gateCovered {
+		return gate, true
+	}
+	for _, w := range waivers {
+		for _, id := range w.StatementIDs {
+			if id == statementID {
+				return w, true
+			}
+		}
+	}
+	return Waiver{}, false
+}
+
+func waiversForGate(waivers []Waiver, gateID string) []Waiver {
+	out := make([]Waiver, 0)
+	for _, w := range waivers {
+		if w.Gate == gateID {
+			out = append(out, w)
+		}
+	}
+	return out
+}
@@ -0,0 +1,193 @@
+package yaml
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fixedNow(t *testing.T, at string) {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := now
+	now = func() time.Time { return ts }
+	t.Cleanup(func() { now = prev })
+}
+
+func waiverPolicy(waivers ...Waiver) Policy {
+	return Policy{
+		Gates: []Gate{
+			{ID: "G001", TriggerPaths: []string{"prompts/**", "app/**"}, RequiredAttestations: []string{"eval_attestation"}, Message: "eval missing"},
+			{
+				ID:           "G002",
+				TriggerPaths: []string{"eval/**"},
+				Conditions: []Condition{
+					{Attestation: "eval_attestation", Field: "predicate.metrics.faithfulness", Op: OpGte, Value: 0.95},
+				},
+			},
+		},
+		Waivers: waivers,
+	}
+}
+
+func waiver(gate, expires string) Waiver {
+	return Waiver{Gate: gate, Justification: "hotfix", Approver: "alice", Expires: expires}
+}
+
+// --- load ---
+
+func TestLoadPolicyWaivers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(body string) string {
+		path := filepath.Join(dir, "policy.yaml")
+		if err := os.WriteFile(path, []byte("gates:\n  - id: G001\n    trigger_paths: [\"prompts/**\"]\n"+body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	p, err := LoadPolicy(write("waivers:\n  - gate: G001\n    paths: [\"prompts/hotfix.txt\"]\n    justification: incident 42\n    approver: alice\n    expires: \"2026-11-01T00:00:00Z\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Waivers) != 1 || p.Waivers[0].Approver != "alice" || p.Waivers[0].Paths[0] != "prompts/hotfix.txt" {
+		t.Fatalf("unexpected waivers: %+v", p.Waivers)
+	}
+
+	bad := map[string]string{
+		"unknown gate":  "waivers:\n  - gate: G999\n    justification: x\n    approver: a\n    expires: \"2026-11-01T00:00:00Z\"\n",
+		"no approver":   "waivers:\n  - gate: G001\n    justification: x\n    expires: \"2026-11-01T00:00:00Z\"\n",
+		"bad expiry":    "waivers:\n  - gate: G001\n    justification: x\n    approver: a\n    expires: next week\n",
+		"missing gate":  "waivers:\n  - justification: x\n    approver: a\n    expires: \"2026-11-01T00:00:00Z\"\n",
+		"no expiry set": "waivers:\n  - gate: G001\n    justification: x\n    approver: a\n",
+	}
+	for name, body := range bad {
+		if _, err := LoadPolicy(write(body)); err == nil || !strings.Contains(err.Error(), "policy waivers") {
+			t.Fatalf("%s: expected waiver error, got %v", name, err)
+		}
+	}
+}
+
+// --- evaluation ---
+
+func TestEvaluateWaivers_WholeGate(t *testing.T) {
+	fixedNow(t, "2026-10-18T12:00:00Z")
+	ev, err := EvaluateWaivers(waiverPolicy(waiver("G001", "2026-10-19T00:00:00Z")), nil, []string{"prompts/a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ev.Violations) != 0 {
+		t.Fatalf("expected waived gate to pass, got %v", ev.Violations)
+	}
+	if len(ev.ActiveWaivers) != 1 || len(ev.Waived) != 1 || ev.Waived[0].Violation != "eval missing" {
+		t.Fatalf("expected the bypass to be reported, got %+v", ev)
+	}
+
+	violations, err := EvaluateWithChanged(waiverPolicy(waiver("G001", "2026-10-19T00:00:00Z")), nil, []string{"prompts/a.txt"})
+	if err != nil || len(violations) != 0 {
+		t.Fatalf("EvaluateWithChanged must honour waivers: %v %v", violations, err)
+	}
+}
+
+func TestEvaluateWaivers_Expired(t *testing.T) {
+	fixedNow(t, "2026-10-18T12:00:00Z")
+	for _, expires := range []string{"2026-10-18T12:00:00Z", "2026-01-01T00:00:00Z"} {
+		ev, err := EvaluateWaivers(waiverPolicy(waiver("G001", expires)), nil, []string{"prompts/a.txt"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ev.Violations) != 1 || len(ev.ActiveWaivers) != 0 || len(ev.Waived) != 0 {
+			t.Fatalf("expires %s: expired waiver must be ignored, got %+v", expires, ev)
+		}
+	}
+}
+
+func TestEvaluateWaivers_PathScope(t *testing.T) {
+	fixedNow(t, "2026-10-18T12:00:00Z")
+	w := waiver("G001", "2026-10-19T00:00:00Z")
+	w.Paths = []string{"prompts/hotfix.txt"}
+	policy := waiverPolicy(w)
+
+	ev, err := EvaluateWaivers(policy, nil, []string{"prompts/hotfix.txt", "README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ev.Violations) != 0 || len(ev.Waived) != 1 {
+		t.Fatalf("covered trigger should be waived, got %+v", ev)
+	}
+
+	ev, err = EvaluateWaivers(policy, nil, []string{"prompts/hotfix.txt", "app/main.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ev.Violations) != 1 || len(ev.Waived) != 0 {
+		t.Fatalf("uncovered trigger must keep the gate, got %+v", ev)
+	}
+}
+
+func TestEvaluateWaivers_StatementScope(t *testing.T) {
+	fixedNow(t, "2026-10-18T12:00:00Z")
+	statements := []StatementView{
+		{AttestationType: "eval_attestation", StatementID: "eval-1", Predicate: map[string]any{"metrics": map[string]any{"faithfulness": 0.90}}},
+		{AttestationType: "eval_attestation", StatementID: "eval-2", Predicate: map[string]any{"metrics": map[string]any{"faithfulness": 0.91}}},
+	}
+	w := waiver("G002", "2026-10-19T00:00:00Z")
+	w.StatementIDs = []string{"eval-1"}
+
+	ev, err := EvaluateWaivers(waiverPolicy(w), statements, []string{"eval/run.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ev.Violations) != 1 || !strings.Contains(ev.Violations[0], "eval-2") {
+		t.Fatalf("only eval-2 should fail, got %v", ev.Violations)
+	}
+	if len(ev.Waived) != 1 || !strings.Contains(ev.Waived[0].Violation, "eval-1") {
+		t.Fatalf("eval-1 failure should be waived, got %+v", ev.Waived)
+	}
+}
+
+func TestActiveWaivers_NormalisesLists(t *testing.T) {
+	active := ActiveWaivers(waiverPolicy(waiver("G001", "2099-01-01T00:00:00Z")), time.Now())
+	if len(active) != 1 || active[0].Paths == nil || active[0].StatementIDs == nil {
+		t.Fatalf("expected non-nil lists, got %+v", active)
+	}
+}
+
+// --- gate results ---
+
+func TestEvaluateWaivers_GateResults(t *testing.T) {
+	fixedNow(t, "2026-10-18T12:00:00Z")
+	statements := []StatementView{
+		{AttestationType: "eval_attestation", StatementID: "eval-1", Predicate: map[string]any{"metrics": map[string]any{"faithfulness": 0.90}}},
+	}
+	ev, err := EvaluateWaivers(waiverPolicy(waiver("G002", "2026-10-19T00:00:00Z")), statements, []string{"prompts/a.txt", "app/main.go", "eval/run.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ev.Gates) != 2 {
+		t.Fatalf("expected a result per gate, got %+v", ev.Gates)
+	}
+	g1, g2 := ev.Gates[0], ev.Gates[1]
+	if g1.Status != GatePassed || len(g1.TriggeredBy) != 2 || len(g1.MissingAttestations) != 0 {
+		t.Fatalf("unexpected G001 result: %+v", g1)
+	}
+	if g2.Status != GateWaived || len(g2.FailedConditions) != 1 || !g2.FailedConditions[0].Waived || len(g2.WaiversApplied) != 1 {
+		t.Fatalf("unexpected G002 result: %+v", g2)
+	}
+
+	ev, err = EvaluateWaivers(waiverPolicy(), nil, []string{"README.md", "prompts/a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Gates[0].Status != GateFailed || ev.Gates[0].MissingAttestations[0] != "eval_attestation" || ev.Gates[0].TriggeredBy[0] != "prompts/a.txt" {
+		t.Fatalf("unexpected G001 result: %+v", ev.Gates[0])
+	}
+	if ev.Gates[1].Status != GateNotTriggered {
+		t.Fatalf("expected G002 not triggered, got %+v", ev.Gates[1])
+	}
+}
@@ -0,0 +1,357 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	policyyaml "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
+)
+
+// Gate report formats.
+const (
+	GateFormatJSON     = "json"
+	GateFormatSARIF    = "sarif"
+	GateFormatJUnit    = "junit"
+	GateFormatMarkdown = "md"
+)
+
+// GateReport is the structured result of `llmsa gate`. Gates is filled by
+// the YAML engine; Rego policies return violations only, so they appear
+// under Violations without a gate ID.
+type GateReport struct {
+	Engine        string                       `json:"engine"`
+	Policy        string                       `json:"policy"`
+	Ref           string                       `json:"ref,omitempty"`
+	Environment   string                       `json:"environment,omitempty"`
+	Passed        bool                         `json:"passed"`
+	ExitCode      int                          `json:"exit_code"`
+	ChangedFiles  []string                     `json:"changed_files"`
+	Gates         []policyyaml.GateResult      `json:"gates"`
+	Violations    []string                     `json:"violations"`
+	ActiveWaivers []policyyaml.Waiver          `json:"active_waivers"`
+	Waived        []policyyaml.WaivedViolation `json:"waived"`
+}
+
+// unattributed returns violations that belong to no gate result, such as
+// the plaintext block or Rego violations.
+func (r GateReport) unattributed() []string {
+	attributed := make(map[string]struct{})
+	for _, g := range r.Gates {
+		for _, v := range g.Violations {
+			attributed[v] = struct{}{}
+		}
+	}
+	out := make([]string, 0)
+	for _, v := range r.Violations {
+		if _, ok := attributed[v]; !ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// BuildGate renders the report in the given format.
+func BuildGate(format string, r GateReport) ([]byte, error) {
+	switch format {
+	case GateFormatJSON:
+		return json.MarshalIndent(r, "", "  ")
+	case GateFormatSARIF:
+		return BuildGateSARIF(r)
+	case GateFormatJUnit:
+		return BuildGateJUnit(r)
+	case GateFormatMarkdown:
+		return []byte(BuildGateMarkdown(r)), nil
+	default:
+		return nil, fmt.Errorf("unsupported gate report format %s", format)
+	}
+}
+
+// WriteGate writes the report in the given format to path.
+func WriteGate(path, format string, r GateReport) error {
+	raw, err := BuildGate(format, r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
+
+func BuildGateMarkdown(r GateReport) string {
+	status := "PASS"
+	if !r.Passed {
+		status = "FAIL"
+	}
+	var b strings.Builder
+	b.WriteString("# LLM Supply-Chain Policy Gate Report\n\n")
+	b.WriteString(fmt.Sprintf("- Status: **%s**\n", status))
+	b.WriteString(fmt.Sprintf("- Exit Code: `%d`\n", r.ExitCode))
+	b.WriteString(fmt.Sprintf("- Engine: `%s`\n", r.Engine))
+	b.WriteString(fmt.Sprintf("- Policy: `%s`\n", r.Policy))
+	if r.Ref != "" {
+		b.WriteString(fmt.Sprintf("- Ref: `%s`\n", r.Ref))
+	}
+	if r.Environment != "" {
+		b.WriteString(fmt.Sprintf("- Environment: `%s`\n", r.Environment))
+	}
+	b.WriteString(fmt.Sprintf("- Changed Files: `%d`\n", len(r.ChangedFiles)))
+
+	if len(r.Gates) > 0 {
+		b.WriteString("\n## Gates\n\n")
+		b.WriteString("| Gate | Status | Triggered By | Missing Attestations | Failed Conditions |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, g := range r.Gates {
+			conds := make([]string, 0, len(g.FailedConditions))
+			for _, c := range g.FailedConditions {
+				entry := c.StatementID + ": " + c.Field + " " + c.Op
+				if c.Waived {
+					entry += " (waived)"
+				}
+				conds = append(conds, entry)
+			}
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", g.ID, g.Status, listOrDash(append(append([]string{}, g.Triggers...), g.TriggeredBy...)), listOrDash(g.MissingAttestations), escapeCell(listOrDash(conds))))
+		}
+	}
+
+	if len(r.Violations) > 0 {
+		b.WriteString("\n## Violations\n\n")
+		for _, v := range r.Violations {
+			b.WriteString("- " + v + "\n")
+		}
+	}
+
+	if len(r.ActiveWaivers) > 0 {
+		b.WriteString("\n## Active Waivers\n\n")
+		b.WriteString("| Gate | Scope | Approver | Expires | Justification |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, w := range r.ActiveWaivers {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", w.Gate, w.Scope(), w.Approver, w.Expires, escapeCell(w.Justification)))
+		}
+	}
+	if len(r.Waived) > 0 {
+		b.WriteString("\n### Waived Violations\n\n")
+		for _, v := range r.Waived {
+			b.WriteString(fmt.Sprintf("- %s (%s, approved by %s)\n", v.Violation, v.Waiver.Gate, v.Waiver.Approver))
+		}
+	}
+	return b.String()
+}
+
+// SARIF 2.1.0 document types.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations,omitempty"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Status        string `json:"status"`
+	Justification string `json:"justification"`
+}
+
+// policyRuleID names violations that belong to no gate.
+const policyRuleID = "llmsa-policy"
+
+// BuildGateSARIF renders one result per violation, located at the changed
+// files that triggered its gate. Waived violations are included with an
+// accepted external suppression carrying the waiver justification.
+func BuildGateSARIF(r GateReport) ([]byte, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "llmsa",
+			InformationURI: "https://github.com/ogulcanaydogan/llm-supply-chain-attestation",
+			Rules:          make([]sarifRule, 0, len(r.Gates)+1),
+		}},
+		Results: make([]sarifResult, 0),
+	}
+	for _, g := range r.Gates {
+		desc := g.Message
+		if desc == "" {
+			desc = "Policy gate " + g.ID
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: g.ID, ShortDescription: sarifMessage{Text: desc}})
+		locations := make([]sarifLocation, 0, len(g.TriggeredBy))
+		for _, f := range g.TriggeredBy {
+			locations = append(locations, sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: f}}})
+		}
+		for _, v := range g.Violations {
+			run.Results = append(run.Results, sarifResult{RuleID: g.ID, Level: "error", Message: sarifMessage{Text: v}, Locations: locations})
+		}
+		for _, w := range r.Waived {
+			if w.Waiver.Gate != g.ID {
+				continue
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    g.ID,
+				Level:     "error",
+				Message:   sarifMessage{Text: w.Violation},
+				Locations: locations,
+				Suppressions: []sarifSuppression{{
+					Kind:          "external",
+					Status:        "accepted",
+					Justification: fmt.Sprintf("%s (approved by %s until %s)", w.Waiver.Justification, w.Waiver.Approver, w.Waiver.Expires),
+				}},
+			})
+		}
+	}
+	if other := r.unattributed(); len(other) > 0 {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: policyRuleID, ShortDescription: sarifMessage{Text: "Policy violation not tied to a gate"}})
+		for _, v := range other {
+			run.Results = append(run.Results, sarifResult{RuleID: policyRuleID, Level: "error", Message: sarifMessage{Text: v}})
+		}
+	}
+	return json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+}
+
+// JUnit XML document types.
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// BuildGateJUnit renders one test case per gate: failed gates fail,
+// untriggered gates are skipped, and waived gates pass with the waiver in
+// system-out. Violations outside any gate become one extra failing case.
+func BuildGateJUnit(r GateReport) ([]byte, error) {
+	suite := junitSuite{Name: "llmsa gate (" + r.Engine + ")", Cases: make([]junitCase, 0, len(r.Gates)+1)}
+	for _, g := range r.Gates {
+		c := junitCase{Name: g.ID, ClassName: "llmsa.gate"}
+		switch g.Status {
+		case policyyaml.GateFailed:
+			c.Failure = &junitFailure{Message: g.Violations[0], Body: strings.Join(g.Violations, "\n")}
+		case policyyaml.GateNotTriggered:
+			c.Skipped = &junitSkipped{Message: "not triggered"}
+		}
+		lines := make([]string, 0)
+		if len(g.Triggers) > 0 {
+			lines = append(lines, "triggered on: "+strings.Join(g.Triggers, ", "))
+		}
+		if len(g.TriggeredBy) > 0 {
+			lines = append(lines, "triggered by: "+strings.Join(g.TriggeredBy, ", "))
+		}
+		for _, w := range g.WaiversApplied {
+			lines = append(lines, fmt.Sprintf("waived by %s until %s: %s", w.Approver, w.Expires, w.Justification))
+		}
+		c.SystemOut = strings.Join(lines, "\n")
+		suite.Cases = append(suite.Cases, c)
+	}
+	if other := r.unattributed(); len(other) > 0 {
+		suite.Cases = append(suite.Cases, junitCase{
+			Name:      policyRuleID,
+			ClassName: "llmsa.gate",
+			Failure:   &junitFailure{Message: other[0], Body: strings.Join(other, "\n")},
+		})
+	}
+	for _, c := range suite.Cases {
+		suite.Tests++
+		if c.Failure != nil {
+			suite.Failures++
+		}
+		if c.Skipped != nil {
+			suite.Skipped++
+		}
+	}
+	raw, err := xml.MarshalIndent(junitSuites{
+		Name:     "llmsa",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(raw, '\n')...), nil
+}
+
+func listOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ", ")
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,157 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	policyyaml "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
+)
+
+func sampleGateReport() GateReport {
+	waiver := policyyaml.Waiver{Gate: "G002", Justification: "INC-1 hotfix", Approver: "alice", Expires: "2099-01-01T00:00:00Z"}
+	return GateReport{
+		Engine:       "yaml",
+		Policy:       "policy/examples/mvp-gates.yaml",
+		Ref:          "refs/tags/v1.2.0",
+		Passed:       false,
+		ExitCode:     13,
+		ChangedFiles: []string{"prompts/system.txt", "corpus/a.md"},
+		Gates: []policyyaml.GateResult{
+			{
+				ID:                  "G001",
+				Status:              policyyaml.GateFailed,
+				Message:             "Prompt changed without passing eval attestation.",
+				TriggeredBy:         []string{"prompts/system.txt"},
+				Triggers:            []string{"ref:refs/tags/v1.2.0"},
+				MissingAttestations: []string{"eval_attestation"},
+				FailedConditions: []policyyaml.ConditionFailure{
+					{Attestation: "eval_attestation", StatementID: "eval-1", Field: "predicate.metrics.faithfulness", Op: "gte", Message: "faithfulness too low"},
+				},
+				Violations: []string{"Prompt changed without passing eval attestation.", "faithfulness too low"},
+			},
+			{
+				ID:             "G002",
+				Status:         policyyaml.GateWaived,
+				TriggeredBy:    []string{"corpus/a.md"},
+				Violations:     []string{},
+				WaiversApplied: []policyyaml.Waiver{waiver},
+			},
+			{ID: "G003", Status: policyyaml.GateNotTriggered},
+		},
+		Violations:    []string{"Prompt changed without passing eval attestation.", "faithfulness too low", "rego says no"},
+		ActiveWaivers: []policyyaml.Waiver{waiver},
+		Waived:        []policyyaml.WaivedViolation{{Violation: "G002 missing attestations: corpus_attestation", Waiver: waiver}},
+	}
+}
+
+// --- JSON ---
+
+func TestBuildGateJSON(t *testing.T) {
+	raw, err := BuildGate(GateFormatJSON, sampleGateReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	gates := doc["gates"].([]any)
+	first := gates[0].(map[string]any)
+	if first["id"] != "G001" || first["triggered_by"].([]any)[0] != "prompts/system.txt" {
+		t.Fatalf("unexpected gate entry: %v", first)
+	}
+	if len(doc["waived"].([]any)) != 1 {
+		t.Fatalf("expected waived violations in JSON: %v", doc["waived"])
+	}
+}
+
+// --- SARIF ---
+
+func TestBuildGateSARIF(t *testing.T) {
+	raw, err := BuildGateSARIF(sampleGateReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(raw, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF envelope: %+v", log)
+	}
+	run := log.Runs[0]
+	// Two G001 violations, one suppressed G002 violation, one unattributed.
+	if len(run.Results) != 4 {
+		t.Fatalf("expected 4 results, got %d: %+v", len(run.Results), run.Results)
+	}
+	if run.Results[0].RuleID != "G001" || run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI != "prompts/system.txt" {
+		t.Fatalf("unexpected first result: %+v", run.Results[0])
+	}
+	waived := run.Results[2]
+	if waived.RuleID != "G002" || len(waived.Suppressions) != 1 || !strings.Contains(waived.Suppressions[0].Justification, "approved by alice") {
+		t.Fatalf("expected suppressed waiver result, got %+v", waived)
+	}
+	if run.Results[3].RuleID != policyRuleID || run.Results[3].Message.Text != "rego says no" {
+		t.Fatalf("expected unattributed policy result, got %+v", run.Results[3])
+	}
+}
+
+// --- JUnit ---
+
+func TestBuildGateJUnit(t *testing.T) {
+	raw, err := BuildGateJUnit(sampleGateReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suites junitSuites
+	if err := xml.Unmarshal(raw, &suites); err != nil {
+		t.Fatalf("invalid junit xml: %v\n%s", err, raw)
+	}
+	if suites.Tests != 4 || suites.Failures != 2 || suites.Skipped != 1 {
+		t.Fatalf("unexpected counts: tests=%d failures=%d skipped=%d", suites.Tests, suites.Failures, suites.Skipped)
+	}
+	cases := suites.Suites[0].Cases
+	if cases[0].Failure == nil || !strings.Contains(cases[0].Failure.Body, "faithfulness too low") || !strings.Contains(cases[0].SystemOut, "triggered on: ref:refs/tags/v1.2.0") {
+		t.Fatalf("expected G001 failure with both violations: %+v", cases[0])
+	}
+	if cases[1].Failure != nil || !strings.Contains(cases[1].SystemOut, "waived by alice") {
+		t.Fatalf("expected waived G002 to pass with waiver in system-out: %+v", cases[1])
+	}
+	if cases[2].Skipped == nil {
+		t.Fatalf("expected untriggered G003 to be skipped: %+v", cases[2])
+	}
+}
+
+// --- Markdown ---
+
+func TestBuildGateMarkdown(t *testing.T) {
+	md := BuildGateMarkdown(sampleGateReport())
+	for _, want := range []string{
+		"- Status: **FAIL**",
+		"- Ref: `refs/tags/v1.2.0`",
+		"| G001 | failed | ref:refs/tags/v1.2.0, prompts/system.txt | eval_attestation | eval-1: predicate.metrics.faithfulness gte |",
+		"| G002 | whole gate | alice | 2099-01-01T00:00:00Z | INC-1 hotfix |",
+		"- G002 missing attestations: corpus_attestation (G002, approved by alice)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestWriteGateUnsupportedFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gate.out")
+	if err := WriteGate(path, "xml", sampleGateReport()); err == nil {
+		t.Fatal("expected unsupported format error")
+	}
+	if err := WriteGate(path, GateFormatJUnit, sampleGateReport()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -23,7 +23,11 @@ func BuildMarkdown(r verify.Report) string {
 	b.WriteString("| Bundle | Check | Passed | Message |\n")
 	b.WriteString("|---|---|---:|---|\n")
 	for _, c := range r.Checks {
-		b.WriteString(fmt.Sprintf("| %s | %s | %t | %s |\n", c.Bundle, c.Check, c.Passed, strings.ReplaceAll(c.Message, "|", "\\|")))
+		passed := fmt.Sprintf("%t", c.Passed)
+		if c.Skipped {
+			passed = "skipped"
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", c.Bundle, c.Check, passed, strings.ReplaceAll(c.Message, "|", "\\|")))
 	}
 
 	if len(r.Violations) > 0 {
@@ -51,9 +55,24 @@ func BuildMarkdown(r verify.Report) string {
 	b.WriteString(fmt.Sprintf("- Nodes: `%d`\n", len(r.Chain.Nodes)))
 	b.WriteString(fmt.Sprintf("- Edges: `%d`\n", len(r.Chain.Edges)))
 
+	if len(r.Chain.Rules) > 0 {
+		b.WriteString("\n| Rule Type | Requires | Optional |\n")
+		b.WriteString("|---|---|---|\n")
+		for _, rule := range r.Chain.Rules {
+			requires, optional := "-", "-"
+			if len(rule.Requires) > 0 {
+				requires = strings.Join(rule.Requires, ", ")
+			}
+			if len(rule.Optional) > 0 {
+				optional = strings.Join(rule.Optional, ", ")
+			}
+			b.WriteString(fmt.Sprintf("| %s | %s | %s |\n", rule.Type, requires, optional))
+		}
+	}
+
 	if len(r.Chain.Edges) > 0 {
-		b.WriteString("\n| From Statement | From Type | To Type | To Statement | Satisfied | Detail |\n")
-		b.WriteString("|---|---|---|---|---:|---|\n")
+		b.WriteString("\n| From Statement | From Type | To Type | To Statement | Pinned Digest | Satisfied | Detail |\n")
+		b.WriteString("|---|---|---|---|---|---:|---|\n")
 		for _, e := range r.Chain.Edges {
 			toID := e.ToStatementID
 			if toID == "" {
@@ -63,7 +82,15 @@ func BuildMarkdown(r verify.Report) string {
 			if detail == "" {
 				detail = "ok"
 			}
-			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %t | %s |\n", e.FromStatementID, e.FromType, e.ToType, toID, e.Satisfied, detail))
+			toType := e.ToType
+			if e.Optional {
+				toType += " (optional)"
+			}
+			pinned := "-"
+			if e.PinnedDigest != "" {
+				pinned = "`" + e.PinnedDigest + "`"
+			}
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %t | %s |\n", e.FromStatementID, e.FromType, toType, toID, pinned, e.Satisfied, detail))
 		}
 	}
 
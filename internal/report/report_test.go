@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
 )
 
@@ -81,6 +82,16 @@ func TestBuildMarkdown_PassingReport(t *testing.T) {
 	}
 }
 
+func TestBuildMarkdown_SkippedCheck(t *testing.T) {
+	r := sampleReport()
+	r.Checks = append(r.Checks, verify.CheckResult{Bundle: "legacy.bundle.json", Check: "predicate_binding", Skipped: true, Message: "skipped (statement predates predicate binding)"})
+
+	md := BuildMarkdown(r)
+	if !strings.Contains(md, "| legacy.bundle.json | predicate_binding | skipped |") {
+		t.Errorf("skipped check should not render as passed:\n%s", md)
+	}
+}
+
 func TestBuildMarkdown_FailingReport(t *testing.T) {
 	r := sampleReport()
 	r.Passed = false
@@ -158,6 +169,20 @@ func TestBuildMarkdown_EdgeEmptyToID(t *testing.T) {
 	}
 }
 
+func TestBuildMarkdown_EdgePinnedDigest(t *testing.T) {
+	r := verify.Report{
+		Chain: verify.ChainReport{
+			Edges: []verify.ChainEdge{
+				{FromStatementID: "eval-1", FromType: "eval_attestation", ToType: "prompt_attestation", PinnedDigest: "sha256:v1", Detail: "pinned_predecessor_missing"},
+			},
+		},
+	}
+	md := BuildMarkdown(r)
+	if !strings.Contains(md, "| `sha256:v1` | false | pinned_predecessor_missing |") {
+		t.Errorf("expected pinned digest column, got:\n%s", md)
+	}
+}
+
 func TestBuildMarkdown_PipeInMessage(t *testing.T) {
 	r := verify.Report{
 		Passed:      true,
@@ -216,3 +241,28 @@ func TestWriteJSON(t *testing.T) {
 		t.Errorf("checks count = %d, want 3", len(r.Checks))
 	}
 }
+
+func TestBuildMarkdown_ChainRules(t *testing.T) {
+	r := verify.Report{
+		Chain: verify.ChainReport{
+			Valid: true,
+			Rules: []chain.Rule{
+				{Type: "eval_attestation", Requires: []string{"prompt_attestation"}, Optional: []string{"corpus_attestation"}},
+				{Type: "slo_attestation", Optional: []string{"route_attestation"}},
+			},
+			Edges: []verify.ChainEdge{
+				{FromStatementID: "eval-1", FromType: "eval_attestation", ToType: "corpus_attestation", Optional: true, Satisfied: true, Detail: "optional_absent"},
+			},
+		},
+	}
+	md := BuildMarkdown(r)
+	if !strings.Contains(md, "| eval_attestation | prompt_attestation | corpus_attestation |") {
+		t.Errorf("expected chain rule row, got:\n%s", md)
+	}
+	if !strings.Contains(md, "| slo_attestation | - | route_attestation |") {
+		t.Errorf("expected dash for empty requires, got:\n%s", md)
+	}
+	if !strings.Contains(md, "| corpus_attestation (optional) |") {
+		t.Errorf("expected optional edge marker, got:\n%s", md)
+	}
+}
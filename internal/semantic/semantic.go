@@ -0,0 +1,106 @@
+// Package semantic holds the eval and SLO rules shared by attestation
+// creation, policy loading and verification.
+package semantic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Policy holds policy-level rejections applied to eval and SLO predicates on
+// top of the consistency checks, which always run.
+type Policy struct {
+	// RejectRegression fails any eval statement with regression_detected: true.
+	RejectRegression bool `yaml:"reject_regression" json:"reject_regression,omitempty"`
+	// SLOLimits bounds SLO predicate numbers using the eval threshold
+	// convention: "<field>_max" and "<field>_min", e.g. ttft_ms_p95_max.
+	SLOLimits map[string]float64 `yaml:"slo_limits" json:"slo_limits,omitempty"`
+}
+
+// sloNumericFields are the SLO predicate fields SLOLimits may bound.
+var sloNumericFields = map[string]struct{}{
+	"ttft_ms_p50":                {},
+	"ttft_ms_p95":                {},
+	"tokens_per_sec_p50":         {},
+	"cost_per_1k_tokens_cap_usd": {},
+	"error_rate_cap":             {},
+	"error_budget_remaining":     {},
+}
+
+// Validate rejects SLO limit keys that do not name a numeric SLO field with a
+// _min or _max suffix.
+func (p Policy) Validate() error {
+	for _, key := range sortedLimitKeys(p.SLOLimits) {
+		field, _, ok := splitLimitKey(key)
+		if !ok {
+			return fmt.Errorf("slo limit %s must end in _min or _max", key)
+		}
+		if _, known := sloNumericFields[field]; !known {
+			return fmt.Errorf("slo limit %s: unknown slo field %s", key, field)
+		}
+	}
+	return nil
+}
+
+// SLOBreaches lists the SLOLimits an SLO predicate breaches, in limit key
+// order. Fields absent from the predicate are not reported.
+func (p Policy) SLOBreaches(predicate map[string]any) []string {
+	var breached []string
+	for _, key := range sortedLimitKeys(p.SLOLimits) {
+		field, isMax, ok := splitLimitKey(key)
+		if !ok {
+			continue
+		}
+		value, present := predicate[field].(float64)
+		if !present {
+			continue
+		}
+		if limit := p.SLOLimits[key]; breaches(value, limit, isMax) {
+			breached = append(breached, fmt.Sprintf("%s %g (limit %s %g)", field, value, key, limit))
+		}
+	}
+	return breached
+}
+
+// EvalRegression reports whether any metric breaches its threshold. A
+// "<metric>_min" threshold is breached by a lower value and "<metric>_max" by a
+// higher one; a missing metric counts as zero.
+func EvalRegression(metrics, thresholds map[string]float64) bool {
+	for key, limit := range thresholds {
+		metric, isMax, ok := splitLimitKey(key)
+		if !ok {
+			continue
+		}
+		if breaches(metrics[metric], limit, isMax) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitLimitKey(key string) (field string, isMax bool, ok bool) {
+	switch {
+	case strings.HasSuffix(key, "_max"):
+		return strings.TrimSuffix(key, "_max"), true, true
+	case strings.HasSuffix(key, "_min"):
+		return strings.TrimSuffix(key, "_min"), false, true
+	}
+	return "", false, false
+}
+
+func breaches(value, limit float64, isMax bool) bool {
+	if isMax {
+		return value > limit
+	}
+	return value < limit
+}
+
+func sortedLimitKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
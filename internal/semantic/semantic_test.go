@@ -0,0 +1,50 @@
+package semantic
+
+import (
+	"strings"
+	"testing"
+)
+
+// --- EvalRegression ---
+
+func TestEvalRegression(t *testing.T) {
+	cases := []struct {
+		name       string
+		metrics    map[string]float64
+		thresholds map[string]float64
+		want       bool
+	}{
+		{"within min", map[string]float64{"accuracy": 0.95}, map[string]float64{"accuracy_min": 0.9}, false},
+		{"below min", map[string]float64{"accuracy": 0.85}, map[string]float64{"accuracy_min": 0.9}, true},
+		{"above max", map[string]float64{"latency": 120}, map[string]float64{"latency_max": 100}, true},
+		{"missing metric counts as zero", map[string]float64{}, map[string]float64{"accuracy_min": 0.9}, true},
+		{"unsuffixed threshold ignored", map[string]float64{"accuracy": 0.1}, map[string]float64{"accuracy": 0.9}, false},
+	}
+	for _, tc := range cases {
+		if got := EvalRegression(tc.metrics, tc.thresholds); got != tc.want {
+			t.Errorf("%s: got %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+// --- Policy ---
+
+func TestPolicyValidate(t *testing.T) {
+	if err := (Policy{SLOLimits: map[string]float64{"ttft_ms_p95_max": 1}}).Validate(); err != nil {
+		t.Fatalf("expected valid policy, got %v", err)
+	}
+	if err := (Policy{SLOLimits: map[string]float64{"ttft_ms_p95": 1}}).Validate(); err == nil || !strings.Contains(err.Error(), "_min or _max") {
+		t.Fatalf("expected suffix error, got %v", err)
+	}
+	if err := (Policy{SLOLimits: map[string]float64{"latency_max": 1}}).Validate(); err == nil || !strings.Contains(err.Error(), "unknown slo field latency") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestPolicySLOBreaches(t *testing.T) {
+	p := Policy{SLOLimits: map[string]float64{"ttft_ms_p95_max": 800, "error_budget_remaining_min": 0.1, "tokens_per_sec_p50_min": 10}}
+	got := p.SLOBreaches(map[string]any{"ttft_ms_p95": 900.0, "error_budget_remaining": 0.2})
+	if len(got) != 1 || !strings.HasPrefix(got[0], "ttft_ms_p95 900") {
+		t.Fatalf("expected only the ttft breach, got %v", got)
+	}
+}
@@ -35,6 +35,9 @@ type Metadata struct {
 	BundleVersion string `json:"bundle_version"`
 	CreatedAt     string `json:"created_at"`
 	StatementHash string `json:"statement_hash"`
+	// Canonicalization names the serialisation of the payload. Bundles
+	// written before it was recorded use the legacy llmsa form.
+	Canonicalization string `json:"canonicalization,omitempty"`
 }
 
 type SignMaterial struct {
@@ -48,7 +51,7 @@ type SignMaterial struct {
 }
 
 func CreateBundle(statement any, material SignMaterial) (Bundle, error) {
-	canonical, err := hash.CanonicalJSON(statement)
+	canonical, err := hash.Canonicalize(hash.DefaultCanonicalization, statement)
 	if err != nil {
 		return Bundle{}, err
 	}
@@ -69,9 +72,10 @@ func CreateBundle(statement any, material SignMaterial) (Bundle, error) {
 			}},
 		},
 		Metadata: Metadata{
-			BundleVersion: "1",
-			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
-			StatementHash: statementHash,
+			BundleVersion:    "1",
+			CreatedAt:        time.Now().UTC().Format(time.RFC3339),
+			StatementHash:    statementHash,
+			Canonicalization: hash.DefaultCanonicalization,
 		},
 	}
 	return bundle, nil
@@ -58,6 +58,9 @@ func TestCreateBundle_MetadataFields(t *testing.T) {
 	if !strings.HasPrefix(bundle.Metadata.StatementHash, "sha256:") {
 		t.Errorf("statement_hash should start with sha256:, got %q", bundle.Metadata.StatementHash)
 	}
+	if bundle.Metadata.Canonicalization != "jcs-rfc8785" {
+		t.Errorf("canonicalization = %q, want jcs-rfc8785", bundle.Metadata.Canonicalization)
+	}
 	_, err = time.Parse(time.RFC3339, bundle.Metadata.CreatedAt)
 	if err != nil {
 		t.Errorf("created_at is not RFC3339: %q", bundle.Metadata.CreatedAt)
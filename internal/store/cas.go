@@ -0,0 +1,415 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+)
+
+// DefaultStoreDir is the root of the local content-addressed store.
+const DefaultStoreDir = ".llmsa/store"
+
+const indexVersion = 1
+
+// Entry describes one bundle in a LocalStore.
+type Entry struct {
+	StatementHash   string `json:"statement_hash"`
+	AttestationType string `json:"attestation_type"`
+	StatementID     string `json:"statement_id"`
+	GitSHA          string `json:"git_sha,omitempty"`
+	GeneratedAt     string `json:"generated_at"`
+	// ClaimedSigner is the OIDC identity the first signature claims, or its
+	// key ID. Add does not verify signatures, so it is only a claim: select
+	// with it, then trust what verify reports.
+	ClaimedSigner string `json:"claimed_signer,omitempty"`
+	AddedAt       string `json:"added_at"`
+}
+
+type storeIndex struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// LocalStore keeps bundles under blobs/sha256/<hex>.bundle.json, keyed by
+// the hash of their statement, with an index.json describing each one.
+type LocalStore struct {
+	root string
+}
+
+// OpenLocalStore returns the store rooted at root; it is created on the
+// first Add.
+func OpenLocalStore(root string) *LocalStore {
+	if root == "" {
+		root = DefaultStoreDir
+	}
+	return &LocalStore{root: root}
+}
+
+// Root returns the store directory.
+func (s *LocalStore) Root() string { return s.root }
+
+// Path returns the bundle file for an entry.
+func (s *LocalStore) Path(e Entry) string {
+	return filepath.Join(s.root, "blobs", "sha256", strings.TrimPrefix(e.StatementHash, "sha256:")+".bundle.json")
+}
+
+// Add stores the bundle at path. The statement hash is recomputed from the
+// payload and must match the bundle metadata. Adding a bundle that is
+// already present returns its entry and false.
+func (s *LocalStore) Add(path string) (Entry, bool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("read bundle: %w", err)
+	}
+	entry, err := describeBundle(raw)
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("%s: %w", path, err)
+	}
+	idx, err := s.readIndex()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	for _, e := range idx.Entries {
+		if e.StatementHash == entry.StatementHash {
+			return e, false, nil
+		}
+	}
+	dst := s.Path(entry)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return Entry{}, false, fmt.Errorf("create store: %w", err)
+	}
+	if err := os.WriteFile(dst, raw, 0o644); err != nil {
+		return Entry{}, false, fmt.Errorf("write bundle: %w", err)
+	}
+	entry.AddedAt = time.Now().UTC().Format(time.RFC3339)
+	idx.Entries = append(idx.Entries, entry)
+	if err := s.writeIndex(idx); err != nil {
+		return Entry{}, false, err
+	}
+	return entry, true, nil
+}
+
+// Entries lists the indexed bundles, newest first.
+func (s *LocalStore) Entries() ([]Entry, error) {
+	idx, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	sortEntries(idx.Entries)
+	return idx.Entries, nil
+}
+
+// Resolve finds the entry whose statement hash starts with prefix, with or
+// without the "sha256:" algorithm prefix.
+func (s *LocalStore) Resolve(prefix string) (Entry, error) {
+	entries, err := s.Entries()
+	if err != nil {
+		return Entry{}, err
+	}
+	want := strings.TrimPrefix(prefix, "sha256:")
+	if want == "" {
+		return Entry{}, fmt.Errorf("empty statement hash")
+	}
+	var found []Entry
+	for _, e := range entries {
+		if strings.HasPrefix(strings.TrimPrefix(e.StatementHash, "sha256:"), want) {
+			found = append(found, e)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Entry{}, fmt.Errorf("no bundle with statement hash %s in %s", prefix, s.root)
+	case 1:
+		return found[0], nil
+	default:
+		return Entry{}, fmt.Errorf("statement hash %s is ambiguous (%d bundles)", prefix, len(found))
+	}
+}
+
+// Query returns the entries matching q, newest first.
+func (s *LocalStore) Query(q Query) ([]Entry, error) {
+	entries, err := s.Entries()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Entry, 0)
+	seenType := map[string]bool{}
+	for _, e := range entries {
+		if !q.matches(e) {
+			continue
+		}
+		if q.Latest {
+			if seenType[e.AttestationType] {
+				continue
+			}
+			seenType[e.AttestationType] = true
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// CopyTo writes the bundles of entries into dir as <hex>.bundle.json and
+// returns the written paths.
+func (s *LocalStore) CopyTo(entries []Entry, dir string) ([]string, error) {
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		raw, err := os.ReadFile(s.Path(e))
+		if err != nil {
+			return nil, fmt.Errorf("read stored bundle %s: %w", e.StatementHash, err)
+		}
+		out := filepath.Join(dir, filepath.Base(s.Path(e)))
+		if err := os.WriteFile(out, raw, 0o644); err != nil {
+			return nil, fmt.Errorf("write bundle: %w", err)
+		}
+		paths = append(paths, out)
+	}
+	return paths, nil
+}
+
+// GC drops index entries whose bundle is missing and bundles that are not
+// indexed. With keepLatest > 0 it also drops all but the newest keepLatest
+// bundles of each attestation type. It returns the removed statement hashes.
+func (s *LocalStore) GC(keepLatest int) ([]string, error) {
+	entries, err := s.Entries()
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]string, 0)
+	kept := make([]Entry, 0, len(entries))
+	perType := map[string]int{}
+	for _, e := range entries {
+		if _, err := os.Stat(s.Path(e)); err != nil {
+			removed = append(removed, e.StatementHash)
+			continue
+		}
+		perType[e.AttestationType]++
+		if keepLatest > 0 && perType[e.AttestationType] > keepLatest {
+			removed = append(removed, e.StatementHash)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	indexed := make(map[string]bool, len(kept))
+	for _, e := range kept {
+		indexed[s.Path(e)] = true
+	}
+	blobs, err := filepath.Glob(filepath.Join(s.root, "blobs", "sha256", "*.bundle.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range blobs {
+		if indexed[b] {
+			continue
+		}
+		if err := os.Remove(b); err != nil {
+			return nil, fmt.Errorf("remove %s: %w", b, err)
+		}
+		h := "sha256:" + strings.TrimSuffix(filepath.Base(b), ".bundle.json")
+		if !containsString(removed, h) {
+			removed = append(removed, h)
+		}
+	}
+	if len(kept) != len(entries) {
+		if err := s.writeIndex(storeIndex{Version: indexVersion, Entries: kept}); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
+
+func (s *LocalStore) indexPath() string { return filepath.Join(s.root, "index.json") }
+
+func (s *LocalStore) readIndex() (storeIndex, error) {
+	raw, err := os.ReadFile(s.indexPath())
+	if os.IsNotExist(err) {
+		return storeIndex{Version: indexVersion, Entries: []Entry{}}, nil
+	}
+	if err != nil {
+		return storeIndex{}, fmt.Errorf("read store index: %w", err)
+	}
+	var idx storeIndex
+	if err := json.Unmarshal(raw, &idx); err != nil {
+		return storeIndex{}, fmt.Errorf("parse store index %s: %w", s.indexPath(), err)
+	}
+	if idx.Version != indexVersion {
+		return storeIndex{}, fmt.Errorf("unsupported store index version %d", idx.Version)
+	}
+	return idx, nil
+}
+
+// writeIndex replaces index.json atomically so readers never see a partial
+// index.
+func (s *LocalStore) writeIndex(idx storeIndex) error {
+	sortEntries(idx.Entries)
+	raw, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.root, 0o755); err != nil {
+		return fmt.Errorf("create store: %w", err)
+	}
+	tmp := s.indexPath() + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write store index: %w", err)
+	}
+	if err := os.Rename(tmp, s.indexPath()); err != nil {
+		return fmt.Errorf("write store index: %w", err)
+	}
+	return nil
+}
+
+// describeBundle builds the index entry for a bundle.
+func describeBundle(raw []byte) (Entry, error) {
+	var bundle sign.Bundle
+	if err := json.Unmarshal(raw, &bundle); err != nil {
+		return Entry{}, fmt.Errorf("parse bundle: %w", err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(bundle.Envelope.Payload)
+	if err != nil {
+		return Entry{}, fmt.Errorf("decode bundle payload: %w", err)
+	}
+	statementHash := hash.DigestBytes(payload)
+	if bundle.Metadata.StatementHash != "" && bundle.Metadata.StatementHash != statementHash {
+		return Entry{}, fmt.Errorf("statement hash mismatch: metadata %s, payload %s", bundle.Metadata.StatementHash, statementHash)
+	}
+	var st struct {
+		StatementID     string `json:"statement_id"`
+		AttestationType string `json:"attestation_type"`
+		GeneratedAt     string `json:"generated_at"`
+		Generator       struct {
+			GitSHA string `json:"git_sha"`
+		} `json:"generator"`
+	}
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return Entry{}, fmt.Errorf("unmarshal bundle payload: %w", err)
+	}
+	if st.AttestationType == "" {
+		return Entry{}, fmt.Errorf("bundle statement has no attestation_type")
+	}
+	entry := Entry{
+		StatementHash:   statementHash,
+		AttestationType: st.AttestationType,
+		StatementID:     st.StatementID,
+		GitSHA:          st.Generator.GitSHA,
+		GeneratedAt:     st.GeneratedAt,
+	}
+	if len(bundle.Envelope.Signatures) > 0 {
+		sig := bundle.Envelope.Signatures[0]
+		entry.ClaimedSigner = sig.OIDCIdentity
+		if entry.ClaimedSigner == "" {
+			entry.ClaimedSigner = sig.KeyID
+		}
+	}
+	return entry, nil
+}
+
+// sortEntries orders entries newest first, breaking ties by statement hash
+// as the upstream pinning in attest does.
+func sortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, entries[i].GeneratedAt)
+		tj, _ := time.Parse(time.RFC3339, entries[j].GeneratedAt)
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return entries[i].StatementHash > entries[j].StatementHash
+	})
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Query selects store entries. Empty fields match everything.
+type Query struct {
+	Type        string
+	StatementID string
+	// GitSHA matches by prefix, so short SHAs work.
+	GitSHA string
+	// ClaimedSigner matches Entry.ClaimedSigner, which is not verified.
+	ClaimedSigner string
+	// Since keeps entries generated at or after this time.
+	Since time.Time
+	// Latest keeps only the newest matching entry of each attestation type.
+	Latest bool
+}
+
+// ParseQuery parses comma-separated key=value terms: type, statement_id,
+// git_sha, claimed_signer, since (RFC 3339) and the bare flag latest, e.g.
+// "type=eval_attestation,git_sha=4f2a9c1,latest".
+func ParseQuery(expr string) (Query, error) {
+	var q Query
+	for _, term := range strings.Split(expr, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(term, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if key == "latest" {
+			if hasValue && value != "true" {
+				return Query{}, fmt.Errorf("query term %q: latest takes no value", term)
+			}
+			q.Latest = true
+			continue
+		}
+		if !hasValue || value == "" {
+			return Query{}, fmt.Errorf("query term %q: expected key=value", term)
+		}
+		switch key {
+		case "type":
+			q.Type = value
+		case "statement_id", "id":
+			q.StatementID = value
+		case "git_sha", "commit":
+			q.GitSHA = value
+		case "claimed_signer":
+			q.ClaimedSigner = value
+		case "since":
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return Query{}, fmt.Errorf("query term %q: %w", term, err)
+			}
+			q.Since = t
+		default:
+			return Query{}, fmt.Errorf("query term %q: unknown key %q", term, key)
+		}
+	}
+	return q, nil
+}
+
+func (q Query) matches(e Entry) bool {
+	if q.Type != "" && e.AttestationType != q.Type {
+		return false
+	}
+	if q.StatementID != "" && e.StatementID != q.StatementID {
+		return false
+	}
+	if q.GitSHA != "" && !strings.HasPrefix(e.GitSHA, q.GitSHA) {
+		return false
+	}
+	if q.ClaimedSigner != "" && e.ClaimedSigner != q.ClaimedSigner {
+		return false
+	}
+	if !q.Since.IsZero() {
+		t, err := time.Parse(time.RFC3339, e.GeneratedAt)
+		if err != nil || t.Before(q.Since) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/pkg/types"
+)
+
+// storeBundle writes a signed-looking bundle and returns its path.
+func storeBundle(t *testing.T, dir, attType, id, gitSHA, generatedAt string) string {
+	t.Helper()
+	st := types.Statement{
+		SchemaVersion:   "1.0.0",
+		StatementID:     id,
+		AttestationType: attType,
+		GeneratedAt:     generatedAt,
+		Generator:       types.Generator{Name: "llmsa", Version: "test", GitSHA: gitSHA},
+	}
+	b, err := sign.CreateBundle(st, sign.SignMaterial{KeyID: "key-1", SigB64: "c2ln", Provider: "pem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, id+".bundle.json")
+	if err := sign.WriteBundle(path, b); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// --- add ---
+
+func TestLocalStoreAdd(t *testing.T) {
+	src := t.TempDir()
+	s := OpenLocalStore(filepath.Join(t.TempDir(), "store"))
+	path := storeBundle(t, src, "eval_attestation", "eval-1", "4f2a9c1e", "2026-10-01T10:00:00Z")
+
+	e, added, err := s.Add(path)
+	if err != nil || !added {
+		t.Fatalf("Add: %v added=%v", err, added)
+	}
+	if e.AttestationType != "eval_attestation" || e.StatementID != "eval-1" || e.GitSHA != "4f2a9c1e" || e.ClaimedSigner != "key-1" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	b, _ := sign.ReadBundle(path)
+	if e.StatementHash != b.Metadata.StatementHash {
+		t.Fatalf("entry keyed by %s, bundle statement hash %s", e.StatementHash, b.Metadata.StatementHash)
+	}
+	if _, err := os.Stat(s.Path(e)); err != nil {
+		t.Fatalf("blob missing: %v", err)
+	}
+
+	if _, added, err := s.Add(path); err != nil || added {
+		t.Fatalf("re-adding should be a no-op: %v added=%v", err, added)
+	}
+	entries, _ := s.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(entries))
+	}
+}
+
+func TestLocalStoreAddRejectsTamperedBundle(t *testing.T) {
+	path := storeBundle(t, t.TempDir(), "eval_attestation", "eval-1", "abc", "2026-10-01T10:00:00Z")
+	b, _ := sign.ReadBundle(path)
+	b.Metadata.StatementHash = "sha256:" + strings.Repeat("0", 64)
+	sign.WriteBundle(path, b)
+
+	if _, _, err := OpenLocalStore(t.TempDir()).Add(path); err == nil || !strings.Contains(err.Error(), "statement hash mismatch") {
+		t.Fatalf("expected hash mismatch, got %v", err)
+	}
+}
+
+// --- query ---
+
+func populatedStore(t *testing.T) *LocalStore {
+	t.Helper()
+	src := t.TempDir()
+	s := OpenLocalStore(t.TempDir())
+	for _, b := range []struct{ typ, id, sha, at string }{
+		{"eval_attestation", "eval-old", "4f2a9c1e", "2026-10-01T10:00:00Z"},
+		{"eval_attestation", "eval-new", "4f2a9c1e", "2026-10-02T10:00:00Z"},
+		{"eval_attestation", "eval-other", "99887766", "2026-10-03T10:00:00Z"},
+		{"prompt_attestation", "prompt-1", "4f2a9c1e", "2026-10-01T09:00:00Z"},
+	} {
+		if _, _, err := s.Add(storeBundle(t, src, b.typ, b.id, b.sha, b.at)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func ids(entries []Entry) string {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.StatementID)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestLocalStoreQuery(t *testing.T) {
+	s := populatedStore(t)
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "eval-other,eval-new,eval-old,prompt-1"},
+		{"type=eval_attestation,git_sha=4f2a", "eval-new,eval-old"},
+		{"type=eval_attestation,commit=4f2a9c1e,latest", "eval-new"},
+		{"git_sha=4f2a9c1e,latest", "eval-new,prompt-1"},
+		{"id=prompt-1", "prompt-1"},
+		{"since=2026-10-02T00:00:00Z", "eval-other,eval-new"},
+		{"claimed_signer=someone-else", ""},
+		{"type=prompt_attestation,claimed_signer=key-1", "prompt-1"},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", tt.expr, err)
+		}
+		got, err := s.Query(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids(got) != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.expr, ids(got), tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, expr := range []string{"type", "colour=red", "since=yesterday", "latest=no"} {
+		if _, err := ParseQuery(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestLocalStoreResolveAndCopy(t *testing.T) {
+	s := populatedStore(t)
+	entries, _ := s.Entries()
+	want := entries[0]
+
+	got, err := s.Resolve(strings.TrimPrefix(want.StatementHash, "sha256:")[:12])
+	if err != nil || got.StatementHash != want.StatementHash {
+		t.Fatalf("Resolve short hash: %+v %v", got, err)
+	}
+	if _, err := s.Resolve("sha256:"); err == nil {
+		t.Fatal("expected error for empty hash")
+	}
+	if _, err := s.Resolve("ffffffffffff"); err == nil || !strings.Contains(err.Error(), "no bundle") {
+		t.Fatalf("expected missing hash error, got %v", err)
+	}
+
+	out := t.TempDir()
+	paths, err := s.CopyTo([]Entry{want}, out)
+	if err != nil || len(paths) != 1 || !strings.HasSuffix(paths[0], ".bundle.json") {
+		t.Fatalf("CopyTo: %v %v", paths, err)
+	}
+}
+
+// --- gc ---
+
+func TestLocalStoreGC(t *testing.T) {
+	s := populatedStore(t)
+	orphan := filepath.Join(s.Root(), "blobs", "sha256", strings.Repeat("a", 64)+".bundle.json")
+	os.WriteFile(orphan, []byte("{}"), 0o644)
+
+	removed, err := s.GC(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != "sha256:"+strings.Repeat("a", 64) {
+		t.Fatalf("expected only the orphan removed, got %v", removed)
+	}
+
+	removed, err = s.GC(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Fatalf("expected two older eval bundles removed, got %v", removed)
+	}
+	entries, _ := s.Entries()
+	if ids(entries) != "eval-other,prompt-1" {
+		t.Fatalf("unexpected survivors: %s", ids(entries))
+	}
+
+	os.Remove(s.Path(entries[0]))
+	if removed, _ := s.GC(0); len(removed) != 1 || removed[0] != entries[0].StatementHash {
+		t.Fatalf("expected dangling entry dropped, got %v", removed)
+	}
+}
+
+func TestSortEntriesTieBreak(t *testing.T) {
+	at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	entries := []Entry{{StatementHash: "sha256:aa", GeneratedAt: at}, {StatementHash: "sha256:bb", GeneratedAt: at}}
+	sortEntries(entries)
+	if entries[0].StatementHash != "sha256:bb" {
+		t.Fatalf("expected higher hash first on equal timestamps, got %v", entries)
+	}
+}
@@ -0,0 +1,436 @@
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Media types of the policy and trust root layers carried next to bundles in
+// an exported attestation set.
+const (
+	policyMediaType    = types.MediaType("application/vnd.llmsa.policy.v1")
+	trustRootMediaType = types.MediaType("application/vnd.llmsa.trust-root.v1")
+)
+
+// AnnotationRefName tags an attestation set in an OCI image layout index.
+const AnnotationRefName = "org.opencontainers.image.ref.name"
+
+// DefaultLayoutTag names an exported attestation set when no tag is given.
+const DefaultLayoutTag = "latest"
+
+// LayoutContents lists the files of an attestation set moved through an OCI
+// image layout: signed bundles plus the policies and trust roots needed to
+// check them offline. File names are kept, so they must be unique per kind.
+type LayoutContents struct {
+	Bundles    []string
+	Policies   []string
+	TrustRoots []string
+}
+
+// ExportOCILayout writes contents as one artifact tagged tag into the OCI
+// image layout at path, replacing any set with the same tag, and returns the
+// artifact digest. A path ending in .tar is written as a tarball; anything
+// else is a layout directory. A policy's signature (<policy>.bundle.json) is
+// exported with it when present.
+func ExportOCILayout(path, tag string, contents LayoutContents) (string, error) {
+	if tag == "" {
+		tag = DefaultLayoutTag
+	}
+	if len(contents.Bundles) == 0 {
+		return "", fmt.Errorf("export requires at least one bundle")
+	}
+	policies := make([]string, 0, len(contents.Policies))
+	for _, p := range contents.Policies {
+		policies = append(policies, p)
+		if _, err := os.Stat(p + ".bundle.json"); err == nil {
+			policies = append(policies, p+".bundle.json")
+		}
+	}
+
+	var layers []mutate.Addendum
+	for _, group := range []struct {
+		kind  string
+		mt    types.MediaType
+		paths []string
+	}{
+		{"bundle", bundleMediaType, contents.Bundles},
+		{"policy", policyMediaType, policies},
+		{"trust root", trustRootMediaType, contents.TrustRoots},
+	} {
+		seen := map[string]bool{}
+		for _, p := range group.paths {
+			title := filepath.Base(p)
+			if seen[title] {
+				return "", fmt.Errorf("duplicate %s file name %s", group.kind, title)
+			}
+			seen[title] = true
+			raw, err := os.ReadFile(p)
+			if err != nil {
+				return "", fmt.Errorf("read %s: %w", group.kind, err)
+			}
+			if group.mt == bundleMediaType {
+				layers = append(layers, bundleLayer(raw, title))
+				continue
+			}
+			layers = append(layers, mutate.Addendum{
+				Layer:       static.NewLayer(raw, group.mt),
+				Annotations: map[string]string{AnnotationTitle: title},
+			})
+		}
+	}
+	img, err := bundleImage(layers, nil)
+	if err != nil {
+		return "", err
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return "", fmt.Errorf("digest oci artifact: %w", err)
+	}
+
+	dir := path
+	if isLayoutTar(path) {
+		tmp, err := os.MkdirTemp("", "llmsa-layout-")
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(tmp)
+		if _, err := os.Stat(path); err == nil {
+			if err := extractLayoutTar(path, tmp); err != nil {
+				return "", err
+			}
+		}
+		dir = tmp
+	}
+	lp, err := openOrCreateLayout(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := lp.ReplaceImage(img, match.Annotation(AnnotationRefName, tag), layout.WithAnnotations(map[string]string{AnnotationRefName: tag})); err != nil {
+		return "", fmt.Errorf("write oci layout: %w", err)
+	}
+	unused, err := lp.GarbageCollect()
+	if err != nil {
+		return "", fmt.Errorf("write oci layout: %w", err)
+	}
+	for _, h := range unused {
+		if err := lp.RemoveBlob(h); err != nil {
+			return "", fmt.Errorf("write oci layout: %w", err)
+		}
+	}
+	if dir != path {
+		if err := writeLayoutTar(dir, path); err != nil {
+			return "", err
+		}
+	}
+	return digest.String(), nil
+}
+
+// ImportOCILayout unpacks the attestation set tagged tag from the layout at
+// path into outDir/attestations, outDir/policies and outDir/trust, and returns
+// the files written. An empty tag selects the only set in the layout. Every
+// blob is checked against its digest.
+func ImportOCILayout(path, tag, outDir string) (LayoutContents, error) {
+	var out LayoutContents
+	err := readLayout(path, tag, func(mt types.MediaType, title string, raw []byte) error {
+		var sub string
+		var dst *[]string
+		switch mt {
+		case bundleMediaType:
+			sub, dst = "attestations", &out.Bundles
+		case policyMediaType:
+			sub, dst = "policies", &out.Policies
+		case trustRootMediaType:
+			sub, dst = "trust", &out.TrustRoots
+		default:
+			return nil
+		}
+		p, err := writeLayoutFile(filepath.Join(outDir, sub), title, raw)
+		if err != nil {
+			return err
+		}
+		*dst = append(*dst, p)
+		return nil
+	})
+	return out, err
+}
+
+// PullOCILayout writes only the bundles of the set tagged tag to outDir, for
+// verification straight from a layout.
+func PullOCILayout(path, tag, outDir string) ([]string, error) {
+	var paths []string
+	err := readLayout(path, tag, func(mt types.MediaType, title string, raw []byte) error {
+		if mt != bundleMediaType {
+			return nil
+		}
+		p, err := writeLayoutFile(outDir, title, raw)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	if err == nil && len(paths) == 0 {
+		err = fmt.Errorf("no bundles in oci layout %s", path)
+	}
+	return paths, err
+}
+
+func readLayout(path, tag string, fn func(mt types.MediaType, title string, raw []byte) error) error {
+	dir, cleanup, err := openLayoutDir(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	descs, err := layoutSets(dir)
+	if err != nil {
+		return err
+	}
+
+	var desc *v1.Descriptor
+	tags := make([]string, 0, len(descs))
+	for i, d := range descs {
+		t := d.Annotations[AnnotationRefName]
+		tags = append(tags, t)
+		if t == tag || (tag == "" && len(descs) == 1) {
+			desc = &descs[i]
+		}
+	}
+	switch {
+	case desc != nil:
+	case len(descs) == 0:
+		return fmt.Errorf("no llmsa attestation sets in oci layout %s", path)
+	case tag == "":
+		sort.Strings(tags)
+		return fmt.Errorf("oci layout %s holds several attestation sets (%s); select one by tag", path, strings.Join(tags, ", "))
+	default:
+		return fmt.Errorf("no attestation set tagged %q in oci layout %s", tag, path)
+	}
+
+	img, err := dir.Image(desc.Digest)
+	if err != nil {
+		return fmt.Errorf("read oci layout: %w", err)
+	}
+	rawManifest, err := img.RawManifest()
+	if err != nil {
+		return fmt.Errorf("read oci layout: %w", err)
+	}
+	if err := checkDigest(rawManifest, desc.Digest); err != nil {
+		return fmt.Errorf("manifest %s: %w", desc.Digest, err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("read oci layout: %w", err)
+	}
+	for _, l := range manifest.Layers {
+		raw, err := os.ReadFile(filepath.Join(string(dir), "blobs", l.Digest.Algorithm, l.Digest.Hex))
+		if err != nil {
+			return fmt.Errorf("read oci layout: %w", err)
+		}
+		title := filepath.Base(l.Annotations[AnnotationTitle])
+		if err := checkDigest(raw, l.Digest); err != nil {
+			return fmt.Errorf("layer %s: %w", title, err)
+		}
+		if err := fn(l.MediaType, title, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// layoutSets returns the index entries of llmsa artifacts.
+func layoutSets(dir layout.Path) ([]v1.Descriptor, error) {
+	idx, err := dir.ImageIndex()
+	if err != nil {
+		return nil, fmt.Errorf("read oci layout: %w", err)
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("read oci layout: %w", err)
+	}
+	var out []v1.Descriptor
+	for _, d := range manifest.Manifests {
+		if d.ArtifactType == ArtifactType {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func checkDigest(raw []byte, want v1.Hash) error {
+	if want.Algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest algorithm %s", want.Algorithm)
+	}
+	sum := sha256.Sum256(raw)
+	if got := hex.EncodeToString(sum[:]); got != want.Hex {
+		return fmt.Errorf("digest mismatch: got sha256:%s", got)
+	}
+	return nil
+}
+
+func writeLayoutFile(dir, title string, raw []byte) (string, error) {
+	if title == "" || title == "." || title == string(filepath.Separator) {
+		return "", fmt.Errorf("oci layout layer has no title")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	p := filepath.Join(dir, title)
+	if err := os.WriteFile(p, raw, 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", p, err)
+	}
+	return p, nil
+}
+
+func isLayoutTar(path string) bool {
+	return strings.HasSuffix(path, ".tar")
+}
+
+func openOrCreateLayout(dir string) (layout.Path, error) {
+	if _, err := os.Stat(filepath.Join(dir, "index.json")); err == nil {
+		return layout.FromPath(dir)
+	}
+	lp, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return "", fmt.Errorf("create oci layout: %w", err)
+	}
+	return lp, nil
+}
+
+// openLayoutDir opens a layout directory, or extracts a layout tarball to a
+// temporary directory removed by the returned cleanup.
+func openLayoutDir(path string) (layout.Path, func(), error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("read oci layout: %w", err)
+	}
+	if fi.IsDir() {
+		lp, err := layout.FromPath(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("read oci layout: %w", err)
+		}
+		return lp, func() {}, nil
+	}
+	tmp, err := os.MkdirTemp("", "llmsa-layout-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(tmp) }
+	if err := extractLayoutTar(path, tmp); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	lp, err := layout.FromPath(tmp)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("read oci layout: %w", err)
+	}
+	return lp, cleanup, nil
+}
+
+// writeLayoutTar archives a layout directory with fixed timestamps so the
+// same set always produces the same tarball.
+func writeLayoutTar(dir, tarPath string) error {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Name: filepath.ToSlash(rel), ModTime: time.Unix(0, 0), Format: tar.FormatPAX}
+		if d.IsDir() {
+			hdr.Typeflag, hdr.Name, hdr.Mode = tar.TypeDir, hdr.Name+"/", 0o755
+			return tw.WriteHeader(hdr)
+		}
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		hdr.Typeflag, hdr.Mode, hdr.Size = tar.TypeReg, 0o644, int64(len(raw))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(raw)
+		return err
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("write oci layout tarball: %w", err)
+	}
+	tmp := tarPath + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write oci layout tarball: %w", err)
+	}
+	return os.Rename(tmp, tarPath)
+}
+
+// extractLayoutTar unpacks regular files and directories, refusing entries
+// that would land outside dir.
+func extractLayoutTar(tarPath, dir string) error {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return fmt.Errorf("read oci layout: %w", err)
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read oci layout tarball %s: %w", tarPath, err)
+		}
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("read oci layout tarball %s: entry %q escapes the layout", tarPath, hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			if cerr := out.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return fmt.Errorf("read oci layout tarball %s: %w", tarPath, err)
+			}
+		default:
+			return fmt.Errorf("read oci layout tarball %s: unsupported entry %q", tarPath, hdr.Name)
+		}
+	}
+}
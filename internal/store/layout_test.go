@@ -0,0 +1,154 @@
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func layoutFixture(t *testing.T) LayoutContents {
+	t.Helper()
+	dir := t.TempDir()
+	policy := filepath.Join(dir, "gates.yaml")
+	os.WriteFile(policy, []byte("version: 1\n"), 0o644)
+	os.WriteFile(policy+".bundle.json", []byte(`{"policy":"sig"}`), 0o644)
+	trust := filepath.Join(dir, "signer.pub")
+	os.WriteFile(trust, []byte("-----BEGIN PUBLIC KEY-----\n"), 0o644)
+	return LayoutContents{
+		Bundles:    []string{writeBundle(t, dir, 1), writeBundle(t, dir, 2)},
+		Policies:   []string{policy},
+		TrustRoots: []string{trust},
+	}
+}
+
+// --- export / import ---
+
+func TestOCILayoutRoundTrip(t *testing.T) {
+	contents := layoutFixture(t)
+	tarPath := filepath.Join(t.TempDir(), "set.tar")
+	digest, err := ExportOCILayout(tarPath, "", contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(digest, "sha256:") {
+		t.Fatalf("unexpected digest %q", digest)
+	}
+
+	out := t.TempDir()
+	got, err := ImportOCILayout(tarPath, "", out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		got, want []string
+		sub       string
+	}{
+		{got.Bundles, contents.Bundles, "attestations"},
+		{got.Policies, append(contents.Policies, contents.Policies[0]+".bundle.json"), "policies"},
+		{got.TrustRoots, contents.TrustRoots, "trust"},
+	} {
+		if len(tc.got) != len(tc.want) {
+			t.Fatalf("%s: got %v, want %d files", tc.sub, tc.got, len(tc.want))
+		}
+		for i, p := range tc.got {
+			if p != filepath.Join(out, tc.sub, filepath.Base(tc.want[i])) {
+				t.Errorf("%s: unexpected path %s", tc.sub, p)
+			}
+			a, _ := os.ReadFile(p)
+			b, _ := os.ReadFile(tc.want[i])
+			if !bytes.Equal(a, b) {
+				t.Errorf("%s: %s differs from the exported file", tc.sub, p)
+			}
+		}
+	}
+
+	bundles, err := PullOCILayout(tarPath, DefaultLayoutTag, t.TempDir())
+	if err != nil || len(bundles) != 2 {
+		t.Fatalf("PullOCILayout: %v %v", bundles, err)
+	}
+}
+
+func TestOCILayoutTags(t *testing.T) {
+	contents := layoutFixture(t)
+	dir := filepath.Join(t.TempDir(), "layout")
+	if _, err := ExportOCILayout(dir, "app-v1", contents); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExportOCILayout(dir, "app-v2", LayoutContents{Bundles: contents.Bundles[:1]}); err != nil {
+		t.Fatal(err)
+	}
+	// Re-exporting a tag replaces the set instead of adding another.
+	if _, err := ExportOCILayout(dir, "app-v2", LayoutContents{Bundles: contents.Bundles}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := PullOCILayout(dir, "", t.TempDir()); err == nil || !strings.Contains(err.Error(), "app-v1, app-v2") {
+		t.Fatalf("expected ambiguous set error, got %v", err)
+	}
+	if _, err := PullOCILayout(dir, "app-v3", t.TempDir()); err == nil || !strings.Contains(err.Error(), `no attestation set tagged "app-v3"`) {
+		t.Fatalf("expected missing tag error, got %v", err)
+	}
+	paths, err := PullOCILayout(dir, "app-v2", t.TempDir())
+	if err != nil || len(paths) != 2 {
+		t.Fatalf("expected the replaced set with two bundles, got %v %v", paths, err)
+	}
+}
+
+func TestOCILayoutTarballIsReproducible(t *testing.T) {
+	contents := layoutFixture(t)
+	a := filepath.Join(t.TempDir(), "a.tar")
+	b := filepath.Join(t.TempDir(), "b.tar")
+	if _, err := ExportOCILayout(a, "v1", contents); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExportOCILayout(b, "v1", contents); err != nil {
+		t.Fatal(err)
+	}
+	rawA, _ := os.ReadFile(a)
+	rawB, _ := os.ReadFile(b)
+	if !bytes.Equal(rawA, rawB) {
+		t.Fatal("exporting the same set twice produced different tarballs")
+	}
+}
+
+// --- integrity ---
+
+func TestOCILayoutRejectsTamperedBlob(t *testing.T) {
+	contents := layoutFixture(t)
+	dir := filepath.Join(t.TempDir(), "layout")
+	if _, err := ExportOCILayout(dir, "", contents); err != nil {
+		t.Fatal(err)
+	}
+	blobs, _ := filepath.Glob(filepath.Join(dir, "blobs", "sha256", "*"))
+	for _, b := range blobs {
+		if raw, _ := os.ReadFile(b); bytes.Equal(raw, []byte(`{"id":1}`)) {
+			os.WriteFile(b, []byte(`{"id":9}`), 0o644)
+		}
+	}
+	if _, err := ImportOCILayout(dir, "", t.TempDir()); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected digest mismatch, got %v", err)
+	}
+}
+
+func TestOCILayoutRejectsEscapingTarEntry(t *testing.T) {
+	tarPath := filepath.Join(t.TempDir(), "evil.tar")
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+	tw.Write([]byte("x"))
+	tw.Close()
+	os.WriteFile(tarPath, buf.Bytes(), 0o644)
+
+	if _, err := ImportOCILayout(tarPath, "", t.TempDir()); err == nil || !strings.Contains(err.Error(), "escapes the layout") {
+		t.Fatalf("expected escaping entry error, got %v", err)
+	}
+}
+
+func TestExportOCILayoutRequiresBundles(t *testing.T) {
+	if _, err := ExportOCILayout(filepath.Join(t.TempDir(), "x.tar"), "", LayoutContents{}); err == nil {
+		t.Fatal("expected error without bundles")
+	}
+}
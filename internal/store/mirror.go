@@ -0,0 +1,279 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// MirrorOptions controls Mirror.
+type MirrorOptions struct {
+	// Registry holds credentials, TLS and retry settings for both sides.
+	Registry RegistryOptions
+	// Verify is called with a directory holding the bundles of each group
+	// (one artifact, or every bundle attached to an image) before anything
+	// is copied. An error aborts the mirror.
+	Verify func(dir string) error
+}
+
+// MirrorResult maps a source artifact to its copy. Both are digest-pinned
+// and share the digest.
+type MirrorResult struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Tag         string `json:"tag,omitempty"`
+}
+
+type mirrorItem struct {
+	src v1.Hash
+	ref string
+	img v1.Image
+	dst name.Reference
+	tag string
+	// image is the digest of the image a referrer is attached to, which its
+	// statements must list as a subject.
+	image string
+}
+
+// Mirror copies llmsa attestations from one registry location to another by
+// digest. from may be
+//   - an artifact ref (tag or digest), copied to the ref or repository to,
+//     keeping the source tag when to is a repository;
+//   - a repository, whose tagged llmsa artifacts are all copied under the
+//     same tags to the repository to;
+//   - an image ref, whose attached bundles (referrers) are copied to the
+//     repository to, where they refer to the same image digest.
+//
+// Every group is pulled and verified before the first copy is made.
+func Mirror(from, to string, opts MirrorOptions) ([]MirrorResult, error) {
+	groups, err := mirrorGroups(from, to, opts.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		if err := verifyMirrorGroup(group, opts.Verify); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []MirrorResult
+	for _, group := range groups {
+		for _, it := range group {
+			remoteOpts, err := opts.Registry.remoteOptions(it.dst.Context())
+			if err != nil {
+				return results, err
+			}
+			if err := remote.Write(it.dst, it.img, remoteOpts...); err != nil {
+				return results, fmt.Errorf("copy %s to %s: %w", it.ref, it.dst, err)
+			}
+			pinned := it.dst.Context().Digest(it.src.String())
+			desc, err := remote.Head(pinned, remoteOpts...)
+			if err != nil {
+				return results, fmt.Errorf("check copy of %s: %w", it.ref, err)
+			}
+			if desc.Digest != it.src {
+				return results, fmt.Errorf("copy of %s has digest %s", it.ref, desc.Digest)
+			}
+			results = append(results, MirrorResult{Source: it.ref, Destination: pinned.String(), Tag: it.tag})
+		}
+	}
+	return results, nil
+}
+
+// mirrorGroups resolves from into groups of artifacts verified together.
+func mirrorGroups(from, to string, reg RegistryOptions) ([][]mirrorItem, error) {
+	if isRepositoryRef(from) {
+		if !isRepositoryRef(to) {
+			return nil, fmt.Errorf("--to must be a repository when --from is a repository")
+		}
+		srcRepo, err := reg.parseRepository(from)
+		if err != nil {
+			return nil, fmt.Errorf("parse source: %w", err)
+		}
+		dstRepo, err := reg.parseRepository(to)
+		if err != nil {
+			return nil, fmt.Errorf("parse destination: %w", err)
+		}
+		remoteOpts, err := reg.remoteOptions(srcRepo)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := remote.List(srcRepo, remoteOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("list tags of %s: %w", srcRepo, err)
+		}
+		sort.Strings(tags)
+		var groups [][]mirrorItem
+		for _, tag := range tags {
+			it, ok, err := mirrorArtifact(srcRepo.Tag(tag), reg)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			it.dst, it.tag = dstRepo.Tag(tag), tag
+			groups = append(groups, []mirrorItem{it})
+		}
+		if len(groups) == 0 {
+			return nil, fmt.Errorf("no llmsa attestations in %s", srcRepo)
+		}
+		return groups, nil
+	}
+
+	src, err := reg.parse(from)
+	if err != nil {
+		return nil, fmt.Errorf("parse source: %w", err)
+	}
+	it, ok, err := mirrorArtifact(src, reg)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if isRepositoryRef(to) {
+			dstRepo, err := reg.parseRepository(to)
+			if err != nil {
+				return nil, fmt.Errorf("parse destination: %w", err)
+			}
+			it.dst = dstRepo.Digest(it.src.String())
+			if tag, isTag := src.(name.Tag); isTag {
+				it.dst, it.tag = dstRepo.Tag(tag.TagStr()), tag.TagStr()
+			}
+		} else {
+			if it.dst, err = reg.parse(to); err != nil {
+				return nil, fmt.Errorf("parse destination: %w", err)
+			}
+			if d, isDigest := it.dst.(name.Digest); isDigest && d.DigestStr() != it.src.String() {
+				return nil, fmt.Errorf("destination digest %s does not match source digest %s", d.DigestStr(), it.src)
+			}
+			if tag, isTag := it.dst.(name.Tag); isTag {
+				it.tag = tag.TagStr()
+			}
+		}
+		return [][]mirrorItem{{it}}, nil
+	}
+
+	// Not an llmsa artifact: mirror the bundles attached to the image.
+	if !isRepositoryRef(to) {
+		return nil, fmt.Errorf("--to must be a repository when mirroring the attestations of image %s", from)
+	}
+	dstRepo, err := reg.parseRepository(to)
+	if err != nil {
+		return nil, fmt.Errorf("parse destination: %w", err)
+	}
+	image, refs, err := discoverReferrers(from, reg)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no llmsa attestations attached to %s", from)
+	}
+	group := make([]mirrorItem, 0, len(refs))
+	for _, r := range refs {
+		ref, err := reg.parse(r)
+		if err != nil {
+			return nil, err
+		}
+		it, _, err := mirrorArtifact(ref, reg)
+		if err != nil {
+			return nil, err
+		}
+		it.dst = dstRepo.Digest(it.src.String())
+		it.image = image.DigestStr()
+		group = append(group, it)
+	}
+	return [][]mirrorItem{group}, nil
+}
+
+// mirrorArtifact fetches ref, reporting whether it is an llmsa artifact.
+func mirrorArtifact(ref name.Reference, reg RegistryOptions) (mirrorItem, bool, error) {
+	remoteOpts, err := reg.remoteOptions(ref.Context())
+	if err != nil {
+		return mirrorItem{}, false, err
+	}
+	desc, err := remote.Get(ref, remoteOpts...)
+	if err != nil {
+		return mirrorItem{}, false, fmt.Errorf("fetch %s: %w", ref, err)
+	}
+	if !isBundleManifest(desc.Manifest) {
+		return mirrorItem{}, false, nil
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return mirrorItem{}, false, fmt.Errorf("read %s: %w", ref, err)
+	}
+	pinned := ref.Context().Digest(desc.Digest.String()).String()
+	return mirrorItem{src: desc.Digest, ref: pinned, img: img}, true, nil
+}
+
+func isBundleManifest(raw []byte) bool {
+	var m struct {
+		ArtifactType string `json:"artifactType"`
+		Config       struct {
+			MediaType string `json:"mediaType"`
+		} `json:"config"`
+	}
+	if json.Unmarshal(raw, &m) != nil {
+		return false
+	}
+	return m.ArtifactType == ArtifactType || m.Config.MediaType == ArtifactType
+}
+
+func verifyMirrorGroup(group []mirrorItem, verifyFn func(string) error) error {
+	if verifyFn == nil {
+		return nil
+	}
+	dir, err := os.MkdirTemp("", "llmsa-mirror-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	refs := make([]string, 0, len(group))
+	for _, it := range group {
+		out := filepath.Join(dir, it.src.Hex[:16]+".bundle.json")
+		written, err := writeLayers(it.img, out)
+		if err != nil {
+			return fmt.Errorf("pull %s: %w", it.ref, err)
+		}
+		for _, p := range written {
+			if it.image == "" {
+				break
+			}
+			if err := RequireImageSubject(p, it.image); err != nil {
+				return fmt.Errorf("referrer %s: %w", it.ref, err)
+			}
+		}
+		refs = append(refs, it.ref)
+	}
+	if err := verifyFn(dir); err != nil {
+		return fmt.Errorf("verify %s: %w", strings.Join(refs, ", "), err)
+	}
+	return nil
+}
+
+// isRepositoryRef reports whether ref names a repository, with neither a tag
+// nor a digest.
+func isRepositoryRef(ref string) bool {
+	if strings.Contains(ref, "@") {
+		return false
+	}
+	return !strings.Contains(ref[strings.LastIndex(ref, "/")+1:], ":")
+}
+
+// parseRepository parses a repository against the default registry, marking
+// insecure hosts.
+func (o RegistryOptions) parseRepository(repo string) (name.Repository, error) {
+	ref, err := o.parse(repo)
+	if err != nil {
+		return name.Repository{}, err
+	}
+	return ref.Context(), nil
+}
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func digestOf(pinned string) string {
+	return pinned[strings.LastIndex(pinned, "@")+1:]
+}
+
+// --- artifacts and repositories ---
+
+func TestMirrorArtifactAndRepository(t *testing.T) {
+	src, dst := startRegistry(t), startRegistry(t)
+	dir := t.TempDir()
+	v1Pinned, err := PublishOCI(writeBundle(t, dir, 1), src+"/org/attestations:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PublishOCI(writeBundle(t, dir, 2), src+"/org/attestations:v2"); err != nil {
+		t.Fatal(err)
+	}
+	img, _ := random.Image(64, 1)
+	other, _ := name.ParseReference(src + "/org/attestations:not-an-attestation")
+	if err := remote.Write(other, img); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Mirror(src+"/org/attestations:v1", dst+"/org/attestations", MirrorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MirrorResult{Source: v1Pinned, Destination: dst + "/org/attestations@" + digestOf(v1Pinned), Tag: "v1"}
+	if len(results) != 1 || results[0] != want {
+		t.Fatalf("got %+v, want %+v", results, want)
+	}
+	out := filepath.Join(t.TempDir(), "b.bundle.json")
+	if err := PullOCI(dst+"/org/attestations:v1", out); err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := os.ReadFile(out); string(raw) != `{"id":1}` {
+		t.Fatalf("unexpected mirrored bundle: %s", raw)
+	}
+
+	var verified int
+	results, err = Mirror(src+"/org/attestations", dst+"/mirror/attestations", MirrorOptions{
+		Verify: func(string) error { verified++; return nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Tag != "v1" || results[1].Tag != "v2" || verified != 2 {
+		t.Fatalf("expected v1 and v2 mirrored and verified, got %+v (verified %d)", results, verified)
+	}
+
+	if _, err := Mirror(src+"/org/attestations", dst+"/org/attestations:v1", MirrorOptions{}); err == nil || !strings.Contains(err.Error(), "must be a repository") {
+		t.Fatalf("expected repository error, got %v", err)
+	}
+	if _, err := Mirror(v1Pinned, dst+"/org/attestations@sha256:"+strings.Repeat("0", 64), MirrorOptions{}); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected digest mismatch error, got %v", err)
+	}
+}
+
+// --- referrers ---
+
+func TestMirrorReferrers(t *testing.T) {
+	src := startRegistry(t, registry.WithReferrersSupport(true))
+	dst := startRegistry(t)
+	tag, pinned := pushImage(t, src)
+	dir := t.TempDir()
+	for i := 1; i <= 2; i++ {
+		if _, err := PublishOCIWithOptions(writeImageBundle(t, dir, i, pinned), "", PublishOptions{Subject: pinned}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Promote the image itself, as a registry copy would.
+	img, err := remote.Image(mustParse(t, tag))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(mustParse(t, dst+"/app/model-server:v1"), img); err != nil {
+		t.Fatal(err)
+	}
+
+	var bundles int
+	results, err := Mirror(tag, dst+"/app/model-server", MirrorOptions{Verify: func(dir string) error {
+		entries, _ := os.ReadDir(dir)
+		bundles = len(entries)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || bundles != 2 {
+		t.Fatalf("expected both bundles verified together and mirrored, got %+v (%d bundles)", results, bundles)
+	}
+
+	// The destination has no referrers API, so the copies are found through
+	// the referrers tag schema.
+	mirrored, err := DiscoverReferrers(dst+"/app/model-server@"+digestOf(pinned), RegistryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mirrored) != 2 {
+		t.Fatalf("expected two referrers at the destination, got %v", mirrored)
+	}
+	for i, r := range results {
+		if digestOf(r.Source) != digestOf(r.Destination) || r.Destination != mirrored[i] {
+			t.Fatalf("digest not preserved: %+v vs %s", r, mirrored[i])
+		}
+	}
+}
+
+func TestMirrorVerifyFailureCopiesNothing(t *testing.T) {
+	src, dst := startRegistry(t), startRegistry(t)
+	if _, err := PublishOCI(writeBundle(t, t.TempDir(), 1), src+"/org/attestations:v1"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Mirror(src+"/org/attestations", dst+"/org/attestations", MirrorOptions{
+		Verify: func(string) error { return errors.New("signature invalid") },
+	})
+	if err == nil || !strings.Contains(err.Error(), "signature invalid") {
+		t.Fatalf("expected verification error, got %v", err)
+	}
+	repo, _ := name.NewRepository(dst + "/org/attestations")
+	if tags, err := remote.List(repo); err == nil && len(tags) > 0 {
+		t.Fatalf("expected nothing copied, found tags %v", tags)
+	}
+}
+
+func mustParse(t *testing.T, ref string) name.Reference {
+	t.Helper()
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
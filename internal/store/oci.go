@@ -1,67 +1,261 @@
 package store
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
-	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/static"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
 )
 
 const bundleMediaType = types.MediaType("application/vnd.llmsa.bundle.v1+json")
 
+// ArtifactType marks llmsa bundle manifests. It is set as the manifest
+// artifactType and config media type so registries and the referrers tag
+// schema both report it.
+const ArtifactType = string(bundleMediaType)
+
+// Layer annotations describing each bundle, so a set can be inspected
+// without downloading it. The title is the bundle's file name.
+const (
+	AnnotationTitle           = "org.opencontainers.image.title"
+	AnnotationAttestationType = "dev.llmsa.attestation.type"
+	AnnotationStatementID     = "dev.llmsa.statement.id"
+)
+
+// emptyConfig is the OCI 1.1 empty config blob used by artifact manifests.
+var emptyConfig = []byte("{}")
+
+// PublishOptions controls how a bundle is pushed.
+type PublishOptions struct {
+	// Subject attaches the bundle to an image through the OCI 1.1 referrers
+	// API. Tags are resolved to the image digest.
+	Subject string
+	// Registry holds credentials, TLS and retry settings.
+	Registry RegistryOptions
+}
+
+// PublishOCI pushes a bundle as an OCI artifact at ociRef and returns the
+// digest-pinned reference. When inPath is a directory every *.bundle.json in
+// it is pushed as one layer of a single artifact.
 func PublishOCI(inPath string, ociRef string) (string, error) {
-	raw, err := os.ReadFile(inPath)
+	return PublishOCIWithOptions(inPath, ociRef, PublishOptions{})
+}
+
+// PublishOCIWithOptions pushes a bundle like PublishOCI. With a Subject the
+// artifact is pushed to the subject's repository and ociRef may be empty;
+// registries without the referrers API get the referrers tag schema
+// (sha256-<digest>) updated instead.
+func PublishOCIWithOptions(inPath, ociRef string, opts PublishOptions) (string, error) {
+	paths, err := bundleFiles(inPath)
 	if err != nil {
-		return "", fmt.Errorf("read bundle: %w", err)
+		return "", err
 	}
-	ref, err := name.ParseReference(ociRef, name.WithDefaultRegistry("ghcr.io"))
-	if err != nil {
-		return "", fmt.Errorf("parse oci ref: %w", err)
+	layers := make([]mutate.Addendum, 0, len(paths))
+	for _, p := range paths {
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			return "", fmt.Errorf("read bundle: %w", err)
+		}
+		layers = append(layers, bundleLayer(raw, filepath.Base(p)))
+	}
+
+	var subject *v1.Descriptor
+	var repo name.Repository
+	if opts.Subject != "" {
+		subjectRef, err := opts.Registry.parse(opts.Subject)
+		if err != nil {
+			return "", fmt.Errorf("parse subject ref: %w", err)
+		}
+		remoteOpts, err := opts.Registry.remoteOptions(subjectRef.Context())
+		if err != nil {
+			return "", err
+		}
+		subject, err = remote.Head(subjectRef, remoteOpts...)
+		if err != nil {
+			return "", fmt.Errorf("resolve subject %s: %w", opts.Subject, err)
+		}
+		for _, p := range paths {
+			if err := RequireImageSubject(p, subject.Digest.String()); err != nil {
+				return "", fmt.Errorf("attach to %s: %w", opts.Subject, err)
+			}
+		}
+		repo = subjectRef.Context()
 	}
 
-	layer := static.NewLayer(raw, bundleMediaType)
-	img, err := mutate.AppendLayers(empty.Image, layer)
+	img, err := bundleImage(layers, subject)
 	if err != nil {
-		return "", fmt.Errorf("append layer: %w", err)
+		return "", err
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return "", fmt.Errorf("digest oci artifact: %w", err)
+	}
+
+	var ref name.Reference
+	if ociRef != "" {
+		ref, err = opts.Registry.parse(ociRef)
+		if err != nil {
+			return "", fmt.Errorf("parse oci ref: %w", err)
+		}
+		if subject != nil && ref.Context().Name() != repo.Name() {
+			return "", fmt.Errorf("oci ref %s must be in the subject repository %s", ociRef, repo.Name())
+		}
+	} else if subject != nil {
+		ref = repo.Digest(digest.String())
+	} else {
+		return "", fmt.Errorf("an oci ref or a subject is required")
 	}
-	img = mutate.MediaType(img, types.OCIManifestSchema1)
 
-	if err := remote.Write(ref, img, remote.WithAuthFromKeychain(authn.DefaultKeychain)); err != nil {
+	remoteOpts, err := opts.Registry.remoteOptions(ref.Context())
+	if err != nil {
+		return "", err
+	}
+	if err := remote.Write(ref, img, remoteOpts...); err != nil {
 		return "", fmt.Errorf("push oci artifact: %w", err)
 	}
-	desc, err := remote.Get(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	return fmt.Sprintf("%s@%s", ref.Context().Name(), digest.String()), nil
+}
+
+// bundleFiles returns inPath itself, or the sorted *.bundle.json files when
+// it is a directory.
+func bundleFiles(inPath string) ([]string, error) {
+	fi, err := os.Stat(inPath)
 	if err != nil {
-		return "", fmt.Errorf("resolve pushed descriptor: %w", err)
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	if !fi.IsDir() {
+		return []string{inPath}, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(inPath, "*.bundle.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list bundles: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.bundle.json files in %s", inPath)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// bundleLayer annotates a bundle layer with its title and, when the payload
+// decodes as a statement, its attestation type and statement ID.
+func bundleLayer(raw []byte, title string) mutate.Addendum {
+	annotations := map[string]string{AnnotationTitle: title}
+	var bundle sign.Bundle
+	var statement struct {
+		StatementID     string `json:"statement_id"`
+		AttestationType string `json:"attestation_type"`
+	}
+	if json.Unmarshal(raw, &bundle) == nil && sign.DecodePayload(bundle, &statement) == nil {
+		if statement.AttestationType != "" {
+			annotations[AnnotationAttestationType] = statement.AttestationType
+		}
+		if statement.StatementID != "" {
+			annotations[AnnotationStatementID] = statement.StatementID
+		}
+	}
+	return mutate.Addendum{Layer: static.NewLayer(raw, bundleMediaType), Annotations: annotations}
+}
+
+// bundleImage wraps bundle layers in an artifact manifest, optionally
+// referring to subject.
+func bundleImage(layers []mutate.Addendum, subject *v1.Descriptor) (v1.Image, error) {
+	img, err := mutate.Append(empty.Image, layers...)
+	if err != nil {
+		return nil, fmt.Errorf("append layer: %w", err)
+	}
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	if subject != nil {
+		img = mutate.Subject(img, *subject).(v1.Image)
 	}
-	pinned := fmt.Sprintf("%s@%s", ref.Context().Name(), desc.Descriptor.Digest.String())
-	return pinned, nil
+	return &artifactImage{Image: img, artifactType: ArtifactType}, nil
 }
 
+// PullOCI pulls the artifact at ociRef to outPath. A bundle set is expanded
+// next to outPath; see PullOCIBundles.
 func PullOCI(ociRef string, outPath string) error {
-	ref, err := name.ParseReference(ociRef, name.WithDefaultRegistry("ghcr.io"))
+	return PullOCIWithOptions(ociRef, outPath, RegistryOptions{})
+}
+
+// PullOCIWithOptions is PullOCI with explicit registry options.
+func PullOCIWithOptions(ociRef, outPath string, reg RegistryOptions) error {
+	_, err := PullOCIBundles(ociRef, outPath, reg)
+	return err
+}
+
+// PullOCIBundles pulls the artifact at ociRef and returns the files written.
+// A single bundle is written to outPath. Each bundle of a set is written to
+// outPath's directory as <outPath stem>_<title>, so pulling "oci_1.bundle.json"
+// yields "oci_1_prompt.bundle.json" and so on.
+func PullOCIBundles(ociRef, outPath string, reg RegistryOptions) ([]string, error) {
+	ref, err := reg.parse(ociRef)
 	if err != nil {
-		return fmt.Errorf("parse oci ref: %w", err)
+		return nil, fmt.Errorf("parse oci ref: %w", err)
 	}
-	img, err := remote.Image(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	remoteOpts, err := reg.remoteOptions(ref.Context())
 	if err != nil {
-		return fmt.Errorf("pull oci artifact: %w", err)
+		return nil, err
 	}
+	img, err := remote.Image(ref, remoteOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("pull oci artifact: %w", err)
+	}
+	return writeLayers(img, outPath)
+}
+
+func writeLayers(img v1.Image, outPath string) ([]string, error) {
 	layers, err := img.Layers()
 	if err != nil {
-		return fmt.Errorf("read layers: %w", err)
+		return nil, fmt.Errorf("read layers: %w", err)
 	}
 	if len(layers) == 0 {
-		return fmt.Errorf("oci artifact has no layers")
+		return nil, fmt.Errorf("oci artifact has no layers")
+	}
+	if len(layers) == 1 {
+		return []string{outPath}, writeLayer(layers[0], outPath)
 	}
 
-	rc, err := layers[0].Uncompressed()
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	stem := strings.TrimSuffix(filepath.Base(outPath), ".bundle.json")
+	stem = strings.TrimSuffix(stem, filepath.Ext(stem))
+	paths := make([]string, 0, len(layers))
+	for i, layer := range layers {
+		title := ""
+		if i < len(manifest.Layers) {
+			title = filepath.Base(manifest.Layers[i].Annotations[AnnotationTitle])
+		}
+		if !strings.HasSuffix(title, ".bundle.json") {
+			title = fmt.Sprintf("%d.bundle.json", i+1)
+		}
+		out := filepath.Join(filepath.Dir(outPath), stem+"_"+title)
+		if err := writeLayer(layer, out); err != nil {
+			return nil, err
+		}
+		paths = append(paths, out)
+	}
+	return paths, nil
+}
+
+func writeLayer(layer v1.Layer, outPath string) error {
+	rc, err := layer.Uncompressed()
 	if err != nil {
 		return fmt.Errorf("read layer payload: %w", err)
 	}
@@ -75,3 +269,52 @@ func PullOCI(ociRef string, outPath string) error {
 	}
 	return nil
 }
+
+// artifactImage replaces an image's config with the empty config and adds
+// the manifest artifactType, which v1.Manifest has no field for.
+type artifactImage struct {
+	v1.Image
+	artifactType string
+}
+
+type artifactManifest struct {
+	v1.Manifest
+	ArtifactType string `json:"artifactType,omitempty"`
+}
+
+func (a *artifactImage) ArtifactType() (string, error) { return a.artifactType, nil }
+
+func (a *artifactImage) RawConfigFile() ([]byte, error) { return emptyConfig, nil }
+
+func (a *artifactImage) ConfigFile() (*v1.ConfigFile, error) { return &v1.ConfigFile{}, nil }
+
+func (a *artifactImage) ConfigName() (v1.Hash, error) {
+	h, _, err := v1.SHA256(bytes.NewReader(emptyConfig))
+	return h, err
+}
+
+func (a *artifactImage) Manifest() (*v1.Manifest, error) {
+	m, err := a.Image.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	m = m.DeepCopy()
+	cfg, err := a.ConfigName()
+	if err != nil {
+		return nil, err
+	}
+	m.Config = v1.Descriptor{MediaType: types.MediaType(a.artifactType), Size: int64(len(emptyConfig)), Digest: cfg}
+	return m, nil
+}
+
+func (a *artifactImage) RawManifest() ([]byte, error) {
+	m, err := a.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(artifactManifest{Manifest: *m, ArtifactType: a.artifactType})
+}
+
+func (a *artifactImage) Digest() (v1.Hash, error) { return partial.Digest(a) }
+
+func (a *artifactImage) Size() (int64, error) { return partial.Size(a) }
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net"
 	"net/http"
@@ -10,14 +11,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 )
 
 // startRegistry spins up an in-memory OCI registry and returns
 // the host:port prefix suitable for use in image references.
-func startRegistry(t *testing.T) string {
+func startRegistry(t *testing.T, opts ...registry.Option) string {
 	t.Helper()
-	handler := registry.New()
+	handler := registry.New(opts...)
 
 	// Force IPv4 listener to avoid environments where IPv6 loopback is unavailable.
 	ln, err := net.Listen("tcp4", "127.0.0.1:0")
@@ -250,3 +253,71 @@ func TestPublishOCI_MultipleTagsSameRepo(t *testing.T) {
 		}
 	}
 }
+
+// --- bundle sets ---
+
+func statementBundle(t *testing.T, dir, attType, id string) {
+	t.Helper()
+	payload := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"statement_id":%q,"attestation_type":%q}`, id, attType)))
+	raw := fmt.Sprintf(`{"envelope":{"payloadType":"application/vnd.llmsa.statement.v1+json","payload":%q,"signatures":[]}}`, payload)
+	if err := os.WriteFile(filepath.Join(dir, attType+".bundle.json"), []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPublishOCI_BundleSet(t *testing.T) {
+	host := startRegistry(t)
+	src := t.TempDir()
+	statementBundle(t, src, "prompt_attestation", "st-prompt")
+	statementBundle(t, src, "eval_attestation", "st-eval")
+	os.WriteFile(filepath.Join(src, "notes.txt"), []byte("ignored"), 0o644)
+
+	pinned, err := PublishOCI(src, host+"/org/attestations:release")
+	if err != nil {
+		t.Fatalf("PublishOCI dir: %v", err)
+	}
+	if !strings.Contains(pinned, "@sha256:") {
+		t.Fatalf("expected a single pinned digest, got %s", pinned)
+	}
+
+	ref, _ := name.ParseReference(pinned)
+	img, err := remote.Image(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Layers) != 2 {
+		t.Fatalf("expected 2 layers, got %d", len(manifest.Layers))
+	}
+	got := manifest.Layers[0].Annotations
+	if got[AnnotationTitle] != "eval_attestation.bundle.json" || got[AnnotationAttestationType] != "eval_attestation" || got[AnnotationStatementID] != "st-eval" {
+		t.Fatalf("unexpected layer annotations: %v", got)
+	}
+
+	out := t.TempDir()
+	paths, err := PullOCIBundles(pinned, filepath.Join(out, "oci_1.bundle.json"), RegistryOptions{})
+	if err != nil {
+		t.Fatalf("PullOCIBundles: %v", err)
+	}
+	want := []string{
+		filepath.Join(out, "oci_1_eval_attestation.bundle.json"),
+		filepath.Join(out, "oci_1_prompt_attestation.bundle.json"),
+	}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", paths, want)
+	}
+	for _, p := range want {
+		pulled, _ := os.ReadFile(p)
+		orig, _ := os.ReadFile(filepath.Join(src, strings.TrimPrefix(filepath.Base(p), "oci_1_")))
+		if string(pulled) != string(orig) {
+			t.Errorf("%s: content mismatch", p)
+		}
+	}
+
+	if _, err := PublishOCI(t.TempDir(), host+"/org/attestations:empty"); err == nil || !strings.Contains(err.Error(), "no *.bundle.json") {
+		t.Fatalf("expected empty directory error, got %v", err)
+	}
+}
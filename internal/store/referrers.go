@@ -0,0 +1,114 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+)
+
+// DiscoverReferrers lists the llmsa bundles attached to imageRef through the
+// OCI 1.1 referrers API, or the referrers tag schema on registries without
+// it. Tags are resolved to the image digest first. The result holds
+// digest-pinned references, sorted.
+func DiscoverReferrers(imageRef string, reg RegistryOptions) ([]string, error) {
+	_, refs, err := discoverReferrers(imageRef, reg)
+	return refs, err
+}
+
+// discoverReferrers is DiscoverReferrers, also returning the resolved image.
+func discoverReferrers(imageRef string, reg RegistryOptions) (name.Digest, []string, error) {
+	ref, err := reg.parse(imageRef)
+	if err != nil {
+		return name.Digest{}, nil, fmt.Errorf("parse image ref: %w", err)
+	}
+	remoteOpts, err := reg.remoteOptions(ref.Context())
+	if err != nil {
+		return name.Digest{}, nil, err
+	}
+	subject, ok := ref.(name.Digest)
+	if !ok {
+		desc, err := remote.Head(ref, remoteOpts...)
+		if err != nil {
+			return name.Digest{}, nil, fmt.Errorf("resolve image %s: %w", imageRef, err)
+		}
+		subject = ref.Context().Digest(desc.Digest.String())
+	}
+	idx, err := remote.Referrers(subject, append(remoteOpts, remote.WithFilter("artifactType", ArtifactType))...)
+	if err != nil {
+		return name.Digest{}, nil, fmt.Errorf("list referrers of %s: %w", subject, err)
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return name.Digest{}, nil, fmt.Errorf("read referrers of %s: %w", subject, err)
+	}
+	out := make([]string, 0, len(manifest.Manifests))
+	for _, m := range manifest.Manifests {
+		if m.ArtifactType != ArtifactType {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s@%s", subject.Context().Name(), m.Digest.String()))
+	}
+	sort.Strings(out)
+	return subject, out, nil
+}
+
+// RequireImageSubject checks that the statement in the bundle at path lists
+// imageDigest ("sha256:<hex>") among its subjects. The referrers subject
+// descriptor is not signed, so only the statement ties a bundle to the image
+// it is attached to; callers still verify the bundle's signature.
+func RequireImageSubject(path, imageDigest string) error {
+	bundle, err := sign.ReadBundle(path)
+	if err != nil {
+		return err
+	}
+	var statement struct {
+		Subject []struct {
+			Digest map[string]string `json:"digest"`
+		} `json:"subject"`
+	}
+	if err := sign.DecodePayload(bundle, &statement); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	alg, hex, _ := strings.Cut(imageDigest, ":")
+	for _, s := range statement.Subject {
+		if hex != "" && s.Digest[alg] == hex {
+			return nil
+		}
+	}
+	return fmt.Errorf("bundle %s does not list image %s as a subject", filepath.Base(path), imageDigest)
+}
+
+// PullReferrers downloads every llmsa bundle attached to imageRef into outDir
+// as sha256-<hex>.bundle.json, expanding bundle sets, and returns the written
+// paths. An image with no attached bundles is an error.
+func PullReferrers(imageRef, outDir string, reg RegistryOptions) ([]string, error) {
+	subject, refs, err := discoverReferrers(imageRef, reg)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no llmsa attestations attached to %s", imageRef)
+	}
+	paths := make([]string, 0, len(refs))
+	for _, r := range refs {
+		digest := r[strings.LastIndex(r, "@")+1:]
+		out := filepath.Join(outDir, strings.Replace(digest, ":", "-", 1)+".bundle.json")
+		written, err := PullOCIBundles(r, out, reg)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range written {
+			if err := RequireImageSubject(p, subject.DigestStr()); err != nil {
+				return nil, fmt.Errorf("referrer %s: %w", r, err)
+			}
+		}
+		paths = append(paths, written...)
+	}
+	return paths, nil
+}
@@ -0,0 +1,222 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+)
+
+// pushImage writes a random image to host and returns its tag and digest refs.
+func pushImage(t *testing.T, host string) (string, string) {
+	t.Helper()
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := fmt.Sprintf("%s/app/model-server:v1", host)
+	ref, err := name.ParseReference(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatalf("push image: %v", err)
+	}
+	d, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tag, fmt.Sprintf("%s/app/model-server@%s", host, d)
+}
+
+func writeBundle(t *testing.T, dir string, id int) string {
+	t.Helper()
+	path := filepath.Join(dir, fmt.Sprintf("bundle%d.json", id))
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(`{"id":%d}`, id)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeImageBundle writes a bundle whose statement lists the digest of
+// pinnedImage as a subject, as attaching it to that image requires.
+func writeImageBundle(t *testing.T, dir string, id int, pinnedImage string) string {
+	t.Helper()
+	statement := map[string]any{
+		"statement_id": fmt.Sprintf("stmt-%d", id),
+		"subject": []any{map[string]any{
+			"uri":    "image://" + pinnedImage,
+			"digest": map[string]any{"sha256": strings.TrimPrefix(digestOf(pinnedImage), "sha256:")},
+		}},
+	}
+	bundle, err := sign.CreateBundle(statement, sign.SignMaterial{KeyID: "k", SigB64: "s", Provider: "pem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("bundle%d.json", id))
+	if err := sign.WriteBundle(path, bundle); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// --- referrers ---
+
+func TestPublishOCIWithSubject(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []registry.Option
+	}{
+		{"referrers api", []registry.Option{registry.WithReferrersSupport(true)}},
+		{"tag schema fallback", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			host := startRegistry(t, tc.opts...)
+			tag, pinnedImage := pushImage(t, host)
+			dir := t.TempDir()
+
+			var published []string
+			for i := 1; i <= 2; i++ {
+				pinned, err := PublishOCIWithOptions(writeImageBundle(t, dir, i, pinnedImage), "", PublishOptions{Subject: tag})
+				if err != nil {
+					t.Fatalf("publish %d: %v", i, err)
+				}
+				if !strings.HasPrefix(pinned, host+"/app/model-server@sha256:") {
+					t.Fatalf("expected artifact in the image repository, got %s", pinned)
+				}
+				published = append(published, pinned)
+			}
+
+			ref, _ := name.ParseReference(published[0])
+			desc, err := remote.Get(ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var manifest struct {
+				ArtifactType string `json:"artifactType"`
+				Subject      struct {
+					Digest string `json:"digest"`
+				} `json:"subject"`
+			}
+			if err := json.Unmarshal(desc.Manifest, &manifest); err != nil {
+				t.Fatal(err)
+			}
+			if manifest.ArtifactType != ArtifactType {
+				t.Errorf("artifactType = %q", manifest.ArtifactType)
+			}
+			if !strings.HasSuffix(pinnedImage, "@"+manifest.Subject.Digest) {
+				t.Errorf("subject %q does not point at %s", manifest.Subject.Digest, pinnedImage)
+			}
+
+			found, err := DiscoverReferrers(pinnedImage, RegistryOptions{})
+			if err != nil {
+				t.Fatalf("DiscoverReferrers: %v", err)
+			}
+			if len(found) != 2 {
+				t.Fatalf("expected 2 referrers, got %v", found)
+			}
+
+			paths, err := PullReferrers(tag, t.TempDir(), RegistryOptions{})
+			if err != nil {
+				t.Fatalf("PullReferrers: %v", err)
+			}
+			seen := map[string]bool{}
+			for _, p := range paths {
+				bundle, err := sign.ReadBundle(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var st struct {
+					StatementID string `json:"statement_id"`
+				}
+				if err := sign.DecodePayload(bundle, &st); err != nil {
+					t.Fatal(err)
+				}
+				seen[st.StatementID] = true
+			}
+			if !seen["stmt-1"] || !seen["stmt-2"] {
+				t.Fatalf("pulled bundles mismatch: %v", seen)
+			}
+		})
+	}
+}
+
+func TestPublishOCIWithSubjectErrors(t *testing.T) {
+	host := startRegistry(t)
+	tag, pinnedImage := pushImage(t, host)
+	bundle := writeImageBundle(t, t.TempDir(), 1, pinnedImage)
+
+	if _, err := PublishOCIWithOptions(bundle, host+"/other/repo:att", PublishOptions{Subject: tag}); err == nil || !strings.Contains(err.Error(), "subject repository") {
+		t.Fatalf("expected repository mismatch error, got %v", err)
+	}
+	if _, err := PublishOCIWithOptions(writeImageBundle(t, t.TempDir(), 2, host+"/app/other@sha256:"+strings.Repeat("0", 64)), "", PublishOptions{Subject: tag}); err == nil || !strings.Contains(err.Error(), "does not list image") {
+		t.Fatalf("expected error for a bundle that does not name the image, got %v", err)
+	}
+	if _, err := PublishOCIWithOptions(bundle, "", PublishOptions{Subject: host + "/app/missing:v1"}); err == nil {
+		t.Fatal("expected error for missing subject image")
+	}
+	if _, err := PublishOCIWithOptions(bundle, "", PublishOptions{}); err == nil {
+		t.Fatal("expected error without oci ref or subject")
+	}
+}
+
+func TestPullReferrersRejectsBundleForAnotherImage(t *testing.T) {
+	host := startRegistry(t, registry.WithReferrersSupport(true))
+	_, signedFor := pushImage(t, host)
+	other, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRef := mustParse(t, host+"/app/model-server:v2")
+	if err := remote.Write(otherRef, other); err != nil {
+		t.Fatal(err)
+	}
+
+	// Attach a bundle signed for one image to another, bypassing publish: the
+	// referrers subject descriptor itself is not signed.
+	raw, err := os.ReadFile(writeImageBundle(t, t.TempDir(), 1, signedFor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := remote.Head(otherRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := bundleImage([]mutate.Addendum{bundleLayer(raw, "bundle1.json")}, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(otherRef.Context().Digest(d.String()), img); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = PullReferrers(otherRef.String(), t.TempDir(), RegistryOptions{})
+	if err == nil || !strings.Contains(err.Error(), "does not list image "+desc.Digest.String()) {
+		t.Fatalf("expected image subject error, got %v", err)
+	}
+}
+
+func TestPullReferrersNone(t *testing.T) {
+	for _, opts := range [][]registry.Option{{registry.WithReferrersSupport(true)}, nil} {
+		host := startRegistry(t, opts...)
+		tag, _ := pushImage(t, host)
+		_, err := PullReferrers(tag, t.TempDir(), RegistryOptions{})
+		if err == nil || !strings.Contains(err.Error(), "no llmsa attestations") {
+			t.Fatalf("expected no attestations error, got %v", err)
+		}
+	}
+}
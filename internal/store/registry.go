@@ -0,0 +1,294 @@
+package store
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// DefaultRegistry is used for references without a registry host.
+const DefaultRegistry = "ghcr.io"
+
+// RegistryUsernameEnv and RegistryTokenEnv hold explicit registry
+// credentials, e.g. a CI token.
+const (
+	RegistryUsernameEnv = "LLMSA_REGISTRY_USERNAME"
+	RegistryTokenEnv    = "LLMSA_REGISTRY_TOKEN"
+)
+
+// RegistryOptions controls how OCI registries are reached. The zero value
+// uses the default keychain (docker config and credential helpers), TLS with
+// the system roots and ghcr.io as the default registry.
+type RegistryOptions struct {
+	// DefaultRegistry replaces ghcr.io for references without a host.
+	DefaultRegistry string
+	// Username and Token are sent to RegistryHost ahead of any other
+	// credentials, and never to other registries. A token without a username
+	// is sent as a bearer token.
+	Username string
+	Token    string
+	// RegistryHost is the only registry the explicit credentials are sent
+	// to. Empty means the default registry.
+	RegistryHost string
+	// DockerConfig is a docker config.json, or a directory holding one.
+	DockerConfig string
+	// PullSecrets are Kubernetes image pull secrets mounted as files: the
+	// .dockerconfigjson or legacy .dockercfg key, or the directory holding it.
+	PullSecrets []string
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// InsecureRegistries are hosts reached over plain HTTP, or over TLS
+	// without certificate verification, e.g. local mirrors.
+	InsecureRegistries []string
+	// Retries is the number of attempts for transient failures; 0 keeps
+	// the library default of 3. RetryDelay is the first wait, tripled after
+	// each attempt (default 1s).
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// parse parses ref against the default registry, marking insecure hosts.
+func (o RegistryOptions) parse(ref string) (name.Reference, error) {
+	reg := o.DefaultRegistry
+	if reg == "" {
+		reg = DefaultRegistry
+	}
+	opts := []name.Option{name.WithDefaultRegistry(reg)}
+	parsed, err := name.ParseReference(ref, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if o.insecure(parsed.Context().RegistryStr()) {
+		return name.ParseReference(ref, append(opts, name.Insecure)...)
+	}
+	return parsed, nil
+}
+
+func (o RegistryOptions) insecure(host string) bool {
+	for _, h := range o.InsecureRegistries {
+		if strings.EqualFold(strings.TrimSuffix(h, "/"), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteOptions returns the remote options for requests to repo.
+func (o RegistryOptions) remoteOptions(repo name.Repository) ([]remote.Option, error) {
+	keychain, err := o.keychain()
+	if err != nil {
+		return nil, err
+	}
+	opts := []remote.Option{remote.WithAuthFromKeychain(keychain)}
+	if o.CAFile != "" || o.insecure(repo.RegistryStr()) {
+		tr, err := o.transport(repo.RegistryStr())
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, remote.WithTransport(tr))
+	}
+	if o.Retries > 0 || o.RetryDelay > 0 {
+		backoff := remote.Backoff{Duration: time.Second, Factor: 3, Jitter: 0.1, Steps: 3}
+		if o.Retries > 0 {
+			backoff.Steps = o.Retries
+		}
+		if o.RetryDelay > 0 {
+			backoff.Duration = o.RetryDelay
+		}
+		opts = append(opts, remote.WithRetryBackoff(backoff))
+	}
+	return opts, nil
+}
+
+func (o RegistryOptions) transport(host string) (http.RoundTripper, error) {
+	tr := remote.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read registry CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("registry CA bundle %s has no PEM certificates", o.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if o.insecure(host) {
+		// Opted in per registry for local mirrors with self-signed certificates.
+		tlsConfig.InsecureSkipVerify = true
+	}
+	tr.TLSClientConfig = tlsConfig
+	return tr, nil
+}
+
+// keychain orders explicit credentials, then docker config and pull
+// secrets, then the default keychain.
+func (o RegistryOptions) keychain() (authn.Keychain, error) {
+	chain := make([]authn.Keychain, 0, 3)
+	switch {
+	case o.Username != "" && o.Token != "":
+		chain = append(chain, staticKeychain{o.credentialHost(), authn.AuthConfig{Username: o.Username, Password: o.Token}})
+	case o.Token != "":
+		chain = append(chain, staticKeychain{o.credentialHost(), authn.AuthConfig{RegistryToken: o.Token}})
+	case o.Username != "":
+		return nil, fmt.Errorf("registry username %q set without a token", o.Username)
+	}
+	files := make([]string, 0, len(o.PullSecrets)+1)
+	if o.DockerConfig != "" {
+		files = append(files, o.DockerConfig)
+	}
+	files = append(files, o.PullSecrets...)
+	if len(files) > 0 {
+		auths := dockerConfigKeychain{}
+		for _, f := range files {
+			if err := auths.load(f); err != nil {
+				return nil, err
+			}
+		}
+		chain = append(chain, auths)
+	}
+	chain = append(chain, authn.DefaultKeychain)
+	return authn.NewMultiKeychain(chain...), nil
+}
+
+// credentialHost is the registry the explicit credentials belong to.
+func (o RegistryOptions) credentialHost() string {
+	host := o.RegistryHost
+	if host == "" {
+		host = o.DefaultRegistry
+	}
+	if host == "" {
+		host = DefaultRegistry
+	}
+	return registryHost(host)
+}
+
+// staticKeychain holds the explicit credentials for a single registry host.
+type staticKeychain struct {
+	host string
+	cfg  authn.AuthConfig
+}
+
+func (k staticKeychain) Resolve(r authn.Resource) (authn.Authenticator, error) {
+	if !strings.EqualFold(r.RegistryStr(), k.host) {
+		return authn.Anonymous, nil
+	}
+	return authn.FromConfig(k.cfg), nil
+}
+
+// dockerConfigKeychain maps registry hosts to credentials from docker
+// config files and Kubernetes pull secrets, which share the "auths" format.
+type dockerConfigKeychain map[string]authn.AuthConfig
+
+// dockerConfigFiles are looked up, in order, when a directory is given.
+var dockerConfigFiles = []string{"config.json", ".dockerconfigjson", ".dockercfg"}
+
+func (k dockerConfigKeychain) load(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("read registry credentials: %w", err)
+	}
+	if fi.IsDir() {
+		found := ""
+		for _, f := range dockerConfigFiles {
+			if _, err := os.Stat(filepath.Join(path, f)); err == nil {
+				found = filepath.Join(path, f)
+				break
+			}
+		}
+		if found == "" {
+			return fmt.Errorf("read registry credentials: no %s in %s", strings.Join(dockerConfigFiles, ", "), path)
+		}
+		path = found
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read registry credentials: %w", err)
+	}
+	var doc struct {
+		Auths map[string]dockerAuth `json:"auths"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("parse registry credentials %s: %w", path, err)
+	}
+	if doc.Auths == nil && filepath.Base(path) == ".dockercfg" {
+		// Legacy .dockercfg files are the auths map itself.
+		if err := json.Unmarshal(raw, &doc.Auths); err != nil {
+			return fmt.Errorf("parse registry credentials %s: %w", path, err)
+		}
+	}
+	for host, a := range doc.Auths {
+		cfg, err := a.config()
+		if err != nil {
+			return fmt.Errorf("parse registry credentials %s: %s: %w", path, host, err)
+		}
+		k[registryHost(host)] = cfg
+	}
+	return nil
+}
+
+func (k dockerConfigKeychain) Resolve(r authn.Resource) (authn.Authenticator, error) {
+	if cfg, ok := k[r.RegistryStr()]; ok {
+		return authn.FromConfig(cfg), nil
+	}
+	return authn.Anonymous, nil
+}
+
+type dockerAuth struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+	RegistryToken string `json:"registrytoken"`
+}
+
+func (a dockerAuth) config() (authn.AuthConfig, error) {
+	cfg := authn.AuthConfig{
+		Username:      a.Username,
+		Password:      a.Password,
+		IdentityToken: a.IdentityToken,
+		RegistryToken: a.RegistryToken,
+	}
+	if a.Auth != "" {
+		raw, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			return cfg, fmt.Errorf("decode auth: %w", err)
+		}
+		user, pass, ok := strings.Cut(string(raw), ":")
+		if !ok {
+			return cfg, fmt.Errorf("auth is not user:password")
+		}
+		cfg.Username, cfg.Password = user, pass
+	}
+	return cfg, nil
+}
+
+// registryHost normalises a docker config key ("https://index.docker.io/v1/",
+// "ghcr.io") to the host go-containerregistry reports.
+func registryHost(key string) string {
+	if u, err := url.Parse(key); err == nil && u.Host != "" {
+		key = u.Host
+	} else {
+		key, _, _ = strings.Cut(key, "/")
+	}
+	if key == "docker.io" || key == "registry-1.docker.io" {
+		return name.DefaultRegistry
+	}
+	return key
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+// authRegistry serves an in-memory registry that requires basic auth.
+func authRegistry(t *testing.T, user, pass string) string {
+	t.Helper()
+	reg := registry.New()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != user || p != pass {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func publishTestBundle(t *testing.T, ref string, reg RegistryOptions) error {
+	t.Helper()
+	path := writeBundle(t, t.TempDir(), 1)
+	_, err := PublishOCIWithOptions(path, ref, PublishOptions{Registry: reg})
+	return err
+}
+
+// --- credentials ---
+
+func TestRegistryOptions_ExplicitCredentials(t *testing.T) {
+	host := authRegistry(t, "ci", "s3cret")
+	ref := host + "/org/attestations:v1"
+
+	if err := publishTestBundle(t, ref, RegistryOptions{}); err == nil {
+		t.Fatal("expected unauthorized push without credentials")
+	}
+	creds := RegistryOptions{Username: "ci", Token: "s3cret", RegistryHost: host}
+	if err := publishTestBundle(t, ref, creds); err != nil {
+		t.Fatalf("push with credentials: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "pulled.bundle.json")
+	if err := PullOCIWithOptions(ref, out, creds); err != nil {
+		t.Fatalf("pull with credentials: %v", err)
+	}
+	// Without a registry host the credentials belong to the default registry.
+	if err := PullOCIWithOptions(ref, out, RegistryOptions{Username: "ci", Token: "s3cret"}); err == nil {
+		t.Fatal("expected credentials scoped to ghcr.io not to be sent to the test registry")
+	}
+	if err := PullOCIWithOptions(ref, out, RegistryOptions{Username: "ci", Token: "s3cret", DefaultRegistry: host}); err != nil {
+		t.Fatalf("pull with credentials for the default registry: %v", err)
+	}
+	if err := publishTestBundle(t, ref, RegistryOptions{Username: "ci"}); err == nil || !strings.Contains(err.Error(), "without a token") {
+		t.Fatalf("expected missing token error, got %v", err)
+	}
+}
+
+func TestRegistryOptions_ExplicitCredentialsStayOnTheirHost(t *testing.T) {
+	kc, err := RegistryOptions{Username: "ci", Token: "s3cret", RegistryHost: "registry.example.com"}.keychain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for host, want := range map[string]bool{"registry.example.com": true, "other.example.com": false, "ghcr.io": false} {
+		reg, err := name.NewRegistry(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		auth, err := kc.Resolve(reg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := auth.Authorization()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cfg.Username == "ci" && cfg.Password == "s3cret"; got != want {
+			t.Errorf("%s: credentials sent = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestRegistryOptions_DockerConfigAndPullSecrets(t *testing.T) {
+	host := authRegistry(t, "robot", "pull-token")
+	auth := base64.StdEncoding.EncodeToString([]byte("robot:pull-token"))
+
+	dir := t.TempDir()
+	dockerConfig := filepath.Join(dir, "config.json")
+	os.WriteFile(dockerConfig, []byte(fmt.Sprintf(`{"auths":{"https://%s/v2/":{"auth":%q}}}`, host, auth)), 0o600)
+	if err := publishTestBundle(t, host+"/org/a:v1", RegistryOptions{DockerConfig: dir}); err != nil {
+		t.Fatalf("push with docker config dir: %v", err)
+	}
+
+	// A mounted kubernetes.io/dockerconfigjson secret.
+	secretDir := t.TempDir()
+	os.WriteFile(filepath.Join(secretDir, ".dockerconfigjson"), []byte(fmt.Sprintf(`{"auths":{%q:{"username":"robot","password":"pull-token"}}}`, host)), 0o600)
+	if err := publishTestBundle(t, host+"/org/b:v1", RegistryOptions{PullSecrets: []string{secretDir}}); err != nil {
+		t.Fatalf("push with pull secret: %v", err)
+	}
+
+	// A legacy kubernetes.io/dockercfg secret.
+	legacy := filepath.Join(t.TempDir(), ".dockercfg")
+	os.WriteFile(legacy, []byte(fmt.Sprintf(`{%q:{"auth":%q}}`, host, auth)), 0o600)
+	if err := publishTestBundle(t, host+"/org/c:v1", RegistryOptions{PullSecrets: []string{legacy}}); err != nil {
+		t.Fatalf("push with legacy pull secret: %v", err)
+	}
+
+	if err := publishTestBundle(t, host+"/org/d:v1", RegistryOptions{PullSecrets: []string{t.TempDir()}}); err == nil || !strings.Contains(err.Error(), "no config.json") {
+		t.Fatalf("expected missing secret file error, got %v", err)
+	}
+}
+
+func TestDockerConfigKeychainHosts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"auths":{"https://index.docker.io/v1/":{"username":"u","password":"p"},"ghcr.io":{"identitytoken":"tok"}}}`), 0o600)
+	k := dockerConfigKeychain{}
+	if err := k.load(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		ref  string
+		want authn.AuthConfig
+	}{
+		{"library/alpine", authn.AuthConfig{Username: "u", Password: "p"}},
+		{"ghcr.io/acme/app", authn.AuthConfig{IdentityToken: "tok"}},
+		{"quay.io/acme/app", authn.AuthConfig{}},
+	} {
+		repo, _ := name.NewRepository(tc.ref)
+		a, err := k.Resolve(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := a.Authorization()
+		if *got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.ref, *got, tc.want)
+		}
+	}
+}
+
+// --- transport ---
+
+func TestRegistryOptions_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(registry.New())
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "https://")
+	ref := host + "/org/attestations:v1"
+
+	if err := publishTestBundle(t, ref, RegistryOptions{}); err == nil {
+		t.Fatal("expected TLS failure with an untrusted certificate")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	os.WriteFile(caFile, certPEM, 0o644)
+	if err := publishTestBundle(t, ref, RegistryOptions{CAFile: caFile}); err != nil {
+		t.Fatalf("push with CA bundle: %v", err)
+	}
+	if err := publishTestBundle(t, ref, RegistryOptions{InsecureRegistries: []string{host}}); err != nil {
+		t.Fatalf("push to insecure registry: %v", err)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.pem")
+	os.WriteFile(bad, []byte("not a certificate"), 0o644)
+	if err := publishTestBundle(t, ref, RegistryOptions{CAFile: bad}); err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Fatalf("expected CA bundle error, got %v", err)
+	}
+}
+
+func TestRegistryOptions_DefaultRegistry(t *testing.T) {
+	host := startRegistry(t)
+	reg := RegistryOptions{DefaultRegistry: host}
+	pinned, err := PublishOCIWithOptions(writeBundle(t, t.TempDir(), 1), "org/attestations:v1", PublishOptions{Registry: reg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(pinned, host+"/org/attestations@") {
+		t.Fatalf("expected ref on the default registry, got %s", pinned)
+	}
+	if err := PullOCIWithOptions("org/attestations:v1", filepath.Join(t.TempDir(), "b.bundle.json"), reg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryOptions_Retries(t *testing.T) {
+	reg := registry.New()
+	var failures atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/manifests/") && failures.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	ref := strings.TrimPrefix(srv.URL, "http://") + "/org/attestations:v1"
+
+	if err := publishTestBundle(t, ref, RegistryOptions{Retries: 1, RetryDelay: time.Millisecond}); err == nil {
+		t.Fatal("expected failure with a single attempt")
+	}
+	failures.Store(0)
+	if err := publishTestBundle(t, ref, RegistryOptions{Retries: 4, RetryDelay: time.Millisecond}); err != nil {
+		t.Fatalf("push with retries: %v", err)
+	}
+}
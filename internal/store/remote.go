@@ -0,0 +1,82 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// ErrNotFound is wrapped by FetchOCIBlob and FetchS3Object when the registry
+// or object store answers that the object does not exist. Every other
+// failure (auth, TLS, server errors, timeouts) is returned without it.
+var ErrNotFound = errors.New("not found")
+
+// S3EndpointEnv overrides the object store endpoint used for s3:// URIs, e.g.
+// a MinIO or other S3-compatible stand-in.
+const S3EndpointEnv = "LLMSA_S3_ENDPOINT"
+
+const defaultS3Endpoint = "https://s3.amazonaws.com"
+
+// maxRemoteObjectBytes bounds how much of a remote subject is read into memory.
+const maxRemoteObjectBytes = 1 << 30
+
+// FetchOCIBlob downloads the raw bytes of a blob addressed by a digest-pinned
+// reference such as registry/repo@sha256:<hex>, reaching the registry with
+// reg.
+func FetchOCIBlob(ociRef string, reg RegistryOptions) ([]byte, error) {
+	parsed, err := reg.parse(ociRef)
+	if err != nil {
+		return nil, fmt.Errorf("parse oci blob ref (must be digest-pinned): %w", err)
+	}
+	ref, ok := parsed.(name.Digest)
+	if !ok {
+		return nil, fmt.Errorf("parse oci blob ref (must be digest-pinned): %s has no digest", ociRef)
+	}
+	opts, err := reg.remoteOptions(ref.Context())
+	if err != nil {
+		return nil, err
+	}
+	layer, err := remote.Layer(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("fetch oci blob: %w", notFound(err))
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("read oci blob: %w", notFound(err))
+	}
+	defer rc.Close()
+	raw, err := io.ReadAll(io.LimitReader(rc, maxRemoteObjectBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read oci blob bytes: %w", err)
+	}
+	return raw, nil
+}
+
+// FetchS3Object downloads bucket/key from an S3-compatible endpoint using a
+// path-style GET. An empty endpoint falls back to $LLMSA_S3_ENDPOINT and then
+// to AWS. The request is signed when AWS credentials are set in the
+// environment.
+func FetchS3Object(endpoint, bucket, key string) ([]byte, error) {
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("s3 object requires bucket and key")
+	}
+	b, err := NewS3Backend(bucket, S3Options{Endpoint: endpoint})
+	if err != nil {
+		return nil, err
+	}
+	return b.Get(key)
+}
+
+// notFound adds ErrNotFound to registry errors answered with 404.
+func notFound(err error) error {
+	var terr *transport.Error
+	if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+)
+
+func TestFetchOCIBlob(t *testing.T) {
+	host := startRegistry(t)
+	content := []byte("blob subject")
+	layer := static.NewLayer(content, bundleMediaType)
+	repo, err := name.NewRepository(host + "/llmsa/blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteLayer(repo, layer); err != nil {
+		t.Fatalf("write layer: %v", err)
+	}
+	digest, err := layer.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := FetchOCIBlob(host+"/llmsa/blobs@"+digest.String(), RegistryOptions{})
+	if err != nil {
+		t.Fatalf("FetchOCIBlob: %v", err)
+	}
+	if string(raw) != string(content) {
+		t.Fatalf("blob content = %q", raw)
+	}
+}
+
+func TestFetchOCIBlob_RegistryOptions(t *testing.T) {
+	host := authRegistry(t, "ci", "s3cret")
+	reg := RegistryOptions{DefaultRegistry: host, Username: "ci", Token: "s3cret"}
+	layer := static.NewLayer([]byte("private subject"), bundleMediaType)
+	repo, err := name.NewRepository(host + "/llmsa/blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := reg.remoteOptions(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteLayer(repo, layer, opts...); err != nil {
+		t.Fatalf("write layer: %v", err)
+	}
+	digest, _ := layer.Digest()
+
+	if _, err := FetchOCIBlob(host+"/llmsa/blobs@"+digest.String(), RegistryOptions{}); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected an auth failure without credentials, got %v", err)
+	}
+	// The default registry and credentials both come from reg.
+	raw, err := FetchOCIBlob("llmsa/blobs@"+digest.String(), reg)
+	if err != nil {
+		t.Fatalf("FetchOCIBlob with credentials: %v", err)
+	}
+	if string(raw) != "private subject" {
+		t.Fatalf("blob content = %q", raw)
+	}
+}
+
+func TestFetchOCIBlob_NotFound(t *testing.T) {
+	host := startRegistry(t)
+	_, err := FetchOCIBlob(host+"/llmsa/blobs@sha256:"+strings.Repeat("0", 64), RegistryOptions{})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFetchOCIBlob_RequiresDigest(t *testing.T) {
+	_, err := FetchOCIBlob("ghcr.io/acme/blobs:latest", RegistryOptions{})
+	if err == nil || !strings.Contains(err.Error(), "digest-pinned") {
+		t.Fatalf("expected digest-pinned error, got %v", err)
+	}
+}
+
+func TestFetchS3Object(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bucket/dir/object.bin" {
+			_, _ = w.Write([]byte("object"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	raw, err := FetchS3Object(srv.URL, "bucket", "dir/object.bin")
+	if err != nil {
+		t.Fatalf("FetchS3Object: %v", err)
+	}
+	if string(raw) != "object" {
+		t.Fatalf("object content = %q", raw)
+	}
+
+	t.Setenv(S3EndpointEnv, srv.URL)
+	if _, err := FetchS3Object("", "bucket", "dir/object.bin"); err != nil {
+		t.Fatalf("expected endpoint from env: %v", err)
+	}
+	if _, err := FetchS3Object(srv.URL, "bucket", "missing"); !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "status 404") {
+		t.Fatalf("expected 404 error, got %v", err)
+	}
+	if _, err := FetchS3Object(srv.URL, "", "k"); err == nil {
+		t.Fatal("expected missing bucket error")
+	}
+}
@@ -0,0 +1,372 @@
+package store
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backend stores attestation bundles as objects under slash-separated keys.
+type Backend interface {
+	// Put writes raw to key.
+	Put(key string, raw []byte) error
+	// Get reads the object at key.
+	Get(key string) ([]byte, error)
+	// List returns the keys starting with prefix, sorted.
+	List(prefix string) ([]string, error)
+	// URI returns a printable location for key.
+	URI(key string) string
+}
+
+// PublishBundles uploads inPath, or every *.bundle.json in it when it is a
+// directory, under prefix and returns the object URIs. A prefix ending in
+// .bundle.json names the object itself when a single file is published.
+func PublishBundles(b Backend, inPath, prefix string) ([]string, error) {
+	paths, err := bundleFiles(inPath)
+	if err != nil {
+		return nil, err
+	}
+	single := len(paths) == 1 && strings.HasSuffix(prefix, ".bundle.json")
+	uris := make([]string, 0, len(paths))
+	for _, p := range paths {
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("read bundle: %w", err)
+		}
+		key := prefix
+		if !single {
+			key = path.Join(prefix, filepath.Base(p))
+		}
+		if err := b.Put(key, raw); err != nil {
+			return nil, err
+		}
+		uris = append(uris, b.URI(key))
+	}
+	return uris, nil
+}
+
+// PullBundles downloads every *.bundle.json object under prefix into outDir
+// and returns the written paths. Keys below the prefix are flattened, so
+// "a/b.bundle.json" is written as "a_b.bundle.json".
+func PullBundles(b Backend, prefix, outDir string) ([]string, error) {
+	keys, err := b.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+	base := prefix
+	if i := strings.LastIndex(base, "/"); i >= 0 {
+		base = base[:i+1]
+	} else {
+		base = ""
+	}
+	var paths []string
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".bundle.json") {
+			continue
+		}
+		raw, err := b.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		out := filepath.Join(outDir, strings.ReplaceAll(strings.TrimPrefix(key, base), "/", "_"))
+		if err := os.WriteFile(out, raw, 0o644); err != nil {
+			return nil, fmt.Errorf("write pulled bundle: %w", err)
+		}
+		paths = append(paths, out)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.bundle.json objects under %s", b.URI(prefix))
+	}
+	return paths, nil
+}
+
+// Environment variables read for S3 credentials and region, as used by the
+// AWS CLI and SDKs.
+const (
+	S3AccessKeyEnv    = "AWS_ACCESS_KEY_ID"
+	S3SecretKeyEnv    = "AWS_SECRET_ACCESS_KEY"
+	S3SessionTokenEnv = "AWS_SESSION_TOKEN"
+	S3RegionEnv       = "AWS_REGION"
+)
+
+const defaultS3Region = "us-east-1"
+
+// S3Options configures an S3Backend. Empty fields are read from the
+// environment: the endpoint from $LLMSA_S3_ENDPOINT (then AWS), the region
+// from $AWS_REGION (then us-east-1) and credentials from the AWS variables.
+type S3Options struct {
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// S3Backend is a Backend on an S3-compatible object store such as AWS S3 or
+// MinIO, addressed path-style (endpoint/bucket/key). Requests are signed with
+// AWS Signature Version 4 when credentials are set, and anonymous otherwise.
+type S3Backend struct {
+	bucket   string
+	endpoint *url.URL
+	opts     S3Options
+	client   *http.Client
+}
+
+// NewS3Backend returns a backend for bucket.
+func NewS3Backend(bucket string, opts S3Options) (*S3Backend, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	for _, f := range []struct {
+		v   *string
+		env string
+	}{
+		{&opts.Endpoint, S3EndpointEnv},
+		{&opts.Region, S3RegionEnv},
+		{&opts.AccessKey, S3AccessKeyEnv},
+		{&opts.SecretKey, S3SecretKeyEnv},
+		{&opts.SessionToken, S3SessionTokenEnv},
+	} {
+		if *f.v == "" {
+			*f.v = os.Getenv(f.env)
+		}
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = defaultS3Endpoint
+	}
+	if opts.Region == "" {
+		opts.Region = defaultS3Region
+	}
+	if (opts.AccessKey == "") != (opts.SecretKey == "") {
+		return nil, fmt.Errorf("s3 credentials need both %s and %s", S3AccessKeyEnv, S3SecretKeyEnv)
+	}
+	endpoint, err := url.Parse(strings.TrimRight(opts.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("parse s3 endpoint %q: must be a URL such as https://s3.amazonaws.com", opts.Endpoint)
+	}
+	return &S3Backend{bucket: bucket, endpoint: endpoint, opts: opts, client: &http.Client{Timeout: 60 * time.Second}}, nil
+}
+
+// ParseS3URI splits s3://bucket/key into bucket and key; the key may be empty
+// or a prefix.
+func ParseS3URI(uri string) (bucket, key string, err error) {
+	rest, ok := strings.CutPrefix(uri, "s3://")
+	if !ok {
+		return "", "", fmt.Errorf("s3 uri %q must start with s3://", uri)
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("s3 uri %q has no bucket", uri)
+	}
+	return bucket, key, nil
+}
+
+// URI implements Backend.
+func (b *S3Backend) URI(key string) string {
+	return fmt.Sprintf("s3://%s/%s", b.bucket, key)
+}
+
+// Put implements Backend.
+func (b *S3Backend) Put(key string, raw []byte) error {
+	resp, err := b.do(http.MethodPut, key, nil, raw)
+	if err != nil {
+		return fmt.Errorf("put s3 object %s: %w", b.URI(key), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("put s3 object %s: %s", b.URI(key), s3Status(resp))
+	}
+	return nil
+}
+
+// Get implements Backend.
+func (b *S3Backend) Get(key string) ([]byte, error) {
+	resp, err := b.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch s3 object: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("fetch s3 object %s: %w (%s)", b.URI(key), ErrNotFound, s3Status(resp))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch s3 object %s: %s", b.URI(key), s3Status(resp))
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteObjectBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read s3 object: %w", err)
+	}
+	return raw, nil
+}
+
+// List implements Backend with ListObjectsV2, following continuation tokens.
+func (b *S3Backend) List(prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := b.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("list s3 objects %s: %w", b.URI(prefix), err)
+		}
+		var page struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("list s3 objects %s: %s", b.URI(prefix), s3Status(resp))
+		} else if derr := xml.NewDecoder(io.LimitReader(resp.Body, maxRemoteObjectBytes)).Decode(&page); derr != nil {
+			err = fmt.Errorf("list s3 objects %s: decode response: %w", b.URI(prefix), derr)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range page.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (b *S3Backend) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := *b.endpoint
+	u.Path = b.endpoint.Path + "/" + b.bucket
+	if key != "" {
+		u.Path += "/" + strings.TrimLeft(key, "/")
+	}
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = s3CanonicalQuery(query)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if b.opts.AccessKey != "" {
+		sum := sha256.Sum256(body)
+		signS3Request(req, b.opts, hex.EncodeToString(sum[:]), time.Now())
+	}
+	return b.client.Do(req)
+}
+
+// signS3Request adds AWS Signature Version 4 headers to req, signing the
+// host and every header already set.
+func signS3Request(req *http.Request, opts S3Options, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if opts.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", opts.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3Escape(req.URL.Path, false),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + opts.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + opts.SecretKey)
+	for _, part := range []string{day, opts.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", opts.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vals := append([]string(nil), query[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but unreserved characters, keeping "/"
+// unless encodeSlash is set, as Signature Version 4 requires.
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Status describes a failed response, including the S3 error code when the
+// body carries one.
+func s3Status(resp *http.Response) string {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&e) == nil && e.Code != "" {
+		return fmt.Sprintf("status %d: %s: %s", resp.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("status %d", resp.StatusCode)
+}
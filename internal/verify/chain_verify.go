@@ -5,6 +5,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
 )
 
 type ChainStatement struct {
@@ -13,12 +15,15 @@ type ChainStatement struct {
 	AttestationType string
 	GeneratedAt     string
 	DependsOn       []string
-}
-
-var requiredChainDeps = map[string][]string{
-	"eval_attestation":  {"prompt_attestation", "corpus_attestation"},
-	"route_attestation": {"eval_attestation"},
-	"slo_attestation":   {"route_attestation"},
+	// StatementHash is the signed bundle's statement hash.
+	StatementHash string
+	// DependsOnDigests pins a depends_on type to the statement hash of the
+	// exact upstream bundle the statement was produced from.
+	DependsOnDigests map[string]string
+	// LegacyUnpinned accepts depends_on types without a pin, for statements
+	// that carry no depends_on_digests annotation at all when legacy
+	// statements are allowed. Their edges are reported as unpinned_legacy.
+	LegacyUnpinned bool
 }
 
 func VerifyBasicChainConstraints(statement map[string]any) error {
@@ -33,14 +38,26 @@ func VerifyBasicChainConstraints(statement map[string]any) error {
 }
 
 func VerifyProvenanceChain(statements []ChainStatement) ChainReport {
+	rules, _ := chain.DefaultConfig().Resolve("")
+	return VerifyProvenanceChainWithRules(statements, rules)
+}
+
+// VerifyProvenanceChainWithRules checks statements against resolved chain
+// rules (see chain.Config.Resolve).
+func VerifyProvenanceChainWithRules(statements []ChainStatement, rules []chain.Rule) ChainReport {
 	report := ChainReport{
 		Valid: true,
 		Nodes: make([]ChainNode, 0, len(statements)),
 		Edges: make([]ChainEdge, 0),
+		Rules: rules,
 	}
 	if len(statements) == 0 {
 		return report
 	}
+	ruleByType := make(map[string]chain.Rule, len(rules))
+	for _, r := range rules {
+		ruleByType[r.Type] = r
+	}
 
 	byType := make(map[string][]ChainStatement)
 	byID := make(map[string]ChainStatement)
@@ -60,9 +77,11 @@ func VerifyProvenanceChain(statements []ChainStatement) ChainReport {
 
 	violations := map[string]struct{}{}
 	for _, st := range statements {
-		required := requiredChainDeps[st.AttestationType]
-		if len(required) == 0 {
-			checkUnknownDependencies(st, byType, byID, violations)
+		rule := ruleByType[st.AttestationType]
+		ruleDeps := append(append([]string(nil), rule.Requires...), rule.Optional...)
+		if len(ruleDeps) == 0 {
+			checkUnknownDependencies(st, rule, byType, byID, violations)
+			checkPinnedDependencies(st, nil, byType, violations)
 			continue
 		}
 
@@ -72,16 +91,23 @@ func VerifyProvenanceChain(statements []ChainStatement) ChainReport {
 			continue
 		}
 
-		for _, reqType := range required {
+		for _, reqType := range ruleDeps {
+			optional := contains(rule.Optional, reqType)
 			preds := byType[reqType]
 			edge := ChainEdge{
 				FromStatementID: st.StatementID,
 				FromType:        st.AttestationType,
 				ToType:          reqType,
 				Satisfied:       true,
+				Optional:        optional,
 			}
 
 			if len(preds) == 0 {
+				if optional && st.DependsOnDigests[reqType] == "" {
+					edge.Detail = "optional_absent"
+					report.Edges = append(report.Edges, edge)
+					continue
+				}
 				edge.Satisfied = false
 				edge.Detail = "missing_required_attestation_type"
 				report.Edges = append(report.Edges, edge)
@@ -104,6 +130,11 @@ func VerifyProvenanceChain(statements []ChainStatement) ChainReport {
 						}
 					}
 				}
+				if !matched && optional {
+					edge.Detail = "optional_unreferenced"
+					report.Edges = append(report.Edges, edge)
+					continue
+				}
 				if !matched {
 					edge.Satisfied = false
 					edge.Detail = "missing_dependency_reference"
@@ -113,6 +144,31 @@ func VerifyProvenanceChain(statements []ChainStatement) ChainReport {
 				}
 			}
 
+			// A dependency declared by type must name the exact upstream
+			// bundle; otherwise any bundle of that type would satisfy it.
+			pinned := st.DependsOnDigests[reqType]
+			if pinned == "" && contains(st.DependsOn, reqType) && st.LegacyUnpinned {
+				edge.Detail = "unpinned_legacy"
+			} else if pinned == "" && contains(st.DependsOn, reqType) {
+				edge.Satisfied = false
+				edge.Detail = "unpinned_dependency"
+				report.Edges = append(report.Edges, edge)
+				violations[fmt.Sprintf("unpinned dependency: %s depends on %s but does not pin the upstream bundle", st.StatementID, reqType)] = struct{}{}
+				continue
+			}
+			if pinned != "" {
+				edge.PinnedDigest = pinned
+				pred, ok := findByHash(preds, pinned)
+				if !ok {
+					edge.Satisfied = false
+					edge.Detail = "pinned_predecessor_missing"
+					report.Edges = append(report.Edges, edge)
+					violations[fmt.Sprintf("pinned predecessor missing: %s was produced from %s %s, which is not present", st.StatementID, reqType, pinned)] = struct{}{}
+					continue
+				}
+				target = pred
+			}
+
 			edge.ToStatementID = target.StatementID
 			if target.StatementID == "" {
 				edge.ToStatementID = "(by-type)"
@@ -128,7 +184,8 @@ func VerifyProvenanceChain(statements []ChainStatement) ChainReport {
 			report.Edges = append(report.Edges, edge)
 		}
 
-		checkUnknownDependencies(st, byType, byID, violations)
+		checkUnknownDependencies(st, rule, byType, byID, violations)
+		checkPinnedDependencies(st, ruleDeps, byType, violations)
 	}
 
 	report.Violations = make([]string, 0, len(violations))
@@ -152,10 +209,10 @@ func VerifyProvenanceChain(statements []ChainStatement) ChainReport {
 	return report
 }
 
-func checkUnknownDependencies(st ChainStatement, byType map[string][]ChainStatement, byID map[string]ChainStatement, violations map[string]struct{}) {
+func checkUnknownDependencies(st ChainStatement, rule chain.Rule, byType map[string][]ChainStatement, byID map[string]ChainStatement, violations map[string]struct{}) {
 	for _, dep := range st.DependsOn {
 		dep = strings.TrimSpace(dep)
-		if dep == "" {
+		if dep == "" || contains(rule.Optional, dep) {
 			continue
 		}
 		if _, ok := byType[dep]; ok {
@@ -168,6 +225,28 @@ func checkUnknownDependencies(st ChainStatement, byType map[string][]ChainStatem
 	}
 }
 
+// checkPinnedDependencies covers pins on types outside the required chain
+// edges, which are otherwise never resolved.
+func checkPinnedDependencies(st ChainStatement, required []string, byType map[string][]ChainStatement, violations map[string]struct{}) {
+	for depType, pinned := range st.DependsOnDigests {
+		if contains(required, depType) {
+			continue
+		}
+		if _, ok := findByHash(byType[depType], pinned); !ok {
+			violations[fmt.Sprintf("pinned predecessor missing: %s was produced from %s %s, which is not present", st.StatementID, depType, pinned)] = struct{}{}
+		}
+	}
+}
+
+func findByHash(statements []ChainStatement, statementHash string) (ChainStatement, bool) {
+	for _, st := range statements {
+		if st.StatementHash == statementHash {
+			return st, true
+		}
+	}
+	return ChainStatement{}, false
+}
+
 func ordered(predecessorGeneratedAt string, successorGeneratedAt string) bool {
 	predecessor, err := time.Parse(time.RFC3339, predecessorGeneratedAt)
 	if err != nil {
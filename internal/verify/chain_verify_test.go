@@ -3,6 +3,8 @@ package verify
 import (
 	"strings"
 	"testing"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
 )
 
 func TestVerifyProvenanceChainValid(t *testing.T) {
@@ -11,29 +13,36 @@ func TestVerifyProvenanceChainValid(t *testing.T) {
 			StatementID:     "prompt-1",
 			AttestationType: "prompt_attestation",
 			GeneratedAt:     "2026-02-17T20:10:11Z",
+			StatementHash:   "sha256:prompt",
 		},
 		{
 			StatementID:     "corpus-1",
 			AttestationType: "corpus_attestation",
 			GeneratedAt:     "2026-02-17T20:10:12Z",
+			StatementHash:   "sha256:corpus",
 		},
 		{
-			StatementID:     "eval-1",
-			AttestationType: "eval_attestation",
-			GeneratedAt:     "2026-02-17T20:10:13Z",
-			DependsOn:       []string{"prompt_attestation", "corpus_attestation"},
+			StatementID:      "eval-1",
+			AttestationType:  "eval_attestation",
+			GeneratedAt:      "2026-02-17T20:10:13Z",
+			StatementHash:    "sha256:eval",
+			DependsOn:        []string{"prompt_attestation", "corpus_attestation"},
+			DependsOnDigests: map[string]string{"prompt_attestation": "sha256:prompt", "corpus_attestation": "sha256:corpus"},
 		},
 		{
-			StatementID:     "route-1",
-			AttestationType: "route_attestation",
-			GeneratedAt:     "2026-02-17T20:10:14Z",
-			DependsOn:       []string{"eval_attestation"},
+			StatementID:      "route-1",
+			AttestationType:  "route_attestation",
+			GeneratedAt:      "2026-02-17T20:10:14Z",
+			StatementHash:    "sha256:route",
+			DependsOn:        []string{"eval_attestation"},
+			DependsOnDigests: map[string]string{"eval_attestation": "sha256:eval"},
 		},
 		{
-			StatementID:     "slo-1",
-			AttestationType: "slo_attestation",
-			GeneratedAt:     "2026-02-17T20:10:15Z",
-			DependsOn:       []string{"route_attestation"},
+			StatementID:      "slo-1",
+			AttestationType:  "slo_attestation",
+			GeneratedAt:      "2026-02-17T20:10:15Z",
+			DependsOn:        []string{"route_attestation"},
+			DependsOnDigests: map[string]string{"route_attestation": "sha256:route"},
 		},
 	})
 
@@ -45,6 +54,36 @@ func TestVerifyProvenanceChainValid(t *testing.T) {
 	}
 }
 
+func TestVerifyProvenanceChainUnpinnedDependency(t *testing.T) {
+	report := VerifyProvenanceChain([]ChainStatement{
+		{StatementID: "eval-1", AttestationType: "eval_attestation", GeneratedAt: "2026-02-17T20:10:13Z", StatementHash: "sha256:eval"},
+		{StatementID: "route-1", AttestationType: "route_attestation", GeneratedAt: "2026-02-17T20:10:14Z", DependsOn: []string{"eval_attestation"}},
+	})
+	if report.Valid || !containsViolation(report.Violations, "unpinned dependency: route-1 depends on eval_attestation") {
+		t.Fatalf("expected unpinned dependency violation, got %v", report.Violations)
+	}
+	for _, e := range report.Edges {
+		if e.ToType == "eval_attestation" && (e.Satisfied || e.Detail != "unpinned_dependency") {
+			t.Fatalf("expected unsatisfied unpinned edge, got %+v", e)
+		}
+	}
+
+	// A legacy statement, signed without depends_on_digests, may be
+	// accepted by type when the caller allows it.
+	report = VerifyProvenanceChain([]ChainStatement{
+		{StatementID: "eval-1", AttestationType: "eval_attestation", GeneratedAt: "2026-02-17T20:10:13Z", StatementHash: "sha256:eval"},
+		{StatementID: "route-1", AttestationType: "route_attestation", GeneratedAt: "2026-02-17T20:10:14Z", DependsOn: []string{"eval_attestation"}, LegacyUnpinned: true},
+	})
+	if containsViolation(report.Violations, "unpinned dependency") {
+		t.Fatalf("expected legacy unpinned edge to pass, got %v", report.Violations)
+	}
+	for _, e := range report.Edges {
+		if e.ToType == "eval_attestation" && (!e.Satisfied || e.Detail != "unpinned_legacy" || e.ToStatementID != "eval-1") {
+			t.Fatalf("expected satisfied legacy edge to eval-1, got %+v", e)
+		}
+	}
+}
+
 func TestVerifyProvenanceChainMissingPredecessor(t *testing.T) {
 	report := VerifyProvenanceChain([]ChainStatement{
 		{
@@ -192,3 +231,121 @@ func containsViolation(violations []string, needle string) bool {
 	}
 	return false
 }
+
+func TestVerifyProvenanceChainPinnedPredecessor(t *testing.T) {
+	statements := []ChainStatement{
+		{StatementID: "prompt-old", AttestationType: "prompt_attestation", GeneratedAt: "2026-02-17T20:10:10Z", StatementHash: "sha256:old"},
+		{StatementID: "prompt-new", AttestationType: "prompt_attestation", GeneratedAt: "2026-02-17T20:10:11Z", StatementHash: "sha256:new"},
+		{StatementID: "corpus-1", AttestationType: "corpus_attestation", GeneratedAt: "2026-02-17T20:10:12Z", StatementHash: "sha256:corpus"},
+		{
+			StatementID:      "eval-1",
+			AttestationType:  "eval_attestation",
+			GeneratedAt:      "2026-02-17T20:10:13Z",
+			DependsOn:        []string{"corpus_attestation", "prompt_attestation"},
+			DependsOnDigests: map[string]string{"prompt_attestation": "sha256:new", "corpus_attestation": "sha256:corpus"},
+		},
+	}
+	report := VerifyProvenanceChain(statements)
+	if !report.Valid {
+		t.Fatalf("expected valid pinned chain, got %v", report.Violations)
+	}
+	for _, e := range report.Edges {
+		if e.ToType == "prompt_attestation" && (e.ToStatementID != "prompt-new" || e.PinnedDigest != "sha256:new") {
+			t.Fatalf("expected edge to resolve the pinned prompt, got %+v", e)
+		}
+	}
+}
+
+func TestVerifyProvenanceChainPinnedPredecessorMismatch(t *testing.T) {
+	report := VerifyProvenanceChain([]ChainStatement{
+		{StatementID: "prompt-v2", AttestationType: "prompt_attestation", GeneratedAt: "2026-02-17T20:10:11Z", StatementHash: "sha256:v2"},
+		{StatementID: "corpus-1", AttestationType: "corpus_attestation", GeneratedAt: "2026-02-17T20:10:12Z", StatementHash: "sha256:corpus"},
+		{
+			StatementID:      "eval-1",
+			AttestationType:  "eval_attestation",
+			GeneratedAt:      "2026-02-17T20:10:13Z",
+			DependsOn:        []string{"corpus_attestation", "prompt_attestation"},
+			DependsOnDigests: map[string]string{"prompt_attestation": "sha256:v1"},
+		},
+	})
+	if report.Valid {
+		t.Fatal("expected eval run against a different prompt version to fail")
+	}
+	if !containsViolation(report.Violations, "pinned predecessor missing: eval-1 was produced from prompt_attestation sha256:v1") {
+		t.Fatalf("unexpected violations: %v", report.Violations)
+	}
+	for _, e := range report.Edges {
+		if e.ToType == "prompt_attestation" && (e.Satisfied || e.Detail != "pinned_predecessor_missing") {
+			t.Fatalf("expected unsatisfied pinned edge, got %+v", e)
+		}
+	}
+}
+
+func TestVerifyProvenanceChainPinnedNonRequiredType(t *testing.T) {
+	report := VerifyProvenanceChain([]ChainStatement{
+		{StatementID: "prompt-1", AttestationType: "prompt_attestation", GeneratedAt: "2026-02-17T20:10:11Z", StatementHash: "sha256:p"},
+		{
+			StatementID:      "custom-1",
+			AttestationType:  "custom_attestation",
+			GeneratedAt:      "2026-02-17T20:10:12Z",
+			DependsOn:        []string{"prompt_attestation"},
+			DependsOnDigests: map[string]string{"prompt_attestation": "sha256:other"},
+		},
+	})
+	if report.Valid || !containsViolation(report.Violations, "pinned predecessor missing: custom-1") {
+		t.Fatalf("expected pinned violation for custom type, got %v", report.Violations)
+	}
+}
+
+func TestVerifyProvenanceChainOptionalEdgeAbsent(t *testing.T) {
+	rules := []chain.Rule{{Type: "eval_attestation", Requires: []string{"prompt_attestation"}, Optional: []string{"corpus_attestation"}}}
+	report := VerifyProvenanceChainWithRules([]ChainStatement{
+		{StatementID: "prompt-1", AttestationType: "prompt_attestation", GeneratedAt: "2026-02-17T20:10:11Z", StatementHash: "sha256:p"},
+		{StatementID: "eval-1", AttestationType: "eval_attestation", GeneratedAt: "2026-02-17T20:10:13Z", DependsOn: []string{"prompt_attestation", "corpus_attestation"}, DependsOnDigests: map[string]string{"prompt_attestation": "sha256:p"}},
+	}, rules)
+	if !report.Valid {
+		t.Fatalf("expected valid chain without optional corpus, got %v", report.Violations)
+	}
+	var found bool
+	for _, e := range report.Edges {
+		if e.ToType == "corpus_attestation" {
+			found = true
+			if !e.Optional || !e.Satisfied || e.Detail != "optional_absent" {
+				t.Fatalf("unexpected optional edge: %+v", e)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected optional corpus edge in report")
+	}
+	if len(report.Rules) != 1 {
+		t.Fatalf("expected effective rules in report, got %+v", report.Rules)
+	}
+}
+
+func TestVerifyProvenanceChainOptionalEdgePresentIsChecked(t *testing.T) {
+	rules := []chain.Rule{{Type: "eval_attestation", Optional: []string{"corpus_attestation"}}}
+	report := VerifyProvenanceChainWithRules([]ChainStatement{
+		{StatementID: "corpus-1", AttestationType: "corpus_attestation", GeneratedAt: "2026-02-17T20:10:14Z", StatementHash: "sha256:c"},
+		{StatementID: "eval-1", AttestationType: "eval_attestation", GeneratedAt: "2026-02-17T20:10:13Z", DependsOn: []string{"corpus_attestation"}, DependsOnDigests: map[string]string{"corpus_attestation": "sha256:c"}},
+	}, rules)
+	if report.Valid {
+		t.Fatal("expected ordering violation on present optional predecessor")
+	}
+	if !strings.Contains(strings.Join(report.Violations, ";"), "invalid chain order") {
+		t.Fatalf("unexpected violations: %v", report.Violations)
+	}
+}
+
+func TestVerifyProvenanceChainCustomType(t *testing.T) {
+	rules := []chain.Rule{{Type: "guardrail_attestation", Requires: []string{"prompt_attestation"}}}
+	report := VerifyProvenanceChainWithRules([]ChainStatement{
+		{StatementID: "guard-1", AttestationType: "guardrail_attestation", GeneratedAt: "2026-02-17T20:10:13Z", DependsOn: []string{"prompt_attestation"}},
+	}, rules)
+	if report.Valid {
+		t.Fatal("expected missing predecessor for custom type")
+	}
+	if !strings.Contains(strings.Join(report.Violations, ";"), "guardrail_attestation requires prompt_attestation") {
+		t.Fatalf("unexpected violations: %v", report.Violations)
+	}
+}
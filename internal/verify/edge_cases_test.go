@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
 )
@@ -51,7 +52,7 @@ func TestCheckUnknownDependenciesWhitespaceDep(t *testing.T) {
 		DependsOn:       []string{"  ", "", "\t"},
 	}
 
-	checkUnknownDependencies(st, byType, byID, violations)
+	checkUnknownDependencies(st, chain.Rule{}, byType, byID, violations)
 	if len(violations) != 0 {
 		t.Fatalf("expected 0 violations for whitespace deps, got %d: %v", len(violations), violations)
 	}
@@ -8,6 +8,8 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/semantic"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
 )
 
@@ -15,6 +17,18 @@ type Options struct {
 	SourcePath   string
 	SchemaDir    string
 	SignerPolicy SignerPolicy
+	Subjects     SubjectOptions
+	// Chain overrides the built-in provenance chain rules; Service selects
+	// its per-service overrides.
+	Chain   *chain.Config
+	Service string
+	// Semantic holds policy rejections for eval regressions and SLO limits.
+	Semantic semantic.Policy
+	// AllowLegacyStatements accepts statements signed before predicate
+	// binding existed, reporting the check as skipped instead of failing,
+	// and statements without depends_on_digests, whose depends_on types
+	// then match by type (see ChainStatement.LegacyUnpinned).
+	AllowLegacyStatements bool
 }
 
 func Run(opts Options) Report {
@@ -71,11 +85,29 @@ func Run(opts Options) Report {
 		}
 		report.Checks = append(report.Checks, CheckResult{Bundle: p, Check: "chain", Passed: true, Message: "ok"})
 
-		if err := VerifySubjects(statement); err != nil {
+		skipped, err := VerifySubjectsWithOptions(statement, opts.Subjects)
+		if err != nil {
 			report.addFailure(p, "subject_digest", ExitDigestMismatch, err)
 			continue
 		}
-		report.Checks = append(report.Checks, CheckResult{Bundle: p, Check: "subject_digest", Passed: true, Message: "ok"})
+		report.Checks = append(report.Checks, CheckResult{Bundle: p, Check: "subject_digest", Passed: true, Message: subjectCheckMessage(opts.Subjects.Mode, skipped)})
+
+		if predatesPredicateBinding(statement) {
+			if !opts.AllowLegacyStatements {
+				report.addFailure(p, "predicate_binding", ExitDigestMismatch, fmt.Errorf("statement has no %s annotation; it predates predicate binding or was edited (allow legacy statements to skip the check)", predicateBindingAnnotation))
+				continue
+			}
+			report.Checks = append(report.Checks, CheckResult{Bundle: p, Check: "predicate_binding", Skipped: true, Message: "skipped (statement predates predicate binding)"})
+		} else if err := VerifyPredicateBinding(statement); err != nil {
+			report.addFailure(p, "predicate_binding", ExitDigestMismatch, err)
+			continue
+		} else {
+			report.Checks = append(report.Checks, CheckResult{Bundle: p, Check: "predicate_binding", Passed: true, Message: "ok"})
+		}
+
+		if !report.runSemanticChecks(p, statement, opts.Semantic) {
+			continue
+		}
 
 		dependsOn := dependsOn(statement)
 		report.Statements = append(report.Statements, StatementSummary{
@@ -85,17 +117,30 @@ func Run(opts Options) Report {
 			DependsOn:       dependsOn,
 			GeneratedAt:     asString(statement["generated_at"]),
 		})
+		digests := dependsOnDigests(statement)
 		chainStatements = append(chainStatements, ChainStatement{
-			Bundle:          p,
-			StatementID:     asString(statement["statement_id"]),
-			AttestationType: asString(statement["attestation_type"]),
-			GeneratedAt:     asString(statement["generated_at"]),
-			DependsOn:       dependsOn,
+			Bundle:           p,
+			StatementID:      asString(statement["statement_id"]),
+			AttestationType:  asString(statement["attestation_type"]),
+			GeneratedAt:      asString(statement["generated_at"]),
+			DependsOn:        dependsOn,
+			StatementHash:    bundle.Metadata.StatementHash,
+			DependsOnDigests: digests,
+			LegacyUnpinned:   opts.AllowLegacyStatements && digests == nil,
 		})
 	}
 
 	if report.Passed {
-		report.Chain = VerifyProvenanceChain(chainStatements)
+		chainConfig := chain.DefaultConfig()
+		if opts.Chain != nil {
+			chainConfig = *opts.Chain
+		}
+		rules, err := chainConfig.Resolve(opts.Service)
+		if err != nil {
+			report.addFailure("<all>", "chain_graph", ExitSchemaFail, fmt.Errorf("chain rules: %w", err))
+			return report
+		}
+		report.Chain = VerifyProvenanceChainWithRules(chainStatements, rules)
 		if report.Chain.Valid {
 			report.Checks = append(report.Checks, CheckResult{Bundle: "<all>", Check: "chain_graph", Passed: true, Message: "ok"})
 		} else {
@@ -115,6 +160,44 @@ func Run(opts Options) Report {
 	return report
 }
 
+// runSemanticChecks recomputes eval regression, checks the SLO window, and
+// applies the semantic policy. It returns false after recording a failure.
+func (r *Report) runSemanticChecks(bundle string, statement map[string]any, policy semantic.Policy) bool {
+	switch asString(statement["attestation_type"]) {
+	case "eval_attestation":
+		if err := VerifyEvalConsistency(statement); err != nil {
+			r.addFailure(bundle, "eval_consistency", ExitSchemaFail, err)
+			return false
+		}
+		r.Checks = append(r.Checks, CheckResult{Bundle: bundle, Check: "eval_consistency", Passed: true, Message: "ok"})
+	case "slo_attestation":
+		if err := VerifySLOWindow(statement); err != nil {
+			r.addFailure(bundle, "slo_window", ExitSchemaFail, err)
+			return false
+		}
+		r.Checks = append(r.Checks, CheckResult{Bundle: bundle, Check: "slo_window", Passed: true, Message: "ok"})
+	}
+	check, err := VerifySemanticPolicy(statement, policy)
+	if err != nil {
+		r.addFailure(bundle, check, ExitPolicyFail, err)
+		return false
+	}
+	if check != "" {
+		r.Checks = append(r.Checks, CheckResult{Bundle: bundle, Check: check, Passed: true, Message: "ok"})
+	}
+	return true
+}
+
+func subjectCheckMessage(mode string, skipped []string) string {
+	if mode == SubjectsSkip {
+		return "skipped (subjects=skip)"
+	}
+	if len(skipped) > 0 {
+		return fmt.Sprintf("ok (%d unavailable subject(s) skipped: %s)", len(skipped), strings.Join(skipped, ", "))
+	}
+	return "ok"
+}
+
 func (r *Report) addFailure(bundle, check string, exit int, err error) {
 	r.Passed = false
 	if r.ExitCode == ExitPass || exit > r.ExitCode {
@@ -187,3 +270,22 @@ func dependsOn(statement map[string]any) []string {
 	sort.Strings(out)
 	return out
 }
+
+// dependsOnDigests parses the depends_on_digests annotation
+// ("type=sha256:<hex>,...") written by attest create.
+func dependsOnDigests(statement map[string]any) map[string]string {
+	annotations, _ := statement["annotations"].(map[string]any)
+	raw, _ := annotations["depends_on_digests"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	out := map[string]string{}
+	for _, part := range strings.Split(raw, ",") {
+		depType, digest, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || depType == "" || digest == "" {
+			continue
+		}
+		out[depType] = digest
+	}
+	return out
+}
@@ -9,7 +9,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/semantic"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
 )
 
@@ -93,7 +95,7 @@ func TestRunChainGraphFailure(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if _, err := writeBundleForStatement(tmp, signer, map[string]any{
+	if _, err := writeBundleForStatement(tmp, signer, bindPredicate(map[string]any{
 		"schema_version":   "1.0.0",
 		"statement_id":     "prompt-1",
 		"attestation_type": "prompt_attestation",
@@ -109,10 +111,10 @@ func TestRunChainGraphFailure(t *testing.T) {
 			"safety_policy_digest": "sha256:safety",
 		},
 		"privacy": map[string]any{"mode": "hash_only"},
-	}); err != nil {
+	})); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := writeBundleForStatement(tmp, signer, map[string]any{
+	if _, err := writeBundleForStatement(tmp, signer, bindPredicate(map[string]any{
 		"schema_version":   "1.0.0",
 		"statement_id":     "slo-1",
 		"attestation_type": "slo_attestation",
@@ -134,13 +136,14 @@ func TestRunChainGraphFailure(t *testing.T) {
 		"annotations": map[string]any{
 			"depends_on": "route_attestation",
 		},
-	}); err != nil {
+	})); err != nil {
 		t.Fatal(err)
 	}
 
 	report := Run(Options{
 		SourcePath: tmp,
 		SchemaDir:  "../../schemas/v1",
+		Subjects:   boundSubjects,
 	})
 	if report.ExitCode != ExitSchemaFail {
 		t.Fatalf("expected schema/chain failure exit code %d, got %d", ExitSchemaFail, report.ExitCode)
@@ -151,6 +154,31 @@ func TestRunChainGraphFailure(t *testing.T) {
 	if len(report.Chain.Violations) == 0 {
 		t.Fatalf("expected chain violations")
 	}
+
+	// A service override making route optional for slo accepts the same bundles.
+	lite := chain.DefaultConfig()
+	lite.Services = map[string][]chain.Rule{
+		"lite": {{Type: "slo_attestation", Optional: []string{"route_attestation"}}},
+	}
+	report = Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1", Subjects: boundSubjects, Chain: &lite, Service: "lite"})
+	if !report.Passed || !report.Chain.Valid {
+		t.Fatalf("expected service override to pass, got %+v", report.Chain)
+	}
+
+	// A misspelt service must not fall back to the base rules.
+	report = Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1", Subjects: boundSubjects, Chain: &lite, Service: "litte"})
+	if report.Passed || report.ExitCode != ExitSchemaFail || !strings.Contains(strings.Join(report.Violations, ";"), `unknown chain service "litte"`) {
+		t.Fatalf("expected unknown service to fail, got %+v", report.Violations)
+	}
+
+	cyclic := chain.Config{Rules: []chain.Rule{
+		{Type: "prompt_attestation", Requires: []string{"slo_attestation"}},
+		{Type: "slo_attestation", Requires: []string{"prompt_attestation"}},
+	}}
+	report = Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1", Subjects: boundSubjects, Chain: &cyclic})
+	if report.Passed || report.ExitCode != ExitSchemaFail || !strings.Contains(strings.Join(report.Violations, ";"), "cycle") {
+		t.Fatalf("expected cyclic chain rules to fail, got %+v", report.Violations)
+	}
 }
 
 func TestBundlePathsSingleFileAndHelpers(t *testing.T) {
@@ -202,8 +230,11 @@ func TestWriteJSON(t *testing.T) {
 	}
 }
 
-func TestRunFullChainFiveBundles(t *testing.T) {
-	tmp := t.TempDir()
+// writeFiveBundleChain signs the prompt to SLO chain into tmp. Without pin,
+// statements declare depends_on but carry no depends_on_digests, as bundles
+// signed before pinning do.
+func writeFiveBundleChain(t *testing.T, tmp string, pin bool) {
+	t.Helper()
 	keyPath := filepath.Join(tmp, "dev.pem")
 	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
 		t.Fatal(err)
@@ -225,31 +256,31 @@ func TestRunFullChainFiveBundles(t *testing.T) {
 			"safety_policy_digest": "sha256:safety",
 		}, "", 0},
 		{"corpus-1", "corpus_attestation", "https://llmsa.dev/attestation/corpus/v1", map[string]any{
-			"corpus_snapshot_id":       "rag-v1",
-			"connector_config_digests": []any{map[string]any{"name": "file-connector", "digest": "sha256:conn"}},
-			"document_manifest_digest": "sha256:manifest",
-			"chunking_config_digest":   "sha256:chunk",
-			"embedding_model":          "text-embedding-3-small",
-			"embedding_input_digest":   "sha256:embed-in",
+			"corpus_snapshot_id":         "rag-v1",
+			"connector_config_digests":   []any{map[string]any{"name": "file-connector", "digest": "sha256:conn"}},
+			"document_manifest_digest":   "sha256:manifest",
+			"chunking_config_digest":     "sha256:chunk",
+			"embedding_model":            "text-embedding-3-small",
+			"embedding_input_digest":     "sha256:embed-in",
 			"index_builder_image_digest": "sha256:builder",
-			"vector_index_digest":      "sha256:index",
+			"vector_index_digest":        "sha256:index",
 		}, "", 1},
 		{"eval-1", "eval_attestation", "https://llmsa.dev/attestation/eval/v1", map[string]any{
-			"eval_suite_id":          "suite-1",
-			"testset_digest":         "sha256:testset",
-			"scoring_config_digest":  "sha256:scoring",
-			"baseline_result_digest": "sha256:baseline",
+			"eval_suite_id":           "suite-1",
+			"testset_digest":          "sha256:testset",
+			"scoring_config_digest":   "sha256:scoring",
+			"baseline_result_digest":  "sha256:baseline",
 			"candidate_result_digest": "sha256:candidate",
-			"metrics":               map[string]any{"accuracy": 0.95},
-			"thresholds":            map[string]any{"accuracy_min": 0.9},
-			"regression_detected":   false,
+			"metrics":                 map[string]any{"accuracy": 0.95},
+			"thresholds":              map[string]any{"accuracy_min": 0.9},
+			"regression_detected":     false,
 		}, "prompt_attestation, corpus_attestation", 2},
 		{"route-1", "route_attestation", "https://llmsa.dev/attestation/route/v1", map[string]any{
-			"route_config_digest":  "sha256:routecfg",
-			"provider_set":        []any{map[string]any{"provider": "openai", "model": "gpt-4"}},
-			"budget_policy_digest": "sha256:budget",
+			"route_config_digest":   "sha256:routecfg",
+			"provider_set":          []any{map[string]any{"provider": "openai", "model": "gpt-4"}},
+			"budget_policy_digest":  "sha256:budget",
 			"fallback_graph_digest": "sha256:fallback",
-			"routing_strategy":     "rules",
+			"routing_strategy":      "rules",
 		}, "eval_attestation", 3},
 		{"slo-1", "slo_attestation", "https://llmsa.dev/attestation/slo/v1", map[string]any{
 			"slo_profile_id": "prod", "window": map[string]any{"start": "2026-02-18T00:00:00Z", "end": "2026-02-18T01:00:00Z"},
@@ -258,6 +289,7 @@ func TestRunFullChainFiveBundles(t *testing.T) {
 		}, "route_attestation", 4},
 	}
 
+	hashes := map[string]string{}
 	for _, tt := range types {
 		ts := time.Date(2026, 2, 18, 0, 0, tt.timeOffset, 0, time.UTC).Format(time.RFC3339)
 		stmt := map[string]any{
@@ -272,14 +304,34 @@ func TestRunFullChainFiveBundles(t *testing.T) {
 			"privacy":          map[string]any{"mode": "hash_only"},
 		}
 		if tt.dependsOn != "" {
-			stmt["annotations"] = map[string]any{"depends_on": tt.dependsOn}
+			var pins []string
+			for _, dep := range strings.Split(tt.dependsOn, ",") {
+				dep = strings.TrimSpace(dep)
+				pins = append(pins, dep+"="+hashes[dep])
+			}
+			annotations := map[string]any{"depends_on": tt.dependsOn}
+			if pin {
+				annotations["depends_on_digests"] = strings.Join(pins, ",")
+			}
+			stmt["annotations"] = annotations
 		}
-		if _, err := writeBundleForStatement(tmp, signer, stmt); err != nil {
+		path, err := writeBundleForStatement(tmp, signer, bindPredicate(stmt))
+		if err != nil {
 			t.Fatal(err)
 		}
+		bundle, err := sign.ReadBundle(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[tt.attType] = bundle.Metadata.StatementHash
 	}
+}
 
-	report := Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1"})
+func TestRunFullChainFiveBundles(t *testing.T) {
+	tmp := t.TempDir()
+	writeFiveBundleChain(t, tmp, true)
+
+	report := Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1", Subjects: boundSubjects})
 	if !report.Passed {
 		t.Fatalf("expected passed, got exit %d: %v", report.ExitCode, report.Violations)
 	}
@@ -300,6 +352,26 @@ func TestRunFullChainFiveBundles(t *testing.T) {
 	}
 }
 
+func TestRunFullChainLegacyUnpinned(t *testing.T) {
+	tmp := t.TempDir()
+	writeFiveBundleChain(t, tmp, false)
+
+	report := Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1", Subjects: boundSubjects})
+	if report.Passed || !containsViolation(report.Chain.Violations, "unpinned dependency") {
+		t.Fatalf("expected unpinned dependency failure, got passed=%v: %v", report.Passed, report.Chain.Violations)
+	}
+
+	report = Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1", Subjects: boundSubjects, AllowLegacyStatements: true})
+	if !report.Passed {
+		t.Fatalf("expected legacy statements to pass, got exit %d: %v", report.ExitCode, report.Violations)
+	}
+	for _, edge := range report.Chain.Edges {
+		if !edge.Satisfied || edge.Detail != "unpinned_legacy" {
+			t.Errorf("edge %s→%s: satisfied=%v detail=%q, want unpinned_legacy", edge.FromType, edge.ToType, edge.Satisfied, edge.Detail)
+		}
+	}
+}
+
 func TestRunSignatureCorruption(t *testing.T) {
 	tmp := t.TempDir()
 	keyPath := filepath.Join(tmp, "dev.pem")
@@ -440,6 +512,19 @@ func TestDependsOnHelpers(t *testing.T) {
 	}
 }
 
+func TestDependsOnDigestsHelper(t *testing.T) {
+	stmt := map[string]any{"annotations": map[string]any{
+		"depends_on_digests": "corpus_attestation=sha256:aa, prompt_attestation=sha256:bb,malformed",
+	}}
+	got := dependsOnDigests(stmt)
+	if len(got) != 2 || got["corpus_attestation"] != "sha256:aa" || got["prompt_attestation"] != "sha256:bb" {
+		t.Fatalf("unexpected pins: %v", got)
+	}
+	if dependsOnDigests(map[string]any{}) != nil {
+		t.Fatal("expected nil pins without annotation")
+	}
+}
+
 func writeBundleForStatement(dir string, signer *sign.PEMSigner, statement map[string]any) (string, error) {
 	canonical, err := hash.CanonicalJSON(statement)
 	if err != nil {
@@ -459,3 +544,224 @@ func writeBundleForStatement(dir string, signer *sign.PEMSigner, statement map[s
 	}
 	return path, nil
 }
+
+func TestRunSubjectRootAndModes(t *testing.T) {
+	tmp := t.TempDir()
+	bundles := filepath.Join(tmp, "bundles")
+	os.MkdirAll(bundles, 0o755)
+	os.WriteFile(filepath.Join(tmp, "prompt.txt"), []byte("original content"), 0o644)
+	fileDigest, _, _ := hash.DigestFile(filepath.Join(tmp, "prompt.txt"))
+
+	keyPath := filepath.Join(tmp, "dev.pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sign.NewPEMSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A legacy statement without the predicate_binding annotation, accepted
+	// with AllowLegacyStatements, so only subject resolution is checked.
+	_, err = writeBundleForStatement(bundles, signer, map[string]any{
+		"schema_version":   "1.0.0",
+		"statement_id":     "p-1",
+		"attestation_type": "prompt_attestation",
+		"predicate_type":   "https://llmsa.dev/attestation/prompt/v1",
+		"generated_at":     "2026-02-18T00:00:00Z",
+		"generator":        map[string]any{"name": "llmsa", "version": "1.0.0", "git_sha": "abc"},
+		"subject": []any{
+			map[string]any{
+				"name": "prompt.txt", "uri": "prompt.txt",
+				"digest": map[string]any{"sha256": strings.TrimPrefix(fileDigest, "sha256:")}, "size_bytes": 16,
+			},
+		},
+		"predicate": map[string]any{
+			"prompt_bundle_digest": "sha256:b", "system_prompt_digest": "sha256:s",
+			"template_digests": []any{"sha256:t"}, "tool_schema_digests": []any{"sha256:w"},
+			"safety_policy_digest": "sha256:v",
+		},
+		"privacy": map[string]any{"mode": "hash_only"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Run(Options{SourcePath: bundles, SchemaDir: "../../schemas/v1", Subjects: SubjectOptions{Root: tmp}, AllowLegacyStatements: true})
+	if !report.Passed {
+		t.Fatalf("expected pass with subject root: %v", report.Violations)
+	}
+
+	os.Remove(filepath.Join(tmp, "prompt.txt"))
+	report = Run(Options{SourcePath: bundles, SchemaDir: "../../schemas/v1", Subjects: SubjectOptions{Root: tmp}, AllowLegacyStatements: true})
+	if report.ExitCode != ExitDigestMismatch {
+		t.Fatalf("expected required mode to fail on missing subject, got %d", report.ExitCode)
+	}
+
+	for _, mode := range []string{SubjectsOptional, SubjectsSkip} {
+		report = Run(Options{SourcePath: bundles, SchemaDir: "../../schemas/v1", Subjects: SubjectOptions{Root: tmp, Mode: mode}, AllowLegacyStatements: true})
+		if !report.Passed {
+			t.Fatalf("%s: expected pass without local subjects: %v", mode, report.Violations)
+		}
+		for _, c := range report.Checks {
+			if c.Check == "subject_digest" && !strings.Contains(c.Message, "skipped") {
+				t.Fatalf("%s: expected skipped message, got %q", mode, c.Message)
+			}
+		}
+	}
+}
+
+func TestRunPredicateBindingFailure(t *testing.T) {
+	tmp := t.TempDir()
+	keyPath := filepath.Join(tmp, "dev.pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sign.NewPEMSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := bindPredicate(map[string]any{
+		"schema_version":   "1.0.0",
+		"statement_id":     "prompt-1",
+		"attestation_type": "prompt_attestation",
+		"predicate_type":   "https://llmsa.dev/attestation/prompt/v1",
+		"generated_at":     "2026-02-18T00:00:00Z",
+		"generator":        map[string]any{"name": "llmsa", "version": "1.0.0", "git_sha": "abc"},
+		"subject":          []any{},
+		"predicate": map[string]any{
+			"system_prompt_digest": "sha256:system",
+			"template_digests":     []any{"sha256:template"},
+			"tool_schema_digests":  []any{"sha256:tool"},
+			"safety_policy_digest": "sha256:safety",
+		},
+		"privacy": map[string]any{"mode": "hash_only"},
+	})
+	// Hand-edit the predicate after binding so it no longer matches a material.
+	stmt["predicate"].(map[string]any)["safety_policy_digest"] = "sha256:edited"
+	if _, err := writeBundleForStatement(tmp, signer, stmt); err != nil {
+		t.Fatal(err)
+	}
+
+	report := Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1", Subjects: boundSubjects})
+	if report.ExitCode != ExitDigestMismatch {
+		t.Fatalf("expected exit %d, got %d: %v", ExitDigestMismatch, report.ExitCode, report.Violations)
+	}
+	found := false
+	for _, c := range report.Checks {
+		if c.Check == "predicate_binding" && !c.Passed && strings.Contains(c.Message, "safety_policy_digest") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected failed predicate_binding check, got %+v", report.Checks)
+	}
+}
+
+func TestRunPredicateBindingLegacyStatement(t *testing.T) {
+	tmp := t.TempDir()
+	keyPath := filepath.Join(tmp, "dev.pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sign.NewPEMSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signed before collectors recorded canary configs as materials: no
+	// predicate_binding annotation and an unbound canary_config_digest.
+	stmt := bindPredicate(map[string]any{
+		"schema_version":   "1.0.0",
+		"statement_id":     "route-1",
+		"attestation_type": "route_attestation",
+		"predicate_type":   "https://llmsa.dev/attestation/route/v1",
+		"generated_at":     "2026-02-18T00:00:00Z",
+		"generator":        map[string]any{"name": "llmsa", "version": "0.1.0", "git_sha": "abc"},
+		"subject":          []any{},
+		"predicate": map[string]any{
+			"route_config_digest":   "sha256:routecfg",
+			"provider_set":          []any{map[string]any{"provider": "openai", "model": "gpt-4"}},
+			"budget_policy_digest":  "sha256:budget",
+			"fallback_graph_digest": "sha256:fallback",
+			"routing_strategy":      "rules",
+		},
+		"privacy": map[string]any{"mode": "hash_only"},
+	})
+	delete(stmt, "annotations")
+	stmt["predicate"].(map[string]any)["canary_config_digest"] = "sha256:canary"
+	if _, err := writeBundleForStatement(tmp, signer, stmt); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dropping the annotation must not switch the check off.
+	report := Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1", Subjects: boundSubjects})
+	if report.Passed || report.ExitCode != ExitDigestMismatch {
+		t.Fatalf("expected a statement without the annotation to fail, got exit %d %v", report.ExitCode, report.Violations)
+	}
+
+	report = Run(Options{SourcePath: tmp, SchemaDir: "../../schemas/v1", Subjects: boundSubjects, AllowLegacyStatements: true})
+	if !report.Passed {
+		t.Fatalf("expected legacy statement to pass when allowed, got %v", report.Violations)
+	}
+	for _, c := range report.Checks {
+		if c.Check == "predicate_binding" && (c.Passed || !c.Skipped || !strings.Contains(c.Message, "predates predicate binding")) {
+			t.Fatalf("expected a skipped, not passed, predicate_binding check, got %+v", c)
+		}
+	}
+}
+
+func TestRunSemanticChecks(t *testing.T) {
+	tmp := t.TempDir()
+	keyPath := filepath.Join(tmp, "dev.pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sign.NewPEMSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeEval := func(dir string, accuracy float64, regression bool) {
+		t.Helper()
+		if _, err := writeBundleForStatement(dir, signer, bindPredicate(map[string]any{
+			"schema_version":   "1.0.0",
+			"statement_id":     "eval-1",
+			"attestation_type": "eval_attestation",
+			"predicate_type":   "https://llmsa.dev/attestation/eval/v1",
+			"generated_at":     "2026-02-18T00:00:00Z",
+			"generator":        map[string]any{"name": "llmsa", "version": "1.0.0", "git_sha": "abc"},
+			"subject":          []any{},
+			"predicate": map[string]any{
+				"eval_suite_id":           "suite-1",
+				"testset_digest":          "sha256:testset",
+				"scoring_config_digest":   "sha256:scoring",
+				"baseline_result_digest":  "sha256:baseline",
+				"candidate_result_digest": "sha256:candidate",
+				"metrics":                 map[string]any{"accuracy": accuracy},
+				"thresholds":              map[string]any{"accuracy_min": 0.9},
+				"regression_detected":     regression,
+			},
+			"privacy": map[string]any{"mode": "hash_only"},
+		})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inconsistent := filepath.Join(tmp, "inconsistent")
+	os.MkdirAll(inconsistent, 0o755)
+	writeEval(inconsistent, 0.8, false)
+	report := Run(Options{SourcePath: inconsistent, SchemaDir: "../../schemas/v1", Subjects: boundSubjects})
+	if report.ExitCode != ExitSchemaFail || !strings.Contains(strings.Join(report.Violations, ";"), "eval_consistency") {
+		t.Fatalf("expected eval_consistency failure, got %d %v", report.ExitCode, report.Violations)
+	}
+
+	regressed := filepath.Join(tmp, "regressed")
+	os.MkdirAll(regressed, 0o755)
+	writeEval(regressed, 0.8, true)
+	report = Run(Options{SourcePath: regressed, SchemaDir: "../../schemas/v1", Subjects: boundSubjects})
+	if !report.Passed {
+		t.Fatalf("expected consistent regression to pass without policy: %v", report.Violations)
+	}
+	report = Run(Options{SourcePath: regressed, SchemaDir: "../../schemas/v1", Subjects: boundSubjects, Semantic: semantic.Policy{RejectRegression: true}})
+	if report.ExitCode != ExitPolicyFail || !strings.Contains(strings.Join(report.Violations, ";"), "eval_regression_policy") {
+		t.Fatalf("expected eval_regression_policy failure, got %d %v", report.ExitCode, report.Violations)
+	}
+}
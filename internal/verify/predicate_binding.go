@@ -0,0 +1,174 @@
+package verify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+)
+
+// predicateDigestFields lists, per attestation type, the predicate fields
+// whose digests are taken from files and so must match a subject or material
+// of the same statement. Values derived from config strings
+// (build_command_digest) or external references (index_builder_image_digest)
+// are not listed.
+var predicateDigestFields = map[string][]string{
+	"prompt_attestation": {
+		"system_prompt_digest",
+		"template_digests",
+		"tool_schema_digests",
+		"safety_policy_digest",
+		"prompt_render_config_digest",
+		"prompt_test_suite_digest",
+	},
+	"corpus_attestation": {
+		"connector_config_digests",
+		"document_manifest_digest",
+		"chunking_config_digest",
+		"embedding_input_digest",
+		"vector_index_digest",
+	},
+	"eval_attestation": {
+		"testset_digest",
+		"scoring_config_digest",
+		"baseline_result_digest",
+		"candidate_result_digest",
+		"run_environment_digest",
+	},
+	"route_attestation": {
+		"route_config_digest",
+		"budget_policy_digest",
+		"fallback_graph_digest",
+		"canary_config_digest",
+		"simulation_result_digest",
+	},
+	"slo_attestation": {
+		"observability_query_digest",
+	},
+}
+
+// predicateBindingAnnotation is set by collectors that record every
+// predicate input as a subject or material.
+const predicateBindingAnnotation = "predicate_binding"
+
+// predatesPredicateBinding reports whether a statement lacks the
+// predicate_binding annotation: it was created before collectors recorded
+// every predicate input, or the annotation was removed. Run fails such
+// statements unless Options.AllowLegacyStatements is set.
+func predatesPredicateBinding(statement map[string]any) bool {
+	annotations, _ := statement["annotations"].(map[string]any)
+	version, _ := annotations[predicateBindingAnnotation].(string)
+	return version == ""
+}
+
+// VerifyPredicateBinding checks that every file digest in the predicate is
+// backed by a subject or material of the statement, and that a prompt
+// predicate's prompt_bundle_digest recomputes from its components.
+func VerifyPredicateBinding(statement map[string]any) error {
+	predicate, ok := statement["predicate"].(map[string]any)
+	if !ok {
+		return fmt.Errorf("statement predicate must be object")
+	}
+	attType := asString(statement["attestation_type"])
+	bound := boundDigests(statement)
+
+	var unbound []string
+	for _, field := range predicateDigestFields[attType] {
+		for _, digest := range predicateDigestValues(predicate[field]) {
+			alg, value := hash.SplitDigest(digest)
+			if !bound[alg+":"+value] {
+				unbound = append(unbound, fmt.Sprintf("%s %s", field, digest))
+			}
+		}
+	}
+	if len(unbound) > 0 {
+		return fmt.Errorf("predicate digest(s) match no subject or material: %s", strings.Join(unbound, "; "))
+	}
+
+	if attType == "prompt_attestation" {
+		if err := verifyPromptBundleDigest(predicate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// boundDigests collects every "alg:hex" digest of the statement's subjects and materials.
+func boundDigests(statement map[string]any) map[string]bool {
+	out := map[string]bool{}
+	for _, key := range []string{"subject", "materials"} {
+		items, _ := statement[key].([]any)
+		for _, item := range items {
+			entry, _ := item.(map[string]any)
+			digest, _ := entry["digest"].(map[string]any)
+			for alg, v := range digest {
+				if s, ok := v.(string); ok && s != "" {
+					out[alg+":"+s] = true
+				}
+			}
+		}
+	}
+	return out
+}
+
+// predicateDigestValues flattens a digest field that may be a string, a list
+// of strings, or a list of named digests.
+func predicateDigestValues(v any) []string {
+	switch vv := v.(type) {
+	case string:
+		if vv == "" {
+			return nil
+		}
+		return []string{vv}
+	case []any:
+		out := make([]string, 0, len(vv))
+		for _, item := range vv {
+			switch it := item.(type) {
+			case string:
+				out = append(out, it)
+			case map[string]any:
+				if d := asString(it["digest"]); d != "" {
+					out = append(out, d)
+				}
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func verifyPromptBundleDigest(predicate map[string]any) error {
+	recorded := asString(predicate["prompt_bundle_digest"])
+	if recorded == "" {
+		return fmt.Errorf("prompt_bundle_digest is missing")
+	}
+	alg, _ := hash.SplitDigest(recorded)
+	bundle := func(parts []string) (string, error) {
+		sorted := append([]string(nil), parts...)
+		sort.Strings(sorted)
+		return hash.DigestBytesWith(alg, []byte(strings.Join(sorted, "\n")))
+	}
+	templates, err := bundle(predicateDigestValues(predicate["template_digests"]))
+	if err != nil {
+		return fmt.Errorf("recompute prompt_bundle_digest: %w", err)
+	}
+	tools, err := bundle(predicateDigestValues(predicate["tool_schema_digests"]))
+	if err != nil {
+		return fmt.Errorf("recompute prompt_bundle_digest: %w", err)
+	}
+	expected, err := bundle([]string{
+		asString(predicate["system_prompt_digest"]),
+		asString(predicate["safety_policy_digest"]),
+		templates,
+		tools,
+	})
+	if err != nil {
+		return fmt.Errorf("recompute prompt_bundle_digest: %w", err)
+	}
+	if expected != recorded {
+		return fmt.Errorf("prompt_bundle_digest does not match its component digests")
+	}
+	return nil
+}
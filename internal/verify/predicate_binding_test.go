@@ -0,0 +1,166 @@
+package verify
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+)
+
+// boundSubjects runs Run over bindPredicate statements, whose materials name
+// synthetic digests with no file behind them.
+var boundSubjects = SubjectOptions{Mode: SubjectsOptional}
+
+// bindPredicate records every file digest of a fixture predicate as a
+// material, recomputes prompt_bundle_digest and marks the statement with the
+// predicate_binding annotation, so synthetic statements pass the check.
+func bindPredicate(statement map[string]any) map[string]any {
+	predicate := statement["predicate"].(map[string]any)
+	attType := asString(statement["attestation_type"])
+	materials, _ := statement["materials"].([]any)
+	for _, field := range predicateDigestFields[attType] {
+		for _, d := range predicateDigestValues(predicate[field]) {
+			alg, value := hash.SplitDigest(d)
+			materials = append(materials, map[string]any{
+				"name": field, "uri": "materials/" + field,
+				"digest": map[string]any{alg: value},
+			})
+		}
+	}
+	if len(materials) > 0 {
+		statement["materials"] = materials
+	}
+	annotations, _ := statement["annotations"].(map[string]any)
+	if annotations == nil {
+		annotations = map[string]any{}
+	}
+	annotations["predicate_binding"] = "v1"
+	statement["annotations"] = annotations
+	if attType == "prompt_attestation" {
+		predicate["prompt_bundle_digest"] = testPromptBundle(
+			asString(predicate["system_prompt_digest"]),
+			asString(predicate["safety_policy_digest"]),
+			testPromptBundle(predicateDigestValues(predicate["template_digests"])...),
+			testPromptBundle(predicateDigestValues(predicate["tool_schema_digests"])...),
+		)
+	}
+	return statement
+}
+
+func testPromptBundle(parts ...string) string {
+	sort.Strings(parts)
+	return hash.DigestBytes([]byte(strings.Join(parts, "\n")))
+}
+
+func promptStatement() map[string]any {
+	return map[string]any{
+		"attestation_type": "prompt_attestation",
+		"subject": []any{
+			map[string]any{"uri": "system.txt", "digest": map[string]any{"sha256": "aa"}},
+			map[string]any{"uri": "safety.txt", "digest": map[string]any{"sha256": "bb"}},
+			map[string]any{"uri": "templates/a.txt", "digest": map[string]any{"sha256": "cc", "sha512": "c5"}},
+			map[string]any{"uri": "tools/a.json", "digest": map[string]any{"sha256": "dd"}},
+		},
+		"materials": []any{
+			map[string]any{"uri": "render.yaml", "digest": map[string]any{"sha256": "ee"}},
+		},
+		"predicate": map[string]any{
+			"prompt_bundle_digest":        testPromptBundle("sha256:aa", "sha256:bb", testPromptBundle("sha256:cc"), testPromptBundle("sha256:dd")),
+			"system_prompt_digest":        "sha256:aa",
+			"template_digests":            []any{"sha256:cc"},
+			"tool_schema_digests":         []any{"sha256:dd"},
+			"safety_policy_digest":        "sha256:bb",
+			"prompt_render_config_digest": "sha256:ee",
+		},
+	}
+}
+
+func TestVerifyPredicateBinding_PromptBound(t *testing.T) {
+	if err := VerifyPredicateBinding(promptStatement()); err != nil {
+		t.Fatalf("expected bound prompt predicate to pass: %v", err)
+	}
+}
+
+func TestVerifyPredicateBinding_UnboundDigest(t *testing.T) {
+	st := promptStatement()
+	st["predicate"].(map[string]any)["prompt_render_config_digest"] = "sha256:ff"
+	err := VerifyPredicateBinding(st)
+	if err == nil || !strings.Contains(err.Error(), "prompt_render_config_digest sha256:ff") {
+		t.Fatalf("expected unbound render config digest, got %v", err)
+	}
+}
+
+func TestVerifyPredicateBinding_AlgorithmMustMatch(t *testing.T) {
+	st := promptStatement()
+	pred := st["predicate"].(map[string]any)
+	pred["template_digests"] = []any{"sha512:c5"}
+	pred["prompt_bundle_digest"] = testPromptBundle("sha256:aa", "sha256:bb", testPromptBundle("sha512:c5"), testPromptBundle("sha256:dd"))
+	if err := VerifyPredicateBinding(st); err != nil {
+		t.Fatalf("expected sha512 template digest to bind: %v", err)
+	}
+	pred["template_digests"] = []any{"sha512:cc"}
+	if err := VerifyPredicateBinding(st); err == nil {
+		t.Fatal("expected sha512 digest with sha256 value to be unbound")
+	}
+}
+
+func TestVerifyPredicateBinding_PromptBundleRecomputed(t *testing.T) {
+	st := promptStatement()
+	st["predicate"].(map[string]any)["prompt_bundle_digest"] = "sha256:" + strings.Repeat("0", 64)
+	err := VerifyPredicateBinding(st)
+	if err == nil || !strings.Contains(err.Error(), "prompt_bundle_digest") {
+		t.Fatalf("expected prompt bundle mismatch, got %v", err)
+	}
+}
+
+func TestVerifyPredicateBinding_CorpusConnectorsAndExemptions(t *testing.T) {
+	st := map[string]any{
+		"attestation_type": "corpus_attestation",
+		"subject": []any{
+			map[string]any{"uri": "conn.yaml", "digest": map[string]any{"sha256": "01"}},
+			map[string]any{"uri": "manifest.json", "digest": map[string]any{"sha256": "02"}},
+			map[string]any{"uri": "chunk.yaml", "digest": map[string]any{"sha256": "03"}},
+			map[string]any{"uri": "embed.jsonl", "digest": map[string]any{"sha256": "04"}},
+			map[string]any{"uri": "index.bin", "digest": map[string]any{"sha256": "05"}},
+		},
+		"predicate": map[string]any{
+			"connector_config_digests":   []any{map[string]any{"name": "conn.yaml", "digest": "sha256:01"}},
+			"document_manifest_digest":   "sha256:02",
+			"chunking_config_digest":     "sha256:03",
+			"embedding_input_digest":     "sha256:04",
+			"vector_index_digest":        "sha256:05",
+			"index_builder_image_digest": "sha256:external",
+			"build_command_digest":       "sha256:derived",
+		},
+	}
+	if err := VerifyPredicateBinding(st); err != nil {
+		t.Fatalf("expected corpus predicate to bind: %v", err)
+	}
+	st["predicate"].(map[string]any)["connector_config_digests"] = []any{map[string]any{"name": "x", "digest": "sha256:99"}}
+	if err := VerifyPredicateBinding(st); err == nil || !strings.Contains(err.Error(), "connector_config_digests") {
+		t.Fatalf("expected unbound connector digest, got %v", err)
+	}
+}
+
+func TestVerifyPredicateBinding_OtherTypes(t *testing.T) {
+	eval := map[string]any{
+		"attestation_type": "eval_attestation",
+		"subject":          []any{},
+		"predicate":        map[string]any{"testset_digest": "sha256:aa"},
+	}
+	if err := VerifyPredicateBinding(eval); err == nil {
+		t.Fatal("expected unbound eval testset digest")
+	}
+	slo := map[string]any{
+		"attestation_type": "slo_attestation",
+		"subject":          []any{},
+		"predicate":        map[string]any{"slo_profile_id": "prod"},
+	}
+	if err := VerifyPredicateBinding(slo); err != nil {
+		t.Fatalf("expected slo without query digest to pass: %v", err)
+	}
+	if err := VerifyPredicateBinding(map[string]any{"attestation_type": "custom"}); err == nil {
+		t.Fatal("expected missing predicate error")
+	}
+}
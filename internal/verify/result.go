@@ -1,5 +1,7 @@
 package verify
 
+import "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/chain"
+
 const (
 	ExitPass           = 0
 	ExitMissing        = 10
@@ -10,16 +12,19 @@ const (
 )
 
 type CheckResult struct {
-	Bundle  string `json:"bundle"`
-	Check   string `json:"check"`
-	Passed  bool   `json:"passed"`
+	Bundle string `json:"bundle"`
+	Check  string `json:"check"`
+	Passed bool   `json:"passed"`
+	// Skipped marks a check that was not run, e.g. for a legacy statement
+	// accepted with Options.AllowLegacyStatements. It is not a pass.
+	Skipped bool   `json:"skipped,omitempty"`
 	Message string `json:"message"`
 }
 
 type StatementSummary struct {
-	AttestationType string `json:"attestation_type"`
-	StatementID     string `json:"statement_id"`
-	PrivacyMode     string `json:"privacy_mode"`
+	AttestationType string   `json:"attestation_type"`
+	StatementID     string   `json:"statement_id"`
+	PrivacyMode     string   `json:"privacy_mode"`
 	DependsOn       []string `json:"depends_on,omitempty"`
 	GeneratedAt     string   `json:"generated_at,omitempty"`
 }
@@ -38,14 +43,17 @@ type ChainEdge struct {
 	ToStatementID   string `json:"to_statement_id,omitempty"`
 	ToType          string `json:"to_type"`
 	Satisfied       bool   `json:"satisfied"`
+	Optional        bool   `json:"optional,omitempty"`
 	Detail          string `json:"detail,omitempty"`
+	PinnedDigest    string `json:"pinned_digest,omitempty"`
 }
 
 type ChainReport struct {
-	Valid      bool      `json:"valid"`
-	Nodes      []ChainNode `json:"nodes,omitempty"`
-	Edges      []ChainEdge `json:"edges,omitempty"`
-	Violations []string  `json:"violations,omitempty"`
+	Valid      bool         `json:"valid"`
+	Nodes      []ChainNode  `json:"nodes,omitempty"`
+	Edges      []ChainEdge  `json:"edges,omitempty"`
+	Violations []string     `json:"violations,omitempty"`
+	Rules      []chain.Rule `json:"rules,omitempty"`
 }
 
 type Report struct {
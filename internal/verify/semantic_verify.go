@@ -0,0 +1,77 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/semantic"
+)
+
+// VerifyEvalConsistency recomputes regression_detected from the predicate's
+// metrics and thresholds and rejects statements that disagree.
+func VerifyEvalConsistency(statement map[string]any) error {
+	predicate, _ := statement["predicate"].(map[string]any)
+	recorded, _ := predicate["regression_detected"].(bool)
+	computed := semantic.EvalRegression(numberMap(predicate["metrics"]), numberMap(predicate["thresholds"]))
+	if recorded != computed {
+		return fmt.Errorf("regression_detected is %t but metrics vs thresholds give %t", recorded, computed)
+	}
+	return nil
+}
+
+// VerifySLOWindow checks that an SLO predicate's window starts before it ends.
+func VerifySLOWindow(statement map[string]any) error {
+	predicate, _ := statement["predicate"].(map[string]any)
+	window, _ := predicate["window"].(map[string]any)
+	start, err := time.Parse(time.RFC3339, asString(window["start"]))
+	if err != nil {
+		return fmt.Errorf("invalid window.start: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, asString(window["end"]))
+	if err != nil {
+		return fmt.Errorf("invalid window.end: %w", err)
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("window.start %s is not before window.end %s", window["start"], window["end"])
+	}
+	return nil
+}
+
+// VerifySemanticPolicy applies the policy rejections to an eval or SLO
+// statement. It returns the check name alongside any violation; statements of
+// other types, or with no applicable rule, return an empty check name.
+func VerifySemanticPolicy(statement map[string]any, policy semantic.Policy) (string, error) {
+	predicate, _ := statement["predicate"].(map[string]any)
+	switch asString(statement["attestation_type"]) {
+	case "eval_attestation":
+		if !policy.RejectRegression {
+			return "", nil
+		}
+		if regressed, _ := predicate["regression_detected"].(bool); regressed {
+			return "eval_regression_policy", fmt.Errorf("policy rejects eval %s: regression_detected is true", asString(predicate["eval_suite_id"]))
+		}
+		return "eval_regression_policy", nil
+	case "slo_attestation":
+		if len(policy.SLOLimits) == 0 {
+			return "", nil
+		}
+		breached := policy.SLOBreaches(predicate)
+		if len(breached) > 0 {
+			return "slo_limits_policy", fmt.Errorf("policy rejects slo values: %s", strings.Join(breached, "; "))
+		}
+		return "slo_limits_policy", nil
+	}
+	return "", nil
+}
+
+func numberMap(v any) map[string]float64 {
+	raw, _ := v.(map[string]any)
+	out := make(map[string]float64, len(raw))
+	for k, val := range raw {
+		if f, ok := val.(float64); ok {
+			out[k] = f
+		}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/semantic"
+)
+
+func evalStatement(metrics, thresholds map[string]any, regression bool) map[string]any {
+	return map[string]any{
+		"attestation_type": "eval_attestation",
+		"predicate": map[string]any{
+			"eval_suite_id":       "suite-1",
+			"metrics":             metrics,
+			"thresholds":          thresholds,
+			"regression_detected": regression,
+		},
+	}
+}
+
+func sloStatement(start, end string) map[string]any {
+	return map[string]any{
+		"attestation_type": "slo_attestation",
+		"predicate": map[string]any{
+			"window":                 map[string]any{"start": start, "end": end},
+			"ttft_ms_p95":            900.0,
+			"error_budget_remaining": 0.05,
+		},
+	}
+}
+
+// --- VerifyEvalConsistency ---
+
+func TestVerifyEvalConsistency(t *testing.T) {
+	ok := evalStatement(map[string]any{"accuracy": 0.85}, map[string]any{"accuracy_min": 0.9}, true)
+	if err := VerifyEvalConsistency(ok); err != nil {
+		t.Fatalf("expected consistent statement, got %v", err)
+	}
+	bad := evalStatement(map[string]any{"accuracy": 0.85}, map[string]any{"accuracy_min": 0.9}, false)
+	err := VerifyEvalConsistency(bad)
+	if err == nil || !strings.Contains(err.Error(), "regression_detected is false but metrics vs thresholds give true") {
+		t.Fatalf("expected inconsistency error, got %v", err)
+	}
+}
+
+// --- VerifySLOWindow ---
+
+func TestVerifySLOWindow(t *testing.T) {
+	if err := VerifySLOWindow(sloStatement("2026-02-18T00:00:00Z", "2026-02-18T01:00:00Z")); err != nil {
+		t.Fatalf("expected valid window, got %v", err)
+	}
+	for _, st := range []map[string]any{
+		sloStatement("2026-02-18T01:00:00Z", "2026-02-18T00:00:00Z"),
+		sloStatement("2026-02-18T00:00:00Z", "2026-02-18T00:00:00Z"),
+	} {
+		if err := VerifySLOWindow(st); err == nil || !strings.Contains(err.Error(), "is not before") {
+			t.Fatalf("expected inverted window error, got %v", err)
+		}
+	}
+	if err := VerifySLOWindow(sloStatement("yesterday", "2026-02-18T00:00:00Z")); err == nil || !strings.Contains(err.Error(), "window.start") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+// --- VerifySemanticPolicy ---
+
+func TestVerifySemanticPolicyRegression(t *testing.T) {
+	regressed := evalStatement(map[string]any{"accuracy": 0.85}, map[string]any{"accuracy_min": 0.9}, true)
+	if check, err := VerifySemanticPolicy(regressed, semantic.Policy{}); check != "" || err != nil {
+		t.Fatalf("expected no check without policy, got %q %v", check, err)
+	}
+	check, err := VerifySemanticPolicy(regressed, semantic.Policy{RejectRegression: true})
+	if check != "eval_regression_policy" || err == nil || !strings.Contains(err.Error(), "suite-1") {
+		t.Fatalf("expected regression rejection, got %q %v", check, err)
+	}
+	clean := evalStatement(map[string]any{"accuracy": 0.95}, map[string]any{"accuracy_min": 0.9}, false)
+	if check, err := VerifySemanticPolicy(clean, semantic.Policy{RejectRegression: true}); check != "eval_regression_policy" || err != nil {
+		t.Fatalf("expected passing check, got %q %v", check, err)
+	}
+}
+
+func TestVerifySemanticPolicySLOLimits(t *testing.T) {
+	st := sloStatement("2026-02-18T00:00:00Z", "2026-02-18T01:00:00Z")
+	check, err := VerifySemanticPolicy(st, semantic.Policy{SLOLimits: map[string]float64{
+		"ttft_ms_p95_max":            800,
+		"error_budget_remaining_min": 0.1,
+		"tokens_per_sec_p50_min":     10,
+	}})
+	if check != "slo_limits_policy" || err == nil {
+		t.Fatalf("expected slo limit rejection, got %q %v", check, err)
+	}
+	for _, want := range []string{"ttft_ms_p95 900", "error_budget_remaining 0.05"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "tokens_per_sec_p50") {
+		t.Errorf("absent field should not be reported: %v", err)
+	}
+
+	if _, err := VerifySemanticPolicy(st, semantic.Policy{SLOLimits: map[string]float64{"ttft_ms_p95_max": 1000}}); err != nil {
+		t.Fatalf("expected values within limits, got %v", err)
+	}
+}
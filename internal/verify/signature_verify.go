@@ -1,9 +1,11 @@
 package verify
 
 import (
+	"bytes"
 	"crypto/ed25519"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"os"
@@ -32,6 +34,9 @@ func VerifySignature(bundle sign.Bundle, policy SignerPolicy) error {
 	if hash.DigestBytes(rawPayload) != bundle.Metadata.StatementHash {
 		return fmt.Errorf("statement hash mismatch")
 	}
+	if err := verifyCanonicalPayload(rawPayload, bundle.Metadata.Canonicalization); err != nil {
+		return err
+	}
 
 	sig := bundle.Envelope.Signatures[0]
 	if sig.Provider == "sigstore" && strings.TrimSpace(sig.CertificatePEM) != "" {
@@ -61,6 +66,33 @@ func VerifySignature(bundle sign.Bundle, policy SignerPolicy) error {
 	return nil
 }
 
+// verifyCanonicalPayload checks that a versioned payload is byte-identical to
+// its re-canonicalised form. Legacy bundles record no version and are accepted
+// as signed.
+func verifyCanonicalPayload(raw []byte, version string) error {
+	switch version {
+	case "", hash.CanonicalizationLegacy:
+		return nil
+	case hash.CanonicalizationJCS:
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+		canonical, err := hash.CanonicalJSONJCS(v)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(canonical, raw) {
+			return fmt.Errorf("payload is not in %s canonical form", version)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported canonicalization %q", version)
+	}
+}
+
 func verifyWithCosign(payload []byte, sig sign.Signature, policy SignerPolicy) error {
 	if _, err := exec.LookPath("cosign"); err != nil {
 		return fmt.Errorf("cosign binary is required to verify sigstore keyless bundles: %w", err)
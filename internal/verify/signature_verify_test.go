@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"path/filepath"
 	"strings"
@@ -166,6 +167,73 @@ func TestVerifySignatureValidPEM(t *testing.T) {
 	}
 }
 
+func signedPayloadBundle(t *testing.T, payload []byte, canonicalization string) sign.Bundle {
+	t.Helper()
+	keyPath := filepath.Join(t.TempDir(), "dev.pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sign.NewPEMSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	material, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sign.Bundle{
+		Envelope: sign.Envelope{
+			PayloadType: "application/vnd.llmsa.statement.v1+json",
+			Payload:     base64.StdEncoding.EncodeToString(payload),
+			Signatures: []sign.Signature{{
+				KeyID:        material.KeyID,
+				Sig:          material.SigB64,
+				Provider:     material.Provider,
+				PublicKeyPEM: material.PublicKeyPEM,
+			}},
+		},
+		Metadata: sign.Metadata{
+			BundleVersion:    "1",
+			StatementHash:    hash.DigestBytes(payload),
+			Canonicalization: canonicalization,
+		},
+	}
+}
+
+func TestVerifySignatureAcceptsLegacyCanonicalization(t *testing.T) {
+	// Legacy payloads escape HTML characters and carry no canonicalization field.
+	payload, err := hash.CanonicalJSON(map[string]any{"statement_id": "legacy", "note": "<a&b>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := signedPayloadBundle(t, payload, "")
+	if err := VerifySignature(bundle, SignerPolicy{}); err != nil {
+		t.Fatalf("expected legacy bundle to verify: %v", err)
+	}
+}
+
+func TestVerifySignatureRejectsNonCanonicalJCSPayload(t *testing.T) {
+	payload := []byte(`{"statement_id":"jcs","value":4.50}`)
+	bundle := signedPayloadBundle(t, payload, hash.CanonicalizationJCS)
+	err := VerifySignature(bundle, SignerPolicy{})
+	if err == nil || !strings.Contains(err.Error(), "canonical form") {
+		t.Fatalf("expected canonical form error, got %v", err)
+	}
+
+	canonical := []byte(`{"statement_id":"jcs","value":4.5}`)
+	if err := VerifySignature(signedPayloadBundle(t, canonical, hash.CanonicalizationJCS), SignerPolicy{}); err != nil {
+		t.Fatalf("expected canonical JCS payload to verify: %v", err)
+	}
+}
+
+func TestVerifySignatureRejectsUnknownCanonicalization(t *testing.T) {
+	bundle := signedPayloadBundle(t, []byte(`{"a":1}`), "c14n-v9")
+	err := VerifySignature(bundle, SignerPolicy{})
+	if err == nil || !strings.Contains(err.Error(), "unsupported canonicalization") {
+		t.Fatalf("expected unsupported canonicalization error, got %v", err)
+	}
+}
+
 func TestVerifySignatureRejectsInvalidSignatureBytes(t *testing.T) {
 	tmp := t.TempDir()
 	keyPath := filepath.Join(tmp, "dev.pem")
@@ -1,50 +1,269 @@
 package verify
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/store"
+)
+
+const (
+	// SubjectsRequired fails verification when a subject cannot be resolved.
+	SubjectsRequired = "required"
+	// SubjectsOptional skips subjects that are definitely absent (a missing
+	// local path, or a registry or object store answering 404) but fails on
+	// every other fetch error and on digest mismatches.
+	SubjectsOptional = "optional"
+	// SubjectsSkip disables subject digest verification entirely.
+	SubjectsSkip = "skip"
+)
+
+// SubjectOptions controls where subject URIs are resolved from.
+type SubjectOptions struct {
+	// Root is the base directory for relative paths and relative file:// URIs.
+	// Empty means the current working directory.
+	Root string
+	// Mode is one of SubjectsRequired (default), SubjectsOptional or SubjectsSkip.
+	Mode string
+	// S3Endpoint is the S3-compatible endpoint for s3:// URIs.
+	S3Endpoint string
+	// Registry controls how oci:// URIs are fetched: default registry,
+	// credentials, CA bundle and insecure hosts.
+	Registry store.RegistryOptions
+}
+
+// ParseSubjectMode normalises a --subjects flag value.
+func ParseSubjectMode(raw string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(raw)); mode {
+	case "", SubjectsRequired:
+		return SubjectsRequired, nil
+	case SubjectsOptional, SubjectsSkip:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported subjects mode %q (want required|optional|skip)", raw)
+	}
+}
+
+// unavailableError marks subjects that do not exist where their URI points,
+// as opposed to subjects that could not be fetched or whose content does not
+// match.
+type unavailableError struct{ msg string }
+
+func (e *unavailableError) Error() string { return e.msg }
+
+func subjectUnavailable(format string, args ...any) error {
+	return &unavailableError{msg: fmt.Sprintf(format, args...)}
+}
+
+var (
+	fetchOCIBlob  = store.FetchOCIBlob
+	fetchS3Object = store.FetchS3Object
 )
 
 func VerifySubjects(statement map[string]any) error {
+	_, err := VerifySubjectsWithOptions(statement, SubjectOptions{})
+	return err
+}
+
+// VerifySubjectsWithOptions recomputes subject and material digests,
+// resolving URIs against opts. Materials are the optional inputs predicate
+// digests may be bound to, so they are checked the same way. It returns the
+// URIs skipped as unavailable in optional mode.
+func VerifySubjectsWithOptions(statement map[string]any, opts SubjectOptions) ([]string, error) {
+	mode, err := ParseSubjectMode(opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if mode == SubjectsSkip {
+		return nil, nil
+	}
 	subjectAny, ok := statement["subject"].([]any)
 	if !ok {
-		return fmt.Errorf("statement subject must be array")
+		return nil, fmt.Errorf("statement subject must be array")
 	}
-	for _, item := range subjectAny {
-		s, ok := item.(map[string]any)
-		if !ok {
-			return fmt.Errorf("invalid subject entry")
+	materialAny, ok := statement["materials"].([]any)
+	if !ok && statement["materials"] != nil {
+		return nil, fmt.Errorf("statement materials must be array")
+	}
+	var skipped []string
+	for _, set := range []struct {
+		kind  string
+		items []any
+	}{{"subject", subjectAny}, {"material", materialAny}} {
+		for _, item := range set.items {
+			s, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s entry", set.kind)
+			}
+			uri, _ := s["uri"].(string)
+			digestObj, _ := s["digest"].(map[string]any)
+			if uri == "" || len(digestObj) == 0 {
+				return nil, fmt.Errorf("%s missing uri/digest", set.kind)
+			}
+			err := verifySubject(uri, s, digestObj, opts)
+			var unavailable *unavailableError
+			if errors.As(err, &unavailable) && mode == SubjectsOptional {
+				skipped = append(skipped, uri)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
 		}
-		uri, _ := s["uri"].(string)
-		digestObj, _ := s["digest"].(map[string]any)
-		expected, _ := digestObj["sha256"].(string)
-		if uri == "" || expected == "" {
+	}
+	return skipped, nil
+}
+
+func verifySubject(uri string, s map[string]any, digestObj map[string]any, opts SubjectOptions) error {
+	for _, alg := range sortedKeys(digestObj) {
+		expected, _ := digestObj[alg].(string)
+		if expected == "" {
 			return fmt.Errorf("subject missing uri/digest")
 		}
-		path := filepath.FromSlash(uri)
-		if !hash.FileExists(path) {
-			return fmt.Errorf("subject path missing: %s", uri)
+		if _, err := hash.ParseAlgorithm(alg); err != nil {
+			return fmt.Errorf("subject %s: %w", uri, err)
 		}
-		real := ""
-		if info, _ := filepath.Glob(path); len(info) > 0 {
-			_ = info
-		}
-		fiDigest, _, fileErr := hash.DigestFile(path)
-		if fileErr == nil {
-			real = strings.TrimPrefix(fiDigest, "sha256:")
-		} else {
-			treeDigest, _, _, err := hash.DigestTree(path)
+	}
+
+	scheme, rest, hasScheme := strings.Cut(uri, "://")
+	if hasScheme {
+		switch scheme {
+		case "file":
+			return verifyLocalSubject(uri, rest, s, digestObj, opts)
+		case "oci":
+			raw, err := fetchOCIBlob(rest, opts.Registry)
+			if err != nil {
+				return fetchError(uri, err)
+			}
+			return compareRemoteSubject(uri, raw, digestObj)
+		case "image":
+			return verifyImageSubject(uri, rest, digestObj)
+		case "s3":
+			bucket, key, _ := strings.Cut(rest, "/")
+			raw, err := fetchS3Object(opts.S3Endpoint, bucket, key)
 			if err != nil {
-				return fmt.Errorf("cannot digest subject %s: %w", uri, err)
+				return fetchError(uri, err)
 			}
-			real = strings.TrimPrefix(treeDigest, "sha256:")
+			return compareRemoteSubject(uri, raw, digestObj)
+		default:
+			return fmt.Errorf("subject %s: unsupported uri scheme %q", uri, scheme)
 		}
-		if real != expected {
-			return fmt.Errorf("subject digest mismatch for %s", uri)
+	}
+	return verifyLocalSubject(uri, uri, s, digestObj, opts)
+}
+
+// verifyImageSubject checks an image subject, image://<repo>@sha256:<hex>,
+// against its digest. The image is identified by that digest, so nothing is
+// fetched; the subject only ties the statement to the image.
+func verifyImageSubject(uri, ref string, digestObj map[string]any) error {
+	_, pinned, ok := strings.Cut(ref, "@")
+	alg, value, _ := strings.Cut(pinned, ":")
+	if !ok || value == "" {
+		return fmt.Errorf("subject %s: image must be pinned by digest", uri)
+	}
+	if len(digestObj) != 1 {
+		return fmt.Errorf("subject %s: image subjects carry only the image digest", uri)
+	}
+	if expected, _ := digestObj[alg].(string); expected != value {
+		return fmt.Errorf("subject digest mismatch for %s (%s)", uri, alg)
+	}
+	return nil
+}
+
+// fetchError reports a remote subject as unavailable only when the store said
+// it does not exist.
+func fetchError(uri string, err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return subjectUnavailable("subject not found %s: %v", uri, err)
+	}
+	return fmt.Errorf("cannot fetch subject %s: %w", uri, err)
+}
+
+func verifyLocalSubject(uri, rel string, s map[string]any, digestObj map[string]any, opts SubjectOptions) error {
+	root, treeOpts, err := subjectTreeOptions(s)
+	if err != nil {
+		return fmt.Errorf("subject %s: %w", uri, err)
+	}
+	path := resolveSubjectPath(opts.Root, rel)
+	if root != "" {
+		root = resolveSubjectPath(opts.Root, root)
+	}
+	if !pathExists(path) {
+		return subjectUnavailable("subject path missing: %s", uri)
+	}
+	for _, alg := range sortedKeys(digestObj) {
+		expected, _ := digestObj[alg].(string)
+		treeOpts.Algorithm = alg
+		entry, err := hash.DigestPath(root, path, treeOpts)
+		if err != nil {
+			return fmt.Errorf("cannot digest subject %s: %w", uri, err)
+		}
+		if strings.TrimPrefix(entry.Digest, alg+":") != expected {
+			return fmt.Errorf("subject digest mismatch for %s (%s)", uri, alg)
 		}
 	}
 	return nil
 }
+
+func compareRemoteSubject(uri string, raw []byte, digestObj map[string]any) error {
+	for _, alg := range sortedKeys(digestObj) {
+		expected, _ := digestObj[alg].(string)
+		got, err := hash.DigestBytesWith(alg, raw)
+		if err != nil {
+			return fmt.Errorf("subject %s: %w", uri, err)
+		}
+		if strings.TrimPrefix(got, alg+":") != expected {
+			return fmt.Errorf("subject digest mismatch for %s (%s)", uri, alg)
+		}
+	}
+	return nil
+}
+
+// resolveSubjectPath joins relative subject paths onto the configured root.
+func resolveSubjectPath(root, uri string) string {
+	path := filepath.FromSlash(uri)
+	if root == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(root, path)
+}
+
+// subjectTreeOptions reads the optional subject "tree" annotation so the
+// digest is recomputed with the symlink and manifest rules used at attest time.
+func subjectTreeOptions(subject map[string]any) (string, hash.TreeOptions, error) {
+	tree, ok := subject["tree"].(map[string]any)
+	if !ok {
+		return "", hash.TreeOptions{}, nil
+	}
+	mode, err := hash.ParseSymlinkMode(asString(tree["symlinks"]))
+	if err != nil {
+		return "", hash.TreeOptions{}, err
+	}
+	emptyDirs, _ := tree["empty_dirs"].(bool)
+	fileModes, _ := tree["file_modes"].(bool)
+	root := asString(tree["root"])
+	if root != "" {
+		root = filepath.FromSlash(root)
+	}
+	return root, hash.TreeOptions{Symlinks: mode, EmptyDirs: emptyDirs, FileModes: fileModes}, nil
+}
+
+// pathExists uses Lstat so dangling symlinks still reach the symlink policy.
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
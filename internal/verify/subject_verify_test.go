@@ -3,12 +3,16 @@ package verify
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/store"
 )
 
 func TestVerifySubjects_AllMatch(t *testing.T) {
@@ -57,6 +61,33 @@ func TestVerifySubjects_DigestMismatch(t *testing.T) {
 	}
 }
 
+func TestVerifySubjects_MaterialsRecomputed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "canary.yaml")
+	os.WriteFile(path, []byte("weight: 5"), 0o644)
+	h := sha256.Sum256([]byte("weight: 5"))
+
+	statement := map[string]any{
+		"subject": []any{},
+		"materials": []any{
+			map[string]any{"uri": path, "digest": map[string]any{"sha256": hex.EncodeToString(h[:])}},
+		},
+	}
+	if err := VerifySubjects(statement); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	os.WriteFile(path, []byte("weight: 50"), 0o644)
+	if err := VerifySubjects(statement); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected material digest mismatch, got %v", err)
+	}
+
+	statement["materials"] = []any{map[string]any{"uri": path}}
+	if err := VerifySubjects(statement); err == nil || !strings.Contains(err.Error(), "material missing uri/digest") {
+		t.Fatalf("expected malformed material error, got %v", err)
+	}
+}
+
 func TestVerifySubjects_MissingFile(t *testing.T) {
 	statement := map[string]any{
 		"subject": []any{
@@ -181,3 +212,268 @@ func TestVerifySubjects_EmptySubjects(t *testing.T) {
 		t.Fatalf("expected empty subjects to pass: %v", err)
 	}
 }
+
+func TestVerifySubjects_SymlinkFollowedWithoutTreeMode(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "real.txt"), []byte("x"), 0o644)
+	link := filepath.Join(dir, "template.txt")
+	if err := os.Symlink("real.txt", link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	subject := map[string]any{
+		"uri":    link,
+		"digest": map[string]any{"sha256": strings.TrimPrefix(hash.DigestBytes([]byte("x")), "sha256:")},
+	}
+	statement := map[string]any{"subject": []any{subject}}
+	if err := VerifySubjects(statement); err != nil {
+		t.Fatalf("expected legacy subject to follow the symlink: %v", err)
+	}
+
+	subject["tree"] = map[string]any{"symlinks": "reject"}
+	err := VerifySubjects(statement)
+	if err == nil {
+		t.Fatal("expected symlinked subject to be rejected")
+	}
+	if !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifySubjects_TreeModeHashTargetPath(t *testing.T) {
+	dir := t.TempDir()
+	subDir := filepath.Join(dir, "templates")
+	os.MkdirAll(subDir, 0o755)
+	os.WriteFile(filepath.Join(subDir, "a.txt"), []byte("file a"), 0o644)
+	if err := os.Symlink("a.txt", filepath.Join(subDir, "b.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	opts := hash.TreeOptions{Symlinks: hash.SymlinkHashTargetPath}
+	treeDigest, _, _, err := hash.DigestTreeWithOptions(subDir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := map[string]any{"symlinks": "hash_target_path"}
+	statement := map[string]any{
+		"subject": []any{
+			map[string]any{
+				"uri":    subDir,
+				"digest": map[string]any{"sha256": strings.TrimPrefix(treeDigest, "sha256:")},
+				"tree":   tree,
+			},
+			map[string]any{
+				"uri":    filepath.Join(subDir, "b.txt"),
+				"digest": map[string]any{"sha256": strings.TrimPrefix(hash.DigestBytes([]byte("a.txt")), "sha256:")},
+				"tree":   map[string]any{"symlinks": "hash_target_path", "root": subDir},
+			},
+		},
+	}
+	if err := VerifySubjects(statement); err != nil {
+		t.Fatalf("expected tree-mode subjects to pass: %v", err)
+	}
+
+	// The same digests must not verify when the recorded mode is dropped.
+	for _, s := range statement["subject"].([]any) {
+		delete(s.(map[string]any), "tree")
+	}
+	if err := VerifySubjects(statement); err == nil {
+		t.Fatal("expected verification to enforce the default follow mode")
+	}
+}
+
+func TestVerifySubjects_AllAlgorithmsChecked(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	os.WriteFile(path, []byte("multi"), 0o644)
+	digests, _, err := hash.DigestFileAll(path, []string{hash.SHA256, hash.SHA512, hash.SHA3256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := map[string]any{}
+	for alg, d := range digests {
+		digest[alg] = strings.TrimPrefix(d, alg+":")
+	}
+	statement := map[string]any{
+		"subject": []any{map[string]any{"uri": path, "digest": digest}},
+	}
+	if err := VerifySubjects(statement); err != nil {
+		t.Fatalf("expected all digests to verify: %v", err)
+	}
+
+	digest[hash.SHA512] = strings.Repeat("0", 128)
+	err = VerifySubjects(statement)
+	if err == nil || !strings.Contains(err.Error(), "sha512") {
+		t.Fatalf("expected sha512 mismatch, got %v", err)
+	}
+
+	delete(digest, hash.SHA512)
+	digest["md5"] = "abc"
+	if err := VerifySubjects(statement); err == nil {
+		t.Fatal("expected unsupported algorithm error")
+	}
+}
+
+// --- subject sources ---
+
+func sha256Hex(raw []byte) string {
+	h := sha256.Sum256(raw)
+	return hex.EncodeToString(h[:])
+}
+
+func subjectStatement(uri string, raw []byte) map[string]any {
+	return map[string]any{
+		"subject": []any{map[string]any{
+			"uri":    uri,
+			"digest": map[string]any{"sha256": sha256Hex(raw)},
+		}},
+	}
+}
+
+func TestVerifySubjectsWithOptions_RelativeToRoot(t *testing.T) {
+	root := t.TempDir()
+	content := []byte("rooted")
+	os.MkdirAll(filepath.Join(root, "prompts"), 0o755)
+	os.WriteFile(filepath.Join(root, "prompts", "a.txt"), content, 0o644)
+
+	for _, uri := range []string{"prompts/a.txt", "file://prompts/a.txt"} {
+		statement := subjectStatement(uri, content)
+		if _, err := VerifySubjectsWithOptions(statement, SubjectOptions{Root: root}); err != nil {
+			t.Fatalf("%s: expected subject under root to verify: %v", uri, err)
+		}
+		if err := VerifySubjects(statement); err == nil {
+			t.Fatalf("%s: expected missing subject without root", uri)
+		}
+	}
+
+	abs := "file://" + filepath.ToSlash(filepath.Join(root, "prompts", "a.txt"))
+	if _, err := VerifySubjectsWithOptions(subjectStatement(abs, content), SubjectOptions{Root: t.TempDir()}); err != nil {
+		t.Fatalf("expected absolute file URI to ignore root: %v", err)
+	}
+}
+
+func TestVerifySubjectsWithOptions_Modes(t *testing.T) {
+	statement := subjectStatement("missing/file.txt", []byte("x"))
+
+	if _, err := VerifySubjectsWithOptions(statement, SubjectOptions{}); err == nil || !strings.Contains(err.Error(), "subject path missing") {
+		t.Fatalf("expected required mode to fail, got %v", err)
+	}
+	skipped, err := VerifySubjectsWithOptions(statement, SubjectOptions{Mode: SubjectsOptional})
+	if err != nil {
+		t.Fatalf("expected optional mode to skip missing subject: %v", err)
+	}
+	if len(skipped) != 1 || skipped[0] != "missing/file.txt" {
+		t.Fatalf("unexpected skipped list: %v", skipped)
+	}
+	if _, err := VerifySubjectsWithOptions(map[string]any{}, SubjectOptions{Mode: SubjectsSkip}); err != nil {
+		t.Fatalf("expected skip mode to ignore subjects: %v", err)
+	}
+	if _, err := VerifySubjectsWithOptions(statement, SubjectOptions{Mode: "sometimes"}); err == nil {
+		t.Fatal("expected invalid mode error")
+	}
+}
+
+func TestVerifySubjectsWithOptions_OptionalStillDetectsTamper(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("tampered"), 0o644)
+	statement := subjectStatement("a.txt", []byte("original"))
+	_, err := VerifySubjectsWithOptions(statement, SubjectOptions{Root: root, Mode: SubjectsOptional})
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected mismatch in optional mode, got %v", err)
+	}
+}
+
+func TestVerifySubjectsWithOptions_OCIBlob(t *testing.T) {
+	blob := []byte("model weights")
+	orig := fetchOCIBlob
+	t.Cleanup(func() { fetchOCIBlob = orig })
+	fetchOCIBlob = func(ref string, reg store.RegistryOptions) ([]byte, error) {
+		if ref != "ghcr.io/acme/models@sha256:abc" {
+			t.Fatalf("unexpected ref %q", ref)
+		}
+		if reg.CAFile != "ca.pem" {
+			t.Fatalf("registry options not passed through: %+v", reg)
+		}
+		return blob, nil
+	}
+
+	uri := "oci://ghcr.io/acme/models@sha256:abc"
+	opts := SubjectOptions{Registry: store.RegistryOptions{CAFile: "ca.pem"}}
+	if _, err := VerifySubjectsWithOptions(subjectStatement(uri, blob), opts); err != nil {
+		t.Fatalf("expected oci subject to verify: %v", err)
+	}
+	if _, err := VerifySubjectsWithOptions(subjectStatement(uri, []byte("other")), opts); err == nil {
+		t.Fatal("expected oci digest mismatch")
+	}
+
+	fetchOCIBlob = func(string, store.RegistryOptions) ([]byte, error) {
+		return nil, fmt.Errorf("fetch oci blob: %w", store.ErrNotFound)
+	}
+	if _, err := VerifySubjectsWithOptions(subjectStatement(uri, blob), SubjectOptions{}); err == nil {
+		t.Fatal("expected missing blob to fail in required mode")
+	}
+	skipped, err := VerifySubjectsWithOptions(subjectStatement(uri, blob), SubjectOptions{Mode: SubjectsOptional})
+	if err != nil || len(skipped) != 1 {
+		t.Fatalf("expected missing blob to be skipped in optional mode, got %v %v", skipped, err)
+	}
+
+	// Auth, TLS and server failures say nothing about whether the subject
+	// exists, so optional mode must not skip them.
+	fetchOCIBlob = func(string, store.RegistryOptions) ([]byte, error) {
+		return nil, fmt.Errorf("fetch oci blob: UNAUTHORIZED")
+	}
+	if _, err := VerifySubjectsWithOptions(subjectStatement(uri, blob), SubjectOptions{Mode: SubjectsOptional}); err == nil || !strings.Contains(err.Error(), "UNAUTHORIZED") {
+		t.Fatalf("expected fetch failure in optional mode, got %v", err)
+	}
+}
+
+func TestVerifySubjectsWithOptions_S3StandIn(t *testing.T) {
+	object := []byte("corpus shard")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/datasets/shards/0001.jsonl":
+			_, _ = w.Write(object)
+		case "/denied/shards/0001.jsonl":
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	opts := SubjectOptions{S3Endpoint: srv.URL}
+	if _, err := VerifySubjectsWithOptions(subjectStatement("s3://datasets/shards/0001.jsonl", object), opts); err != nil {
+		t.Fatalf("expected s3 subject to verify: %v", err)
+	}
+	if _, err := VerifySubjectsWithOptions(subjectStatement("s3://datasets/shards/0002.jsonl", object), opts); err == nil {
+		t.Fatal("expected missing s3 object to fail")
+	}
+	opts.Mode = SubjectsOptional
+	if skipped, err := VerifySubjectsWithOptions(subjectStatement("s3://datasets/shards/0002.jsonl", object), opts); err != nil || len(skipped) != 1 {
+		t.Fatalf("expected missing s3 object to be skipped in optional mode, got %v %v", skipped, err)
+	}
+	if _, err := VerifySubjectsWithOptions(subjectStatement("s3://denied/shards/0001.jsonl", object), opts); err == nil {
+		t.Fatal("expected a 403 to fail in optional mode")
+	}
+}
+
+func TestVerifySubjectsWithOptions_ImageSubject(t *testing.T) {
+	hex := strings.Repeat("ab", 32)
+	subject := func(uri, digest string) map[string]any {
+		return map[string]any{"subject": []any{map[string]any{"uri": uri, "digest": map[string]any{"sha256": digest}}}}
+	}
+	if _, err := VerifySubjectsWithOptions(subject("image://ghcr.io/acme/app@sha256:"+hex, hex), SubjectOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := VerifySubjectsWithOptions(subject("image://ghcr.io/acme/app@sha256:"+hex, strings.Repeat("cd", 32)), SubjectOptions{}); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected digest mismatch, got %v", err)
+	}
+	if _, err := VerifySubjectsWithOptions(subject("image://ghcr.io/acme/app:v1", hex), SubjectOptions{Mode: SubjectsOptional}); err == nil || !strings.Contains(err.Error(), "pinned by digest") {
+		t.Fatalf("expected unpinned image error, got %v", err)
+	}
+}
+
+func TestVerifySubjectsWithOptions_UnsupportedScheme(t *testing.T) {
+	_, err := VerifySubjectsWithOptions(subjectStatement("ftp://host/a", []byte("x")), SubjectOptions{})
+	if err == nil || !strings.Contains(err.Error(), "unsupported uri scheme") {
+		t.Fatalf("expected unsupported scheme error, got %v", err)
+	}
+}
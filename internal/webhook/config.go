@@ -1,5 +1,14 @@
 package webhook
 
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/signed"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/store"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
+)
+
 // Config holds the webhook server settings.
 type Config struct {
 	Port            int
@@ -10,6 +19,81 @@ type Config struct {
 	RegistryPrefix  string
 	FailOpen        bool
 	CacheTTLSeconds int
+	// Referrers discovers every bundle attached to the image through the OCI
+	// referrers API instead of the single RegistryPrefix tag.
+	Referrers bool
+	// OCILayout reads bundles from a mounted OCI image layout (directory or
+	// tarball) instead of a registry, taking each image's set from the tag
+	// given by AttestationTag. It takes precedence over Referrers and
+	// RegistryPrefix.
+	OCILayout string
+	// Registry holds registry credentials (including mounted image pull
+	// secrets), TLS and retry settings for bundle pulls.
+	Registry store.RegistryOptions
+	// SubjectMode is passed to subject verification. The webhook has no local
+	// artifacts, so the default only checks remote (oci://, s3://) subjects.
+	SubjectMode string
+	// PolicySignature, when Require is set, makes the webhook refuse to
+	// admit anything unless PolicyPath is signed by a trusted policy signer.
+	PolicySignature signed.Options
+	// PolicyEngine evaluates the PolicyPath gates with "yaml" (the default)
+	// or "rego", which evaluates RegoPolicyPath instead.
+	PolicyEngine   string
+	RegoPolicyPath string
+	// PolicyDir holds named policies, <name>.yaml, e.g. a mounted ConfigMap.
+	// A workload selects one with the PolicyAnnotation, and a namespace by
+	// routing to /validate/<name>; DefaultPolicy, or else PolicyPath, covers
+	// the rest.
+	PolicyDir     string
+	DefaultPolicy string
+	// PolicyOverrides lists, per namespace or default policy, the policies a
+	// workload annotation may select instead. That policy is the floor: an
+	// annotation naming anything else is denied, so it cannot downgrade it.
+	PolicyOverrides map[string][]string
+	// Environment fires gates whose trigger_environments match. Admission
+	// has no changed files, so trigger_paths never fire; gates meant for
+	// every image set always: true.
+	Environment string
+}
+
+// CheckPolicy verifies the policy signatures required by PolicySignature,
+// for PolicyPath, every policy in PolicyDir and, with the rego engine, the
+// Rego module each of them uses.
+func (c Config) CheckPolicy() error {
+	files, err := c.policyFiles()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		// Check still refuses a missing policy when signing is required.
+		files[""] = ""
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := signed.Check(files[name], c.PolicySignature); err != nil {
+			return fmt.Errorf("webhook policy: %w", err)
+		}
+		if c.PolicyEngine != "rego" || name == "" {
+			continue
+		}
+		if regoPath := c.regoModulePath(name, files[name]); regoPath != "" {
+			if err := signed.Check(regoPath, c.PolicySignature); err != nil {
+				return fmt.Errorf("webhook policy %s: rego module: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidatePolicy loads PolicyPath and the PolicyDir policies and checks the
+// engine and DefaultPolicy settings, so a broken policy fails at startup instead of denying every request.
+func (c Config) ValidatePolicy() error {
+	_, err := loadPolicies(c)
+	return err
 }
 
 // DefaultConfig returns the default webhook configuration.
@@ -19,5 +103,6 @@ func DefaultConfig() Config {
 		SchemaDir:       "schemas/v1",
 		FailOpen:        false,
 		CacheTTLSeconds: 300,
+		SubjectMode:     verify.SubjectsOptional,
 	}
 }
@@ -38,11 +38,21 @@ func AttestationRef(registryPrefix, imageRef string) (string, error) {
 	if registryPrefix == "" {
 		return "", fmt.Errorf("registry prefix is required")
 	}
+	tag, err := AttestationTag(imageRef)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s", strings.TrimSuffix(registryPrefix, "/"), tag), nil
+}
+
+// AttestationTag returns the tag under which an image's attestation bundles
+// are stored, in a registry or an OCI image layout.
+func AttestationTag(imageRef string) (string, error) {
 	tag := sanitiseImageTag(imageRef)
 	if tag == "" {
 		return "", fmt.Errorf("cannot derive attestation tag from image ref %q", imageRef)
 	}
-	return fmt.Sprintf("%s:%s", strings.TrimSuffix(registryPrefix, "/"), tag), nil
+	return tag, nil
 }
 
 // sanitiseImageTag converts an image reference into a safe OCI tag.
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"golang.org/x/sync/singleflight"
@@ -22,16 +23,41 @@ import (
 )
 
 // ociPullFunc is a package-level variable for test injection.
-var ociPullFunc = store.PullOCI
+var ociPullFunc = store.PullOCIWithOptions
+
+// referrersPullFunc is a package-level variable for test injection.
+var referrersPullFunc = store.PullReferrers
+
+// layoutPullFunc is a package-level variable for test injection.
+var layoutPullFunc = store.PullOCILayout
 
 const maxBodyBytes = 10 * 1024 * 1024 // 10 MB
 
 // Handler returns an http.Handler that processes AdmissionReview requests.
+// Policy signatures are checked and the policies loaded once here; if either
+// fails every request is denied, even with FailOpen. Later changes to the
+// policy files take effect only in a new Handler, i.e. after a restart. Mounted at both
+// /validate and /validate/, a request to /validate/<name> is admitted under
+// policy <name>, or one its PolicyOverrides let the workload's
+// PolicyAnnotation select.
 func Handler(cfg Config) http.Handler {
 	cache := newVerifierCache(time.Duration(cfg.CacheTTLSeconds) * time.Second)
 	group := &singleflight.Group{}
+	policyErr := cfg.CheckPolicy()
+	var policies *policySet
+	if policyErr == nil {
+		policies, policyErr = loadPolicies(cfg)
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		handleAdmission(w, r, cfg, cache, group)
+		if policyErr != nil {
+			denyAll(w, r, policyErr)
+			return
+		}
+		namespacePolicy := ""
+		if name, ok := strings.CutPrefix(r.URL.Path, "/validate/"); ok {
+			namespacePolicy = strings.Trim(name, "/")
+		}
+		handleAdmission(w, r, cfg, policies, namespacePolicy, cache, group)
 	})
 }
 
@@ -43,7 +69,7 @@ func HealthHandler() http.Handler {
 	})
 }
 
-func handleAdmission(w http.ResponseWriter, r *http.Request, cfg Config, cache *verifierCache, group *singleflight.Group) {
+func handleAdmission(w http.ResponseWriter, r *http.Request, cfg Config, policies *policySet, namespacePolicy string, cache *verifierCache, group *singleflight.Group) {
 	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
 	if err != nil {
 		writeError(w, cfg, nil, fmt.Errorf("read body: %w", err))
@@ -66,44 +92,67 @@ func handleAdmission(w http.ResponseWriter, r *http.Request, cfg Config, cache *
 		return
 	}
 
+	// An unknown policy name is a configuration error, so it denies even
+	// with FailOpen.
+	pol, err := policies.selectPolicy(workloadAnnotations(review.Request.Object.Raw), namespacePolicy)
+	if err != nil {
+		writeResponse(w, review.Request.UID, "", false, fmt.Sprintf("policy selection failed: %v", err))
+		return
+	}
+
 	refs := ExtractImageRefs(*spec)
 	var violations []string
 	for _, ref := range refs {
-		if err := verifyImage(ref, cfg, cache, group); err != nil {
+		if err := verifyImage(ref, cfg, pol, cache, group); err != nil {
 			violations = append(violations, fmt.Sprintf("container %q (%s): %v", ref.Container, ref.Image, err))
 		}
 	}
 
 	if len(violations) > 0 && !cfg.FailOpen {
-		writeResponse(w, review.Request.UID, false, fmt.Sprintf("attestation verification failed: %v", violations))
+		writeResponse(w, review.Request.UID, pol.label(), false, fmt.Sprintf("attestation verification failed%s: %v", pol.suffix(), violations))
 		return
 	}
-	writeResponse(w, review.Request.UID, true, "all attestations verified")
+	writeResponse(w, review.Request.UID, pol.label(), true, "all attestations verified"+pol.suffix())
 }
 
-func verifyImage(ref ImageRef, cfg Config, cache *verifierCache, group *singleflight.Group) error {
-	ociRef, err := AttestationRef(cfg.RegistryPrefix, ref.Image)
+func verifyImage(ref ImageRef, cfg Config, pol *admissionPolicy, cache *verifierCache, group *singleflight.Group) error {
+	// With referrers discovery the bundles hang off the image itself, so the
+	// image reference is both the lookup and the cache key. A layout is
+	// looked up by the attestation tag alone.
+	ociRef := ref.Image
+	var err error
+	switch {
+	case cfg.OCILayout != "":
+		ociRef, err = AttestationTag(ref.Image)
+	case !cfg.Referrers:
+		ociRef, err = AttestationRef(cfg.RegistryPrefix, ref.Image)
+	}
 	if err != nil {
 		return fmt.Errorf("construct attestation ref: %w", err)
 	}
 
+	// The same attestations may pass one policy and fail another.
+	key := ociRef
+	if pol != nil {
+		key = pol.name + "|" + ociRef
+	}
 	now := time.Now()
-	if cache.hasFresh(ociRef, now) {
+	if cache.hasFresh(key, now) {
 		return nil
 	}
 	run := func() error {
 		// Re-check cache in case another in-flight request already populated it.
-		if cache.hasFresh(ociRef, time.Now()) {
+		if cache.hasFresh(key, time.Now()) {
 			return nil
 		}
-		if err := verifyImageNoCache(ociRef, cfg); err != nil {
+		if err := verifyImageNoCache(ociRef, cfg, pol); err != nil {
 			return err
 		}
-		cache.putSuccess(ociRef, time.Now())
+		cache.putSuccess(key, time.Now())
 		return nil
 	}
 	if group != nil {
-		_, err, _ := group.Do(ociRef, func() (any, error) {
+		_, err, _ := group.Do(key, func() (any, error) {
 			return nil, run()
 		})
 		return err
@@ -111,26 +160,37 @@ func verifyImage(ref ImageRef, cfg Config, cache *verifierCache, group *singlefl
 	return run()
 }
 
-func verifyImageNoCache(ociRef string, cfg Config) error {
+func verifyImageNoCache(ociRef string, cfg Config, pol *admissionPolicy) error {
 	tmpDir, err := os.MkdirTemp("", "llmsa-webhook-")
 	if err != nil {
 		return fmt.Errorf("create temp dir: %w", err)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	outPath := filepath.Join(tmpDir, "bundle.bundle.json")
-	if err := ociPullFunc(ociRef, outPath); err != nil {
-		return fmt.Errorf("pull attestation bundle: %w", err)
+	if cfg.OCILayout != "" {
+		if _, err := layoutPullFunc(cfg.OCILayout, ociRef, tmpDir); err != nil {
+			return fmt.Errorf("read attestation bundles from oci layout: %w", err)
+		}
+	} else if cfg.Referrers {
+		if _, err := referrersPullFunc(ociRef, tmpDir, cfg.Registry); err != nil {
+			return fmt.Errorf("discover attestation bundles: %w", err)
+		}
+	} else {
+		outPath := filepath.Join(tmpDir, "bundle.bundle.json")
+		if err := ociPullFunc(ociRef, outPath, cfg.Registry); err != nil {
+			return fmt.Errorf("pull attestation bundle: %w", err)
+		}
 	}
 
-	report := verify.Run(verify.Options{
+	report := verify.Run(pol.verifyOptions(verify.Options{
 		SourcePath: tmpDir,
 		SchemaDir:  cfg.SchemaDir,
-	})
+		Subjects:   verify.SubjectOptions{Mode: cfg.SubjectMode, Registry: cfg.Registry},
+	}))
 	if !report.Passed {
 		return fmt.Errorf("exit %d: %v", report.ExitCode, report.Violations)
 	}
-	return nil
+	return pol.evaluate(tmpDir, report)
 }
 
 // podSpecFromResource extracts the PodSpec from the raw object in the
@@ -170,7 +230,33 @@ func podSpecFromResource(raw []byte) (*corev1.PodSpec, error) {
 	return nil, fmt.Errorf("unsupported resource kind")
 }
 
-func writeResponse(w http.ResponseWriter, uid k8stypes.UID, allowed bool, message string) {
+// workloadAnnotations returns the annotations of the resource, with those of
+// its pod template filling in keys the resource does not set.
+func workloadAnnotations(raw []byte) map[string]string {
+	var obj struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Spec     struct {
+			Template struct {
+				Metadata metav1.ObjectMeta `json:"metadata"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if json.Unmarshal(raw, &obj) != nil {
+		return nil
+	}
+	out := make(map[string]string, len(obj.Metadata.Annotations))
+	for k, v := range obj.Spec.Template.Metadata.Annotations {
+		out[k] = v
+	}
+	for k, v := range obj.Metadata.Annotations {
+		out[k] = v
+	}
+	return out
+}
+
+// writeResponse sends the admission decision. A non-empty policy name is
+// recorded as the "policy" audit annotation.
+func writeResponse(w http.ResponseWriter, uid k8stypes.UID, policy string, allowed bool, message string) {
 	resp := admissionv1.AdmissionReview{
 		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
 		Response: &admissionv1.AdmissionResponse{
@@ -179,17 +265,31 @@ func writeResponse(w http.ResponseWriter, uid k8stypes.UID, allowed bool, messag
 			Result:  &metav1.Status{Message: message},
 		},
 	}
+	if policy != "" {
+		resp.Response.AuditAnnotations = map[string]string{"policy": policy}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// denyAll rejects a request because the webhook cannot trust its policy.
+func denyAll(w http.ResponseWriter, r *http.Request, err error) {
+	var review admissionv1.AdmissionReview
+	body, readErr := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if readErr != nil || json.Unmarshal(body, &review) != nil || review.Request == nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeResponse(w, review.Request.UID, "", false, err.Error())
+}
+
 func writeError(w http.ResponseWriter, cfg Config, uid *k8stypes.UID, err error) {
 	if cfg.FailOpen {
 		respUID := k8stypes.UID("")
 		if uid != nil {
 			respUID = *uid
 		}
-		writeResponse(w, respUID, true, fmt.Sprintf("fail-open: %v", err))
+		writeResponse(w, respUID, "", true, fmt.Sprintf("fail-open: %v", err))
 		return
 	}
 	http.Error(w, err.Error(), http.StatusBadRequest)
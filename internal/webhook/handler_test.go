@@ -2,15 +2,23 @@ package webhook
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	admissionv1 "k8s.io/api/admission/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -19,7 +27,9 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/signed"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/store"
 )
 
 // --- Extractor Tests ---
@@ -89,7 +99,9 @@ func TestAttestationRef_EmptyPrefix(t *testing.T) {
 
 // --- Handler Tests ---
 
-func writeValidBundle(t testing.TB, dir string) {
+// writeValidBundle writes a signed prompt bundle to dir. Its statement lists
+// each of images (digest refs) as an image subject.
+func writeValidBundle(t testing.TB, dir string, images ...string) {
 	t.Helper()
 
 	// Generate a real PEM key and sign properly so verify.Run passes.
@@ -102,6 +114,11 @@ func writeValidBundle(t testing.TB, dir string) {
 		t.Fatal(err)
 	}
 
+	promptPath := filepath.Join(dir, "prompt.txt")
+	if err := os.WriteFile(promptPath, []byte("prompt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	promptDigest := hash.DigestBytes([]byte("prompt"))
 	statement := map[string]any{
 		"schema_version":   "1.0.0",
 		"statement_id":     "stmt-test",
@@ -111,19 +128,23 @@ func writeValidBundle(t testing.TB, dir string) {
 		"generator": map[string]any{
 			"name": "llmsa", "version": "0.1.0", "git_sha": "abc123",
 		},
-		"subject": []any{},
+		"subject": imageSubjects(images),
+		"materials": []any{
+			map[string]any{"name": "prompt", "uri": promptPath, "digest": map[string]any{"sha256": strings.TrimPrefix(promptDigest, "sha256:")}},
+		},
 		"predicate": map[string]any{
-			"prompt_bundle_digest": "sha256:abc",
-			"system_prompt_digest": "sha256:def",
-			"template_digests":     []any{"sha256:t1"},
-			"tool_schema_digests":  []any{"sha256:s1"},
-			"safety_policy_digest": "sha256:safety",
+			"prompt_bundle_digest": promptBundle(promptDigest, promptDigest, promptBundle(promptDigest), promptBundle(promptDigest)),
+			"system_prompt_digest": promptDigest,
+			"template_digests":     []any{promptDigest},
+			"tool_schema_digests":  []any{promptDigest},
+			"safety_policy_digest": promptDigest,
 		},
-		"privacy": map[string]any{"mode": "hash_only"},
+		"privacy":     map[string]any{"mode": "hash_only"},
+		"annotations": map[string]any{"predicate_binding": "v1"},
 	}
 
 	// Use the same canonical JSON that CreateBundle uses internally.
-	canonical, err := hash.CanonicalJSON(statement)
+	canonical, err := hash.Canonicalize(hash.DefaultCanonicalization, statement)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -140,6 +161,15 @@ func writeValidBundle(t testing.TB, dir string) {
 	}
 }
 
+func imageSubjects(images []string) []any {
+	out := make([]any, 0, len(images))
+	for _, image := range images {
+		digest := image[strings.LastIndex(image, "@")+len("@sha256:"):]
+		out = append(out, map[string]any{"name": image, "uri": "image://" + image, "digest": map[string]any{"sha256": digest}, "size_bytes": 0})
+	}
+	return out
+}
+
 func buildAdmissionReview(t testing.TB, obj any) []byte {
 	t.Helper()
 	raw, err := json.Marshal(obj)
@@ -167,7 +197,7 @@ func TestHandlerAllowValidAttestation(t *testing.T) {
 	writeValidBundle(t, bundleDir)
 
 	original := ociPullFunc
-	ociPullFunc = func(ociRef, outPath string) error {
+	ociPullFunc = func(ociRef, outPath string, _ store.RegistryOptions) error {
 		data, err := os.ReadFile(filepath.Join(bundleDir, "bundle.bundle.json"))
 		if err != nil {
 			return err
@@ -205,13 +235,142 @@ func TestHandlerAllowValidAttestation(t *testing.T) {
 	}
 }
 
+func TestHandlerOCILayout(t *testing.T) {
+	signed := "ghcr.io/acme/model-server@sha256:" + strings.Repeat("ab", 32)
+	tag, err := AttestationTag(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundleDir := t.TempDir()
+	writeValidBundle(t, bundleDir)
+	layoutPath := filepath.Join(t.TempDir(), "attestations.tar")
+	if _, err := store.ExportOCILayout(layoutPath, tag, store.LayoutContents{Bundles: []string{filepath.Join(bundleDir, "bundle.bundle.json")}}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{SchemaDir: "../../schemas/v1", OCILayout: layoutPath}
+	for _, tc := range []struct {
+		image   string
+		allowed bool
+	}{
+		{signed, true},
+		{"ghcr.io/acme/unsigned:v1", false},
+	} {
+		pod := corev1.Pod{
+			TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+			Spec:     corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: tc.image}}},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(buildAdmissionReview(t, pod)))
+		rec := httptest.NewRecorder()
+		Handler(cfg).ServeHTTP(rec, req)
+		var resp admissionv1.AdmissionReview
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if resp.Response.Allowed != tc.allowed {
+			t.Fatalf("%s: allowed=%v, want %v: %s", tc.image, resp.Response.Allowed, tc.allowed, resp.Response.Result)
+		}
+		if !tc.allowed && !strings.Contains(resp.Response.Result.Message, "no attestation set tagged") {
+			t.Fatalf("unexpected deny message: %s", resp.Response.Result.Message)
+		}
+	}
+}
+
+func TestHandlerReferrersDiscovery(t *testing.T) {
+	srv := httptest.NewServer(registry.New(registry.WithReferrersSupport(true)))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := name.ParseReference(host + "/app/model-server:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(tag, img); err != nil {
+		t.Fatal(err)
+	}
+	digest, _ := img.Digest()
+	attached := fmt.Sprintf("%s/app/model-server@%s", host, digest)
+	bare, _ := random.Image(64, 1)
+	bareTag, _ := name.ParseReference(host + "/app/unsigned:v1")
+	if err := remote.Write(bareTag, bare); err != nil {
+		t.Fatal(err)
+	}
+
+	bundleDir := t.TempDir()
+	writeValidBundle(t, bundleDir, attached)
+	if _, err := store.PublishOCIWithOptions(filepath.Join(bundleDir, "bundle.bundle.json"), "", store.PublishOptions{Subject: attached}); err != nil {
+		t.Fatalf("attach bundle: %v", err)
+	}
+
+	cfg := Config{SchemaDir: "../../schemas/v1", Referrers: true}
+	for _, tc := range []struct {
+		image   string
+		allowed bool
+	}{
+		{attached, true},
+		{host + "/app/unsigned:v1", false},
+	} {
+		pod := corev1.Pod{
+			TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+			Spec:     corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: tc.image}}},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(buildAdmissionReview(t, pod)))
+		rec := httptest.NewRecorder()
+		Handler(cfg).ServeHTTP(rec, req)
+		var resp admissionv1.AdmissionReview
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if resp.Response.Allowed != tc.allowed {
+			t.Fatalf("%s: allowed=%v, want %v: %s", tc.image, resp.Response.Allowed, tc.allowed, resp.Response.Result)
+		}
+		if !tc.allowed && !strings.Contains(resp.Response.Result.Message, "no llmsa attestations") {
+			t.Fatalf("unexpected deny message: %s", resp.Response.Result.Message)
+		}
+	}
+}
+
+func TestHandlerPassesRegistryOptions(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeValidBundle(t, bundleDir)
+
+	original := ociPullFunc
+	var got store.RegistryOptions
+	ociPullFunc = func(_, outPath string, reg store.RegistryOptions) error {
+		got = reg
+		data, err := os.ReadFile(filepath.Join(bundleDir, "bundle.bundle.json"))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(outPath, data, 0o644)
+	}
+	t.Cleanup(func() { ociPullFunc = original })
+
+	reg := store.RegistryOptions{PullSecrets: []string{"/var/run/secrets/regcred"}, InsecureRegistries: []string{"mirror.local:5000"}}
+	cfg := Config{RegistryPrefix: "mirror.local:5000/attestations", SchemaDir: "../../schemas/v1", Registry: reg}
+	pod := corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		Spec:     corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "myapp@sha256:abc123"}}},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(buildAdmissionReview(t, pod)))
+	rec := httptest.NewRecorder()
+	Handler(cfg).ServeHTTP(rec, req)
+	if got.PullSecrets[0] != "/var/run/secrets/regcred" || got.InsecureRegistries[0] != "mirror.local:5000" {
+		t.Fatalf("registry options not passed to pull: %+v", got)
+	}
+}
+
 func TestHandlerCachesSuccessfulVerification(t *testing.T) {
 	bundleDir := t.TempDir()
 	writeValidBundle(t, bundleDir)
 
 	original := ociPullFunc
 	pullCount := 0
-	ociPullFunc = func(_ string, outPath string) error {
+	ociPullFunc = func(_ string, outPath string, _ store.RegistryOptions) error {
 		pullCount++
 		data, err := os.ReadFile(filepath.Join(bundleDir, "bundle.bundle.json"))
 		if err != nil {
@@ -253,7 +412,7 @@ func TestHandlerCachesSuccessfulVerification(t *testing.T) {
 
 func TestHandlerDenyMissingAttestation(t *testing.T) {
 	original := ociPullFunc
-	ociPullFunc = func(_, _ string) error {
+	ociPullFunc = func(_, _ string, _ store.RegistryOptions) error {
 		return fmt.Errorf("not found")
 	}
 	t.Cleanup(func() { ociPullFunc = original })
@@ -286,9 +445,387 @@ func TestHandlerDenyMissingAttestation(t *testing.T) {
 	}
 }
 
+func TestHandlerDeniesUnsignedPolicy(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("version: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{
+		RegistryPrefix:  "ghcr.io/test/attestations",
+		SchemaDir:       "../../schemas/v1",
+		PolicyPath:      policyPath,
+		FailOpen:        true,
+		PolicySignature: signed.Options{Require: true},
+	}
+	if err := cfg.CheckPolicy(); err == nil {
+		t.Fatal("expected unsigned policy to be rejected")
+	}
+
+	pod := corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		Spec:     corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "myapp@sha256:abc123"}}},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(buildAdmissionReview(t, pod)))
+	rec := httptest.NewRecorder()
+	Handler(cfg).ServeHTTP(rec, req)
+
+	var resp admissionv1.AdmissionReview
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Response == nil || resp.Response.Allowed {
+		t.Fatal("expected deny even with fail-open when the policy is unsigned")
+	}
+	if !strings.Contains(resp.Response.Result.Message, "is not signed") {
+		t.Fatalf("unexpected message: %s", resp.Response.Result.Message)
+	}
+}
+
+// --- Policy Tests ---
+
+// admitWithPolicy serves the bundle in bundleDir for every image and returns
+// the admission response under a policy with the given YAML body.
+func admitWithPolicy(t *testing.T, bundleDir, policy string, cfg Config) *admissionv1.AdmissionResponse {
+	t.Helper()
+	original := ociPullFunc
+	ociPullFunc = func(_, outPath string, _ store.RegistryOptions) error {
+		data, err := os.ReadFile(filepath.Join(bundleDir, "bundle.bundle.json"))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(outPath, data, 0o644)
+	}
+	t.Cleanup(func() { ociPullFunc = original })
+
+	cfg.PolicyPath = filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(cfg.PolicyPath, []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.RegistryPrefix = "ghcr.io/test/attestations"
+	cfg.SchemaDir = "../../schemas/v1"
+	return admitPod(t, Handler(cfg))
+}
+
+// admitPod sends h an admission review for a single-container pod.
+func admitPod(t *testing.T, h http.Handler) *admissionv1.AdmissionResponse {
+	t.Helper()
+	pod := corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		Spec:     corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "myapp@sha256:abc123"}}},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(buildAdmissionReview(t, pod)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp admissionv1.AdmissionReview
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Response == nil {
+		t.Fatal("response is nil")
+	}
+	return resp.Response
+}
+
+const fullSetPolicy = `version: 1
+gates:
+  - id: model-serving
+    always: true
+    required_attestations: [prompt_attestation, eval_attestation, route_attestation, slo_attestation]
+    message: "Model-serving images need the full attestation set."
+  - id: prod-eval
+    trigger_environments: [prod]
+    required_attestations: [eval_attestation]
+`
+
+func TestHandlerPolicyGatesListMissingTypes(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeValidBundle(t, bundleDir)
+
+	resp := admitWithPolicy(t, bundleDir, fullSetPolicy, Config{})
+	if resp.Allowed {
+		t.Fatal("expected deny for an incomplete attestation set")
+	}
+	msg := resp.Result.Message
+	if !strings.Contains(msg, "Model-serving images need the full attestation set.") ||
+		!strings.Contains(msg, "missing attestations: eval_attestation, route_attestation, slo_attestation") {
+		t.Fatalf("deny message should name the gate and the missing types: %s", msg)
+	}
+	if strings.Contains(msg, "prod-eval") {
+		t.Fatalf("environment gate fired without --env prod: %s", msg)
+	}
+
+	resp = admitWithPolicy(t, bundleDir, fullSetPolicy, Config{Environment: "prod"})
+	if !strings.Contains(resp.Result.Message, "prod-eval missing attestations: eval_attestation") {
+		t.Fatalf("expected the prod gate to fire: %s", resp.Result.Message)
+	}
+
+	promptOnly := "version: 1\ngates:\n  - id: prompt\n    always: true\n    required_attestations: [prompt_attestation]\n  - id: paths\n    trigger_paths: [\"**\"]\n    required_attestations: [slo_attestation]\n"
+	if resp := admitWithPolicy(t, bundleDir, promptOnly, Config{}); !resp.Allowed {
+		t.Fatalf("expected allow when every firing gate is satisfied: %s", resp.Result.Message)
+	}
+}
+
+func TestHandlerShippedAdmissionPolicy(t *testing.T) {
+	policy, err := os.ReadFile("../../policy/examples/admission.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chartCopy, err := os.ReadFile("../../deploy/helm/files/admission.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(policy, chartCopy) {
+		t.Fatal("deploy/helm/files/admission.yaml differs from policy/examples/admission.yaml")
+	}
+
+	bundleDir := t.TempDir()
+	writeValidBundle(t, bundleDir)
+
+	resp := admitWithPolicy(t, bundleDir, string(policy), Config{})
+	if resp.Allowed || !strings.Contains(resp.Result.Message, "A001 missing attestations: corpus_attestation, eval_attestation") {
+		t.Fatalf("expected the always gate to deny, got allowed=%v: %s", resp.Allowed, resp.Result.Message)
+	}
+	if strings.Contains(resp.Result.Message, "A002") {
+		t.Fatalf("production gate fired without --env: %s", resp.Result.Message)
+	}
+
+	resp = admitWithPolicy(t, bundleDir, string(policy), Config{Environment: "prod"})
+	if !strings.Contains(resp.Result.Message, "A002 missing attestations: route_attestation, slo_attestation") {
+		t.Fatalf("expected the production gate to fire: %s", resp.Result.Message)
+	}
+}
+
+func TestHandlerPolicyRegoEngine(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeValidBundle(t, bundleDir)
+
+	policy := "version: 1\ngates:\n  - id: model-serving\n    always: true\n    required_attestations: [prompt_attestation, slo_attestation]\n"
+	resp := admitWithPolicy(t, bundleDir, policy, Config{PolicyEngine: "rego", RegoPolicyPath: "../../policy/examples/rego-gates.rego"})
+	if resp.Allowed || !strings.Contains(resp.Result.Message, "slo_attestation") {
+		t.Fatalf("expected rego deny naming slo_attestation, got allowed=%v: %s", resp.Allowed, resp.Result.Message)
+	}
+
+	resp = admitWithPolicy(t, bundleDir, policy, Config{PolicyEngine: "rego"})
+	if resp.Allowed || !strings.Contains(resp.Result.Message, "rego policy path") {
+		t.Fatalf("expected deny-all without a rego policy, got allowed=%v: %s", resp.Allowed, resp.Result.Message)
+	}
+}
+
+func TestHandlerRegoModuleReadOnce(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeValidBundle(t, bundleDir)
+	original := ociPullFunc
+	ociPullFunc = func(_, outPath string, _ store.RegistryOptions) error {
+		data, err := os.ReadFile(filepath.Join(bundleDir, "bundle.bundle.json"))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(outPath, data, 0o644)
+	}
+	t.Cleanup(func() { ociPullFunc = original })
+
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	policy := "version: 1\ngates:\n  - id: model-serving\n    always: true\n    required_attestations: [prompt_attestation, slo_attestation]\n"
+	if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	module, err := os.ReadFile("../../policy/examples/rego-gates.rego")
+	if err != nil {
+		t.Fatal(err)
+	}
+	regoPath := filepath.Join(dir, "policy.rego")
+	if err := os.WriteFile(regoPath, module, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h := Handler(Config{
+		RegistryPrefix: "ghcr.io/test/attestations",
+		SchemaDir:      "../../schemas/v1",
+		PolicyPath:     policyPath,
+		PolicyEngine:   "rego",
+	})
+
+	// Swapping the module for an allow-all one after startup must not
+	// change the decision: the webhook evaluates the module it loaded.
+	allowAll := "package llmsa.gates\n\nresult := {\"allow\": true, \"violations\": []}\n"
+	if err := os.WriteFile(regoPath, []byte(allowAll), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resp := admitPod(t, h)
+	if resp.Allowed || !strings.Contains(resp.Result.Message, "slo_attestation") {
+		t.Fatalf("expected the startup module to deny, got allowed=%v: %s", resp.Allowed, resp.Result.Message)
+	}
+}
+
+func TestCheckPolicyRequiresSignedRegoModule(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "policy.pem")
+	if err := sign.GeneratePEMPrivateKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := sign.NewPEMSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signFile := func(path string, content []byte) {
+		t.Helper()
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		bundle, err := signed.Sign(path, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sign.WriteBundle(signed.BundlePath(path), bundle); err != nil {
+			t.Fatal(err)
+		}
+	}
+	policyPath := filepath.Join(dir, "policy.yaml")
+	signFile(policyPath, []byte("version: 1\n"))
+	regoPath := filepath.Join(dir, "policy.rego")
+	if err := os.WriteFile(regoPath, []byte("package llmsa.gates\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{
+		PolicyPath:      policyPath,
+		PolicyEngine:    "rego",
+		PolicySignature: signed.Options{Require: true, Trust: signed.TrustRoot{PublicKeys: []ed25519.PublicKey{signer.PublicKey}}},
+	}
+	if err := cfg.CheckPolicy(); err == nil || !strings.Contains(err.Error(), "policy.rego is not signed") {
+		t.Fatalf("expected unsigned rego module error, got %v", err)
+	}
+	signFile(regoPath, []byte("package llmsa.gates\n"))
+	if err := cfg.CheckPolicy(); err != nil {
+		t.Fatalf("signed rego module: %v", err)
+	}
+}
+
+func TestHandlerPolicySignerIdentity(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeValidBundle(t, bundleDir)
+	path := filepath.Join(bundleDir, "bundle.bundle.json")
+	bundle, err := sign.ReadBundle(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle.Envelope.Signatures[0].Provider = "sigstore"
+	bundle.Envelope.Signatures[0].OIDCIssuer = "https://issuer.example.com"
+	if err := sign.WriteBundle(path, bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := admitWithPolicy(t, bundleDir, "version: 1\noidc_issuer: https://token.actions.githubusercontent.com\n", Config{})
+	if resp.Allowed || !strings.Contains(resp.Result.Message, "oidc issuer mismatch") {
+		t.Fatalf("expected signer policy deny, got allowed=%v: %s", resp.Allowed, resp.Result.Message)
+	}
+	if resp := admitWithPolicy(t, bundleDir, "version: 1\noidc_issuer: https://issuer.example.com\n", Config{}); !resp.Allowed {
+		t.Fatalf("expected allow for the trusted issuer: %s", resp.Result.Message)
+	}
+}
+
+func TestHandlerSelectsPolicyByNamespaceAndAnnotation(t *testing.T) {
+	bundleDir := t.TempDir()
+	writeValidBundle(t, bundleDir)
+	original := ociPullFunc
+	ociPullFunc = func(_, outPath string, _ store.RegistryOptions) error {
+		data, err := os.ReadFile(filepath.Join(bundleDir, "bundle.bundle.json"))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(outPath, data, 0o644)
+	}
+	t.Cleanup(func() { ociPullFunc = original })
+
+	// Laid out like a mounted ConfigMap, with a signature that is not a policy.
+	policyDir := t.TempDir()
+	os.WriteFile(filepath.Join(policyDir, "research.yaml"), []byte("version: 1\n"), 0o644)
+	os.WriteFile(filepath.Join(policyDir, "strict.yaml"), []byte(fullSetPolicy), 0o644)
+	os.WriteFile(filepath.Join(policyDir, "strict.yaml.bundle.json"), []byte("{}"), 0o644)
+	os.Mkdir(filepath.Join(policyDir, "..data"), 0o755)
+
+	cfg := Config{
+		RegistryPrefix:  "ghcr.io/test/attestations",
+		SchemaDir:       "../../schemas/v1",
+		CacheTTLSeconds: 60,
+		PolicyDir:       policyDir,
+		DefaultPolicy:   "strict",
+		PolicyOverrides: map[string][]string{"research": {"strict"}},
+	}
+	if err := cfg.ValidatePolicy(); err != nil {
+		t.Fatal(err)
+	}
+	handler := Handler(cfg)
+
+	podWith := func(annotations map[string]string) corev1.Pod {
+		return corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "myapp@sha256:abc123"}}},
+		}
+	}
+	deployment := appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{PolicyAnnotation: "strict"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "myapp@sha256:abc123"}}},
+		}},
+	}
+
+	// research runs first so a cached pass must not leak into strict.
+	for _, tc := range []struct {
+		desc, path string
+		obj        any
+		allowed    bool
+		policy     string
+		message    string
+	}{
+		{"namespace routed to research", "/validate/research", podWith(nil), true, "research", "all attestations verified (policy research)"},
+		{"default policy", "/validate", podWith(nil), false, "strict", "(policy strict)"},
+		{"annotation overrides the namespace", "/validate/research", podWith(map[string]string{PolicyAnnotation: "strict"}), false, "strict", "missing attestations: eval_attestation"},
+		{"pod template annotation", "/validate/research", deployment, false, "strict", "(policy strict)"},
+		{"annotation naming the floor", "/validate", podWith(map[string]string{PolicyAnnotation: "strict"}), false, "strict", "(policy strict)"},
+		{"annotation cannot downgrade the default", "/validate", podWith(map[string]string{PolicyAnnotation: "research"}), false, "", `policy "research" may not override "strict"`},
+		{"unlisted annotation", "/validate/research", podWith(map[string]string{PolicyAnnotation: "lenient"}), false, "", `policy "lenient" may not override "research"`},
+		{"unknown policy", "/validate/lenient", podWith(nil), false, "", `unknown policy "lenient"`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewReader(buildAdmissionReview(t, tc.obj)))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var resp admissionv1.AdmissionReview
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode response: %v", tc.desc, err)
+		}
+		if resp.Response.Allowed != tc.allowed || resp.Response.AuditAnnotations["policy"] != tc.policy {
+			t.Fatalf("%s: allowed=%v policy=%q, want %v %q: %s", tc.desc, resp.Response.Allowed, resp.Response.AuditAnnotations["policy"], tc.allowed, tc.policy, resp.Response.Result.Message)
+		}
+		if !strings.Contains(resp.Response.Result.Message, tc.message) {
+			t.Fatalf("%s: unexpected message: %s", tc.desc, resp.Response.Result.Message)
+		}
+	}
+}
+
+func TestPolicyDirErrors(t *testing.T) {
+	policyDir := t.TempDir()
+	os.WriteFile(filepath.Join(policyDir, "research.yaml"), []byte("version: 1\n"), 0o644)
+
+	if err := (Config{PolicyDir: policyDir, DefaultPolicy: "strict"}).ValidatePolicy(); err == nil || !strings.Contains(err.Error(), `default policy "strict" not found`) {
+		t.Fatalf("expected missing default error, got %v", err)
+	}
+	if err := (Config{PolicyDir: policyDir, PolicySignature: signed.Options{Require: true}}).CheckPolicy(); err == nil || !strings.Contains(err.Error(), "research.yaml is not signed") {
+		t.Fatalf("expected unsigned dir policy error, got %v", err)
+	}
+	if err := (Config{PolicyDir: policyDir, PolicyOverrides: map[string][]string{"research": {"strict"}}}).ValidatePolicy(); err == nil || !strings.Contains(err.Error(), `override research: policy "strict" not found`) {
+		t.Fatalf("expected missing override error, got %v", err)
+	}
+	os.WriteFile(filepath.Join(policyDir, "research.yml"), []byte("version: 1\n"), 0o644)
+	if err := (Config{PolicyDir: policyDir}).ValidatePolicy(); err == nil || !strings.Contains(err.Error(), "defined by both") {
+		t.Fatalf("expected duplicate name error, got %v", err)
+	}
+}
+
 func TestHandlerFailOpenOnError(t *testing.T) {
 	original := ociPullFunc
-	ociPullFunc = func(_, _ string) error {
+	ociPullFunc = func(_, _ string, _ store.RegistryOptions) error {
 		return fmt.Errorf("registry unavailable")
 	}
 	t.Cleanup(func() { ociPullFunc = original })
@@ -324,7 +861,7 @@ func TestHandlerFailOpenOnError(t *testing.T) {
 
 func TestHandlerDeploymentExtraction(t *testing.T) {
 	original := ociPullFunc
-	ociPullFunc = func(_, _ string) error {
+	ociPullFunc = func(_, _ string, _ store.RegistryOptions) error {
 		return fmt.Errorf("not found")
 	}
 	t.Cleanup(func() { ociPullFunc = original })
@@ -395,7 +932,7 @@ func BenchmarkHandlerWarmCache(b *testing.B) {
 	original := ociPullFunc
 	defer func() { ociPullFunc = original }()
 	pullCount := 0
-	ociPullFunc = func(_ string, outPath string) error {
+	ociPullFunc = func(_ string, outPath string, _ store.RegistryOptions) error {
 		pullCount++
 		data, err := os.ReadFile(filepath.Join(bundleDir, "bundle.bundle.json"))
 		if err != nil {
@@ -430,3 +967,25 @@ func BenchmarkHandlerWarmCache(b *testing.B) {
 		b.Fatalf("expected warm cache to avoid repeated pulls, pull count=%d", pullCount)
 	}
 }
+
+// promptBundle mirrors how the prompt collector derives prompt_bundle_digest.
+func promptBundle(parts ...string) string {
+	sort.Strings(parts)
+	return hash.DigestBytes([]byte(strings.Join(parts, "\n")))
+}
+
+func TestParsePolicyOverrides(t *testing.T) {
+	got, err := ParsePolicyOverrides([]string{"research=strict, audit", "default=strict"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"research": {"strict", "audit"}, "default": {"strict"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for _, bad := range []string{"research", "=strict", "research="} {
+		if _, err := ParsePolicyOverrides([]string{bad}); err == nil {
+			t.Fatalf("%q: expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package webhook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	policyrego "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/rego"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/signed"
+	policyyaml "github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/policy/yaml"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
+)
+
+// PolicyAnnotation on a workload, or on its pod template, names the policy
+// its images are admitted under.
+const PolicyAnnotation = "llmsa.dev/policy"
+
+// admissionPolicy is one loaded policy: its signer identity, chain and
+// semantic rules feed verify.Run, and its gates are evaluated against each
+// image's attestation set. With the rego engine, regoModule holds the module
+// read (and signature-checked) at startup, under the name regoName.
+type admissionPolicy struct {
+	name       string
+	policy     policyyaml.Policy
+	engine     string
+	regoName   string
+	regoModule []byte
+	trigger    policyyaml.TriggerContext
+}
+
+// policySet holds every policy the webhook can select, by name.
+type policySet struct {
+	byName      map[string]*admissionPolicy
+	defaultName string
+	overrides   map[string][]string
+}
+
+// policyFiles lists the policies to load by name: PolicyPath under its file
+// stem, then every *.yaml or *.yml file in PolicyDir. Hidden entries, such as
+// the ..data links of a mounted ConfigMap, are skipped.
+func (c Config) policyFiles() (map[string]string, error) {
+	files := make(map[string]string)
+	if c.PolicyPath != "" {
+		files[policyName(c.PolicyPath)] = c.PolicyPath
+	}
+	if c.PolicyDir == "" {
+		return files, nil
+	}
+	entries, err := os.ReadDir(c.PolicyDir)
+	if err != nil {
+		return nil, fmt.Errorf("webhook policy dir: %w", err)
+	}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(c.PolicyDir, e.Name())
+		name := policyName(path)
+		if prev, ok := files[name]; ok && prev != path {
+			return nil, fmt.Errorf("webhook policy %q is defined by both %s and %s", name, prev, path)
+		}
+		files[name] = path
+	}
+	return files, nil
+}
+
+func policyName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// loadPolicies loads every policy from cfg. Without any, only signatures,
+// schemas and the built-in chain are checked.
+func loadPolicies(cfg Config) (*policySet, error) {
+	files, err := cfg.policyFiles()
+	if err != nil {
+		return nil, err
+	}
+	set := &policySet{byName: make(map[string]*admissionPolicy, len(files)), defaultName: cfg.DefaultPolicy, overrides: cfg.PolicyOverrides}
+	if set.defaultName == "" && cfg.PolicyPath != "" {
+		set.defaultName = policyName(cfg.PolicyPath)
+	}
+	for name, path := range files {
+		pol, err := loadAdmissionPolicy(name, path, cfg)
+		if err != nil {
+			return nil, err
+		}
+		set.byName[name] = pol
+	}
+	if _, ok := set.byName[set.defaultName]; set.defaultName != "" && !ok {
+		return nil, fmt.Errorf("webhook policy: default policy %q not found", set.defaultName)
+	}
+	for floor, names := range set.overrides {
+		for _, name := range append([]string{floor}, names...) {
+			if _, ok := set.byName[name]; !ok {
+				return nil, fmt.Errorf("webhook policy override %s: policy %q not found", floor, name)
+			}
+		}
+	}
+	return set, nil
+}
+
+// loadAdmissionPolicy loads one policy, parsing the same bytes whose
+// signature cfg.PolicySignature checks. With the rego engine, a <name>.rego
+// file next to it takes precedence over cfg.RegoPolicyPath; the module is
+// checked against cfg.PolicySignature the same way and kept in memory, so
+// later edits to the file do not change admission decisions.
+func loadAdmissionPolicy(name, path string, cfg Config) (*admissionPolicy, error) {
+	raw, err := signed.Load(path, cfg.PolicySignature)
+	if err != nil {
+		return nil, fmt.Errorf("webhook policy %s: %w", name, err)
+	}
+	p, err := policyyaml.LoadPolicyBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("webhook policy %s: %w", name, err)
+	}
+	engine := cfg.PolicyEngine
+	var regoName string
+	var regoModule []byte
+	switch engine {
+	case "":
+		engine = "yaml"
+	case "yaml":
+	case "rego":
+		regoPath := cfg.regoModulePath(name, path)
+		if regoPath == "" {
+			return nil, fmt.Errorf("webhook policy %s: the rego engine needs a rego policy path", name)
+		}
+		regoModule, err = signed.Load(regoPath, cfg.PolicySignature)
+		if err != nil {
+			return nil, fmt.Errorf("webhook policy %s: rego module: %w", name, err)
+		}
+		regoName = filepath.Base(regoPath)
+	default:
+		return nil, fmt.Errorf("webhook policy: unsupported policy engine %s", engine)
+	}
+	return &admissionPolicy{
+		name:       name,
+		policy:     p,
+		engine:     engine,
+		regoName:   regoName,
+		regoModule: regoModule,
+		trigger:    policyyaml.TriggerContext{Environment: cfg.Environment},
+	}, nil
+}
+
+// regoModulePath is the Rego module for the policy name at path: a
+// <name>.rego file next to it, else RegoPolicyPath.
+func (c Config) regoModulePath(name, path string) string {
+	if own := filepath.Join(filepath.Dir(path), name+".rego"); fileExists(own) {
+		return own
+	}
+	return c.RegoPolicyPath
+}
+
+// selectPolicy picks the policy for a workload: the policy its namespace
+// routes to, else the default, is the floor. Its PolicyAnnotation may
+// replace the floor only with a policy listed in the floor's overrides;
+// without a floor any policy may be selected. A nil policy (with an empty
+// name) means verification only.
+func (s *policySet) selectPolicy(annotations map[string]string, namespacePolicy string) (*admissionPolicy, error) {
+	name := s.defaultName
+	if namespacePolicy != "" {
+		name = namespacePolicy
+	}
+	if v := annotations[PolicyAnnotation]; v != "" && v != name {
+		if name != "" && !slices.Contains(s.overrides[name], v) {
+			return nil, fmt.Errorf("policy %q may not override %q", v, name)
+		}
+		name = v
+	}
+	if name == "" {
+		return nil, nil
+	}
+	pol, ok := s.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown policy %q", name)
+	}
+	return pol, nil
+}
+
+func fileExists(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
+
+// label is the policy name echoed in admission responses.
+func (p *admissionPolicy) label() string {
+	if p == nil {
+		return ""
+	}
+	return p.name
+}
+
+func (p *admissionPolicy) suffix() string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (policy %s)", p.name)
+}
+
+// verifyOptions adds the policy's signer, chain and semantic rules to opts.
+func (p *admissionPolicy) verifyOptions(opts verify.Options) verify.Options {
+	if p == nil {
+		return opts
+	}
+	opts.SignerPolicy = verify.SignerPolicy{OIDCIssuer: p.policy.OIDCIssuer, IdentityRegex: p.policy.IdentityRegex}
+	opts.Chain = p.policy.Chain
+	opts.Semantic = p.policy.Semantic
+	return opts
+}
+
+// evaluate runs the policy gates over the verified bundles in dir. There are
+// no changed files at admission, so only gates with always: true or a
+// matching trigger_environments entry fire.
+func (p *admissionPolicy) evaluate(dir string, verification verify.Report) error {
+	if p == nil {
+		return nil
+	}
+	statements, err := policyyaml.LoadStatements(dir)
+	if err != nil {
+		return fmt.Errorf("load statements: %w", err)
+	}
+	if p.engine == "rego" {
+		bundles, err := policyrego.LoadBundles(dir)
+		if err != nil {
+			return fmt.Errorf("load bundles: %w", err)
+		}
+		input := policyrego.BuildInputWithOptions(p.policy, statements, nil, policyrego.InputOptions{
+			Bundles:      bundles,
+			Verification: &verification,
+			Trigger:      p.trigger,
+		})
+		result, err := policyrego.EvaluateModule(p.regoName, p.regoModule, input)
+		if err != nil {
+			return fmt.Errorf("evaluate rego policy: %w", err)
+		}
+		if result.Allow {
+			return nil
+		}
+		if len(result.Violations) == 0 {
+			return fmt.Errorf("policy denied: rego policy denied request")
+		}
+		return fmt.Errorf("policy denied: %s", strings.Join(result.Violations, "; "))
+	}
+
+	ev, err := policyyaml.EvaluateContext(p.policy, statements, nil, p.trigger)
+	if err != nil {
+		return fmt.Errorf("evaluate policy: %w", err)
+	}
+	if len(ev.Violations) == 0 {
+		return nil
+	}
+	// A gate's own message may not say which types are missing; the deny
+	// message always does.
+	var violations []string
+	for _, g := range ev.Gates {
+		for _, v := range g.Violations {
+			if v == g.Message && len(g.MissingAttestations) > 0 {
+				v = fmt.Sprintf("%s (%s missing attestations: %s)", v, g.ID, strings.Join(g.MissingAttestations, ", "))
+			}
+			violations = append(violations, v)
+		}
+	}
+	if len(violations) == 0 {
+		// Plaintext exposure is reported without gate results.
+		violations = ev.Violations
+	}
+	return fmt.Errorf("policy denied: %s", strings.Join(violations, "; "))
+}
+
+// ParsePolicyOverrides parses <floor>=<name>[,<name>...] entries into
+// Config.PolicyOverrides.
+func ParsePolicyOverrides(values []string) (map[string][]string, error) {
+	out := make(map[string][]string, len(values))
+	for _, v := range values {
+		floor, names, ok := strings.Cut(v, "=")
+		floor = strings.TrimSpace(floor)
+		if !ok || floor == "" || strings.TrimSpace(names) == "" {
+			return nil, fmt.Errorf("invalid policy override %q (want <policy>=<name>[,<name>])", v)
+		}
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				out[floor] = append(out[floor], name)
+			}
+		}
+	}
+	return out, nil
+}
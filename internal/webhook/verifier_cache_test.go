@@ -3,6 +3,8 @@ package webhook
 import (
 	"testing"
 	"time"
+
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
 )
 
 func TestDefaultConfigIncludesCacheTTL(t *testing.T) {
@@ -10,6 +12,9 @@ func TestDefaultConfigIncludesCacheTTL(t *testing.T) {
 	if cfg.CacheTTLSeconds <= 0 {
 		t.Fatalf("expected positive cache ttl, got %d", cfg.CacheTTLSeconds)
 	}
+	if cfg.SubjectMode != verify.SubjectsOptional {
+		t.Fatalf("expected optional subject mode, got %q", cfg.SubjectMode)
+	}
 }
 
 func TestVerifierCacheFreshAndExpiry(t *testing.T) {
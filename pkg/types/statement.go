@@ -21,14 +21,29 @@ type Generator struct {
 }
 
 type Subject struct {
-	Name      string `json:"name"`
-	URI       string `json:"uri"`
-	Digest    Digest `json:"digest"`
-	SizeBytes int64  `json:"size_bytes"`
+	Name      string    `json:"name"`
+	URI       string    `json:"uri"`
+	Digest    Digest    `json:"digest"`
+	SizeBytes int64     `json:"size_bytes"`
+	Tree      *TreeSpec `json:"tree,omitempty"`
 }
 
-type Digest struct {
-	SHA256 string `json:"sha256"`
+// TreeSpec records the hashing rules used for a directory subject, or for a
+// file enumerated from one (Root is then the enclosing directory URI).
+type TreeSpec struct {
+	Root      string `json:"root,omitempty"`
+	Symlinks  string `json:"symlinks"`
+	EmptyDirs bool   `json:"empty_dirs,omitempty"`
+	FileModes bool   `json:"file_modes,omitempty"`
+}
+
+// Digest maps a digest algorithm name (sha256, sha512, sha3-256) to its hex
+// value. Statements written before multi-algorithm support carry only sha256.
+type Digest map[string]string
+
+// SHA256 returns the sha256 hex value, kept for callers that predate multi-algorithm digests.
+func (d Digest) SHA256() string {
+	return d[DigestSHA256]
 }
 
 type Privacy struct {
@@ -37,6 +52,12 @@ type Privacy struct {
 	EncryptionRecipientFingerprint string `json:"encryption_recipient_fingerprint,omitempty"`
 }
 
+const (
+	DigestSHA256  = "sha256"
+	DigestSHA512  = "sha512"
+	DigestSHA3256 = "sha3-256"
+)
+
 const (
 	AttestationPrompt = "prompt_attestation"
 	AttestationCorpus = "corpus_attestation"
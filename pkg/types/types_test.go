@@ -73,7 +73,7 @@ func TestStatementJSON_RoundTrip(t *testing.T) {
 				Name: "system_prompt.txt",
 				URI:  "file://system_prompt.txt",
 				Digest: Digest{
-					SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+					DigestSHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
 				},
 				SizeBytes: 42,
 			},
@@ -106,7 +106,7 @@ func TestStatementJSON_RoundTrip(t *testing.T) {
 	if len(got.Subject) != 1 {
 		t.Fatalf("subject count = %d", len(got.Subject))
 	}
-	if got.Subject[0].Digest.SHA256 != s.Subject[0].Digest.SHA256 {
+	if got.Subject[0].Digest.SHA256() != s.Subject[0].Digest.SHA256() {
 		t.Errorf("subject digest mismatch")
 	}
 	if got.Privacy.Mode != "hash_only" {
@@ -30,7 +30,7 @@ present_types[t] if {
 
 gate_violations[msg] if {
   some g in input.gates
-  gate_triggered(g.trigger_paths)
+  gate_enforced(g)
   missing := [r | some r in g.required_attestations; not present_types[r]]
   count(missing) > 0
   g.message != ""
@@ -39,17 +39,73 @@ gate_violations[msg] if {
 
 gate_violations[msg] if {
   some g in input.gates
-  gate_triggered(g.trigger_paths)
+  gate_enforced(g)
   missing := [r | some r in g.required_attestations; not present_types[r]]
   count(missing) > 0
   g.message == ""
   msg := sprintf("%s missing attestations: %v", [g.id, missing])
 }
 
-gate_triggered(patterns) if {
-  some p in patterns
+# A gate is enforced when at least one triggering changed file is not
+# covered by an active waiver. Statement-scoped waivers apply only to YAML
+# conditions, which this policy does not evaluate.
+gate_enforced(g) if {
+  some p in g.trigger_paths
   some c in input.changed_files
   path_match(c, p)
+  not file_waived(g.id, c)
+}
+
+# Ref, branch, environment and always triggers fire regardless of changed
+# files; only whole-gate waivers cover them.
+gate_enforced(g) if {
+  context_triggered(g)
+  not gate_waived(g.id)
+}
+
+context_triggered(g) if {
+  object.get(g, "always", false) == true
+}
+
+context_triggered(g) if {
+  ref := object.get(input, "ref", "")
+  ref != ""
+  some p in object.get(g, "trigger_refs", [])
+  path_match(ref, p)
+}
+
+context_triggered(g) if {
+  branch := object.get(input, "branch", "")
+  branch != ""
+  some p in object.get(g, "trigger_branches", [])
+  path_match(branch, p)
+}
+
+context_triggered(g) if {
+  env := object.get(input, "environment", "")
+  env != ""
+  some p in object.get(g, "trigger_environments", [])
+  path_match(env, p)
+}
+
+waivers := object.get(input, "waivers", [])
+
+gate_waived(id) if {
+  some w in waivers
+  w.gate == id
+  count(w.paths) == 0
+  count(w.statement_ids) == 0
+}
+
+file_waived(id, _) if {
+  gate_waived(id)
+}
+
+file_waived(id, c) if {
+  some w in waivers
+  w.gate == id
+  some p in w.paths
+  path_match(c, p)
 }
 
 path_match(path, pattern) if {
@@ -0,0 +1,42 @@
+package llmsa.gates
+
+# Predicate checks over the full decoded statements (input version 2).
+
+import future.keywords.if
+import future.keywords.in
+
+default result := {"allow": false, "violations": ["rego result unavailable"]}
+
+result := {"allow": count(violations) == 0, "violations": violations}
+
+allowed_embedding_models := {"text-embedding-3-large", "bge-m3"}
+
+violations[msg] if {
+  some b in input.bundles
+  b.statement.attestation_type == "eval_attestation"
+  score := b.statement.predicate.metrics.faithfulness
+  score < 0.9
+  msg := sprintf("eval %s faithfulness %v is below 0.9", [b.statement.statement_id, score])
+}
+
+violations[msg] if {
+  some b in input.bundles
+  b.statement.attestation_type == "eval_attestation"
+  b.statement.predicate.regression_detected
+  msg := sprintf("eval %s reports a regression", [b.statement.statement_id])
+}
+
+violations[msg] if {
+  some b in input.bundles
+  b.statement.attestation_type == "corpus_attestation"
+  model := b.statement.predicate.embedding_model
+  not model in allowed_embedding_models
+  msg := sprintf("corpus %s uses embedding model %s, which is not allowlisted", [b.statement.statement_id, model])
+}
+
+violations[msg] if {
+  some b in input.bundles
+  b.statement.attestation_type == "route_attestation"
+  b.statement.predicate.routing_strategy != "latency_aware"
+  msg := sprintf("route %s must use latency_aware routing", [b.statement.statement_id])
+}
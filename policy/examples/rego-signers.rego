@@ -0,0 +1,43 @@
+package llmsa.gates
+
+# Signer identity checks over bundle signature metadata (input version 2).
+
+import future.keywords.if
+import future.keywords.in
+
+default result := {"allow": false, "violations": ["rego result unavailable"]}
+
+result := {"allow": count(violations) == 0, "violations": violations}
+
+trusted_issuer := "https://token.actions.githubusercontent.com"
+
+trusted_identity := `^https://github\.com/your-org/.+/\.github/workflows/release\.yml@refs/heads/main$`
+
+violations[msg] if {
+  some b in input.bundles
+  count(b.signatures) == 0
+  msg := sprintf("%s is unsigned", [b.path])
+}
+
+violations[msg] if {
+  some b in input.bundles
+  some s in b.signatures
+  s.provider != "sigstore"
+  msg := sprintf("%s is signed with %s key %s; keyless sigstore signing is required", [b.path, s.provider, s.key_id])
+}
+
+violations[msg] if {
+  some b in input.bundles
+  some s in b.signatures
+  s.provider == "sigstore"
+  s.oidc_issuer != trusted_issuer
+  msg := sprintf("%s signed via untrusted issuer %s", [b.path, s.oidc_issuer])
+}
+
+violations[msg] if {
+  some b in input.bundles
+  some s in b.signatures
+  s.provider == "sigstore"
+  not regex.match(trusted_identity, s.oidc_identity)
+  msg := sprintf("%s signed by untrusted identity %s", [b.path, s.oidc_identity])
+}
@@ -0,0 +1,36 @@
+package llmsa.gates
+
+# Gates on verify results and provenance chain edges (input version 2).
+
+import future.keywords.if
+import future.keywords.in
+
+default result := {"allow": false, "violations": ["rego result unavailable"]}
+
+result := {"allow": count(violations) == 0, "violations": violations}
+
+violations["verification results missing from input"] if {
+  not input.verification
+}
+
+violations[msg] if {
+  some c in input.verification.checks
+  not c.passed
+  not c.skipped
+  msg := sprintf("verify check %s failed for %s: %s", [c.check, c.bundle, c.message])
+}
+
+violations[msg] if {
+  some e in input.verification.chain_edges
+  not e.satisfied
+  msg := sprintf("chain edge %s -> %s unsatisfied: %s", [e.from_type, e.to_type, e.detail])
+}
+
+# Production routes must be pinned to the exact eval they were promoted from.
+violations[msg] if {
+  some e in input.verification.chain_edges
+  e.from_type == "route_attestation"
+  e.to_type == "eval_attestation"
+  not e.pinned_digest
+  msg := sprintf("route %s is not pinned to an eval digest", [e.from_statement_id])
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/attest"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/hash"
 	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/sign"
+	"github.com/ogulcanaydogan/llm-supply-chain-attestation/internal/verify"
 )
 
 func repoRoot(t *testing.T) string {
@@ -39,6 +40,9 @@ func createAndSignAttestation(t *testing.T, attType, cfgPath, outDir, keyPath st
 		Type:       attType,
 		ConfigPath: cfgPath,
 		OutDir:     outDir,
+		VerifyUpstream: func(_ string, bundle sign.Bundle) error {
+			return verify.VerifySignature(bundle, verify.SignerPolicy{})
+		},
 	})
 	if err != nil {
 		t.Fatalf("create %s: %v", attType, err)